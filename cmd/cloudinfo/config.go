@@ -28,6 +28,8 @@ import (
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/cistore"
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/loader"
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/management"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/distribution"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/providers/alibaba"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/providers/amazon"
@@ -124,8 +126,34 @@ type configuration struct {
 	Scrape struct {
 		Enabled bool
 
-		// Cloud info scrape interval
+		// Cloud info scrape interval, used when no scrape schedule is set
 		Interval time.Duration
+
+		// Default scrape settings of the providers
+		cloudinfo.ScrapeConfig `mapstructure:",squash"`
+
+		// Provider specific scrape settings, the unset values are taken from the defaults
+		Provider map[string]cloudinfo.ScrapeConfig
+
+		// Leader election among the replicas sharing the store, so that only one of them scrapes a provider
+		LeaderElection cloudinfo.LeaderElectionConfig
+
+		// On demand scraping of the regions requested before being scraped
+		OnDemand struct {
+			Enabled bool
+
+			// Timeout the maximum time a request waits for the scrape of the region
+			Timeout time.Duration
+
+			// FailureCooldown the time a region failed to be scraped is not scraped on demand again
+			FailureCooldown time.Duration
+		}
+	}
+
+	// Offline mode: the data of a snapshot file is served, no provider is scraped
+	Offline struct {
+		// Snapshot the snapshot file to serve
+		Snapshot string
 	}
 
 	// Provider configuration
@@ -179,19 +207,120 @@ type configuration struct {
 	ServiceLoader loader.Config
 
 	Store cistore.Config
+
+	EventBus messaging.Config
 }
 
 // Validate validates the configuration.
 func (c configuration) Validate() error {
 	// TODO: write config validation
 
-	if !c.Scrape.Enabled && !(c.Store.Redis.Enabled || c.Store.Cassandra.Enabled) {
-		return errors.New("storage is required when scraping is disabled")
+	if !c.Scrape.Enabled && !c.offline() && !(c.Store.Redis.Enabled || c.Store.Cassandra.Enabled || c.Store.Bolt.Enabled) {
+		return errors.New("persistent storage or a snapshot is required when scraping is disabled")
+	}
+
+	if c.offline() && c.Store.Snapshot.Enabled {
+		return errors.New("local snapshots are not available in offline mode")
+	}
+
+	if c.Scrape.Concurrency < 1 {
+		return errors.New("scrape concurrency must be positive")
+	}
+
+	if err := c.Scrape.Sanity.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Scrape.RateLimit.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Scrape.Schedule.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Scrape.Regions.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Scrape.LeaderElection.Validate(); err != nil {
+		return err
+	}
+
+	if c.Scrape.OnDemand.Enabled && !c.scraping() {
+		return errors.New("on demand scraping requires scraping to be enabled")
+	}
+
+	if c.Scrape.OnDemand.Enabled && c.Scrape.OnDemand.Timeout <= 0 {
+		return errors.New("on demand scrape timeout must be positive")
+	}
+
+	if c.Scrape.LeaderElection.Enabled && !c.Store.Redis.Enabled {
+		return errors.New("leader election requires the redis store")
+	}
+
+	for _, provider := range c.Scrape.Provider {
+		if err := provider.Sanity.Validate(); err != nil {
+			return err
+		}
+
+		if err := provider.RateLimit.Validate(); err != nil {
+			return err
+		}
+
+		if err := provider.Schedule.Validate(); err != nil {
+			return err
+		}
+
+		if err := provider.Regions.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Store.Bolt.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Store.PriceHistory.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Store.Snapshot.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.EventBus.Validate(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// offline checks whether the data of a snapshot file is served instead of the scraped one
+func (c configuration) offline() bool {
+	return c.Offline.Snapshot != ""
+}
+
+// scraping checks whether the providers are scraped, they are never scraped in offline mode
+func (c configuration) scraping() bool {
+	return c.Scrape.Enabled && !c.offline()
+}
+
+// scrapeConfigs returns the scrape settings of the providers
+func (c configuration) scrapeConfigs(providers []string) map[string]cloudinfo.ScrapeConfig {
+	defaults := c.Scrape.ScrapeConfig
+	if defaults.Schedule.Cron == "" {
+		defaults.Schedule.Cron = fmt.Sprintf("@every %s", c.Scrape.Interval)
+	}
+
+	configs := make(map[string]cloudinfo.ScrapeConfig, len(providers))
+	for _, provider := range providers {
+		configs[provider] = c.Scrape.Provider[provider].WithDefaults(defaults)
+	}
+
+	return configs
+}
+
 // configure configures some defaults in the Viper instance.
 func configure(v *viper.Viper, p *pflag.FlagSet) {
 	// Viper settings
@@ -269,6 +398,40 @@ func configure(v *viper.Viper, p *pflag.FlagSet) {
 	p.Duration("scrape-interval", 24*time.Hour, "duration (in go syntax) between renewing information")
 	_ = v.BindPFlag("scrape.interval", p.Lookup("scrape-interval"))
 
+	p.Int("scrape-concurrency", 4, "maximum number of regions scraped in parallel per provider")
+	_ = v.BindPFlag("scrape.concurrency", p.Lookup("scrape-concurrency"))
+
+	p.String("snapshot", "", "serve the data of the snapshot file, without scraping the providers (offline mode)")
+	_ = v.BindPFlag("offline.snapshot", p.Lookup("snapshot"))
+
+	v.SetDefault("scrape.runHistory", 10)
+	v.SetDefault("scrape.retry.maxRetries", 3)
+	v.SetDefault("scrape.retry.initialBackoff", time.Second)
+	v.SetDefault("scrape.retry.maxBackoff", 30*time.Second)
+	v.SetDefault("scrape.retry.budgetRatio", 0.2)
+	v.SetDefault("scrape.retry.budgetBurst", 10)
+	v.SetDefault("scrape.circuitBreaker.threshold", 5)
+	v.SetDefault("scrape.circuitBreaker.timeout", time.Minute)
+	v.SetDefault("scrape.rateLimit.rate", 0)
+	v.SetDefault("scrape.rateLimit.burst", 1)
+	v.SetDefault("scrape.sanity.action", cloudinfo.SanityActionReject)
+	v.SetDefault("scrape.sanity.maxInstanceDrop", 50)
+	v.SetDefault("scrape.sanity.maxPriceChange", 90)
+	v.SetDefault("scrape.sanity.allowMissingCategories", false)
+	v.SetDefault("scrape.schedule.cron", "")
+	v.SetDefault("scrape.schedule.prices", "@every 4m")
+	v.SetDefault("scrape.schedule.jitter", 0)
+	v.SetDefault("scrape.schedule.quietWindows", []string{})
+	v.SetDefault("scrape.regions.include", []string{})
+	v.SetDefault("scrape.regions.exclude", []string{})
+	v.SetDefault("scrape.events.spotPriceThreshold", 10)
+	v.SetDefault("scrape.onDemand.enabled", false)
+	v.SetDefault("scrape.onDemand.timeout", 30*time.Second)
+	v.SetDefault("scrape.onDemand.failureCooldown", time.Minute)
+	v.SetDefault("scrape.leaderElection.enabled", false)
+	v.SetDefault("scrape.leaderElection.lease", 30*time.Second)
+	v.SetDefault("scrape.leaderElection.renewInterval", 10*time.Second)
+
 	// Amazon config
 	p.Bool("provider-amazon", false, "enable amazon provider")
 	_ = v.BindPFlag("provider.amazon.enabled", p.Lookup("provider-amazon"))
@@ -366,7 +529,36 @@ func configure(v *viper.Viper, p *pflag.FlagSet) {
 	v.SetDefault("store.cassandra.keyspace", "cloudinfo")
 	v.SetDefault("store.cassandra.table", "products")
 
+	// Bolt (embedded, file backed) product store
+	v.SetDefault("store.bolt.enabled", false)
+	v.SetDefault("store.bolt.path", "./data/cloudinfo.db")
+	v.SetDefault("store.bolt.timeout", time.Second)
+
+	// Local cache in front of the Redis, Cassandra or Bolt product store
+	v.SetDefault("store.localCache.enabled", false)
+	v.SetDefault("store.localCache.size", 100000)
+	v.SetDefault("store.localCache.statusCheckInterval", 10*time.Second)
+	v.SetDefault("store.localCache.ttl", time.Minute)
+
+	// Price history, kept in the same backend as the products
+	v.SetDefault("store.priceHistory.enabled", false)
+	v.SetDefault("store.priceHistory.retention", 90*24*time.Hour)
+	v.SetDefault("store.priceHistory.pruneInterval", time.Hour)
+
+	// Snapshots written to the local disk, the most recent one is restored on startup
+	v.SetDefault("store.snapshot.enabled", false)
+	v.SetDefault("store.snapshot.dir", "./data/snapshots")
+	v.SetDefault("store.snapshot.interval", 10*time.Minute)
+	v.SetDefault("store.snapshot.keep", 3)
+
 	// InMemory product store
 	v.SetDefault("store.gocache.expiration", 0)
 	v.SetDefault("store.gocache.cleanupInterval", 0)
+
+	// Event bus, the events are shared by the replicas with the redis or nats backend
+	v.SetDefault("eventBus.backend", messaging.BackendInMemory)
+	v.SetDefault("eventBus.redis.host", "localhost")
+	v.SetDefault("eventBus.redis.port", 6379)
+	v.SetDefault("eventBus.redis.maxLen", 1000)
+	v.SetDefault("eventBus.nats.url", "nats://localhost:4222")
 }
@@ -27,7 +27,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 
@@ -38,6 +40,7 @@ import (
 	_ "github.com/sagikazarmark/viperx/remote/bankvaults"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"logur.dev/logur"
 
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/api"
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/cistore"
@@ -55,6 +58,7 @@ import (
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/providers/digitalocean"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/providers/google"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/providers/oracle"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 	"github.com/banzaicloud/cloudinfo/internal/platform/errorhandler"
 	"github.com/banzaicloud/cloudinfo/internal/platform/log"
@@ -69,82 +73,23 @@ var (
 )
 
 func main() {
-	v, p := viper.New(), pflag.NewFlagSet(friendlyAppName, pflag.ExitOnError)
-	configure(v, p)
-
-	p.String("config", "", "Configuration file")
-	p.Bool("version", false, "Show version information")
-	p.Bool("dump-config", false, "Dump configuration to the console (and exit)")
-
-	_ = p.Parse(os.Args[1:])
-
-	if v, _ := p.GetBool("version"); v {
-		fmt.Printf("%s version %s (%s) built on %s\n", friendlyAppName, version, commitHash, buildDate)
-
-		os.Exit(0)
-	}
-
-	if c, _ := p.GetString("config"); c != "" {
-		v.SetConfigFile(c)
-	}
-
-	err := v.ReadInConfig()
-	_, configFileNotFound := err.(viper.ConfigFileNotFoundError)
-	if !configFileNotFound {
-		emperror.Panic(errors.Wrap(err, "failed to read configuration"))
-	}
-
-	var metaConfig metaConfiguration
-	err = v.UnmarshalKey("config", &metaConfig)
-	emperror.Panic(errors.Wrap(err, "failed to unmarshal meta configuration"))
-
-	err = metaConfig.Validate()
-	emperror.Panic(err)
-
-	if metaConfig.Vault.Enabled {
-		vaultremote.SetErrorHandler(errorhandler.NewPanicHandler())
+	if len(os.Args) > 1 && os.Args[1] == scrapeCommand {
+		runScrape(os.Args[2:])
 
-		u, _ := url.Parse(metaConfig.Vault.Address)
-		q := u.Query()
-		q.Set("token", metaConfig.Vault.Token)
-		u.RawQuery = q.Encode()
-
-		err = v.AddRemoteProvider("bankvaults", u.String(), metaConfig.Vault.SecretPath)
-		emperror.Panic(errors.Wrap(err, "failed to add vault config provider"))
-
-		v.SetConfigType("json")
-		err = v.ReadRemoteConfig()
-		emperror.Panic(errors.Wrap(err, "failed to read remote configuration"))
+		return
 	}
 
-	var config configuration
-	err = v.Unmarshal(&config)
-	emperror.Panic(errors.Wrap(err, "failed to unmarshal configuration"))
-
-	// Create logger (first thing after configuration loading)
-	logger := log.NewLogger(config.Log)
-
-	// Provide some basic context to all log lines
-	logger = log.WithFields(logger, map[string]interface{}{"environment": config.Environment, "application": appName})
-
-	log.SetStandardLogger(logger)
+	if len(os.Args) > 1 && os.Args[1] == recordCommand {
+		runRecord(os.Args[2:])
 
-	if configFileNotFound {
-		logger.Warn("configuration file not found")
+		return
 	}
 
-	err = config.Validate()
-	if err != nil {
-		logger.Error(err.Error())
+	v, p := newFlagSet(friendlyAppName)
 
-		os.Exit(3)
-	}
-
-	if d, _ := p.GetBool("dump-config"); d {
-		fmt.Printf("%+v\n", config)
+	_ = p.Parse(os.Args[1:])
 
-		os.Exit(0)
-	}
+	config, logger := loadConfiguration(v, p)
 
 	// Configure error handler
 	errorHandler := errorhandler.New(logger)
@@ -168,33 +113,93 @@ func main() {
 	cloudInfoLogger := cloudinfoadapter.NewLogger(logger)
 
 	// use the configured store implementation
-	cloudInfoStore := cistore.NewCloudInfoStore(config.Store, cloudInfoLogger)
+	cloudInfoStore := cistore.NewCloudInfoStore(config.Store, buildInfo, cloudInfoLogger)
 	defer cloudInfoStore.Close()
 	if !cloudInfoStore.Ready() {
 		emperror.Panic(errors.New("configured product store not available"))
 	}
 
-	infoers, providers, err := loadInfoers(config, cloudInfoLogger)
-	emperror.Panic(err)
+	priceHistory := cistore.NewPriceHistoryStore(config.Store, cloudInfoLogger)
 
 	reporter := metrics.NewDefaultMetricsReporter()
 
-	eventBus := messaging.NewDefaultEventBus(errorHandler)
+	eventBus, err := messaging.NewEventBus(config.EventBus, errorHandler)
+	emperror.Panic(errors.WrapIf(err, "configured event bus not available"))
+	defer eventBus.Close()
+
+	var (
+		infoers   map[string]cloudinfo.CloudInfoer
+		providers []string
+	)
+
+	if config.offline() {
+		// the providers of the snapshot are served as they are, no credentials are needed
+		header, err := cistore.LoadSnapshotFile(cloudInfoStore, config.Offline.Snapshot)
+		emperror.Panic(errors.WrapIf(err, "failed to load snapshot"))
+
+		providers = header.ProviderNames()
+		logger.Info("serving snapshot in offline mode", map[string]interface{}{
+			"file": config.Offline.Snapshot, "createdAt": header.CreatedAt, "providers": providers,
+		})
+	} else {
+		infoers, providers, err = loadInfoers(config, rateLimitedClients(config, nil, reporter, cloudInfoLogger), cloudInfoLogger)
+		emperror.Panic(err)
+
+		// the snapshot is restored before the services are loaded, so the current service definitions and static data
+		// take the place of the restored ones
+		if config.Store.Snapshot.Enabled {
+			if _, _, err := cistore.RestoreSnapshot(cloudInfoStore, config.Store.Snapshot.Dir, cloudInfoLogger); err != nil {
+				errorHandler.Handle(errors.WrapIf(err, "failed to restore snapshot"))
+			}
+		}
+
+		serviceManager := loader.NewDefaultServiceManager(config.ServiceLoader, cloudInfoStore, cloudInfoLogger, eventBus)
+		serviceManager.ConfigureServices(providers, config.Distribution)
+
+		serviceManager.LoadServiceInformation(providers)
+	}
 
-	serviceManager := loader.NewDefaultServiceManager(config.ServiceLoader, cloudInfoStore, cloudInfoLogger, eventBus)
-	serviceManager.ConfigureServices(providers, config.Distribution)
+	// write snapshots periodically, the most recent one is restored on the next startup
+	if config.Store.Snapshot.Enabled {
+		snapshotExecutor, err := cloudinfo.NewScheduledExecutor(cloudinfo.ScheduleConfig{}, fmt.Sprintf("@every %s", config.Store.Snapshot.Interval), cloudInfoLogger)
+		emperror.Panic(err)
 
-	serviceManager.LoadServiceInformation(providers)
+		snapshotTask := cistore.WriteSnapshots(cloudInfoStore, config.Store.Snapshot, providers, cloudInfoLogger, errorHandler)
+		err = snapshotExecutor.Schedule(context.Background(), snapshotTask)
+		emperror.Panic(err)
+	}
 
-	prodInfo, err := cloudinfo.NewCloudInfo(providers, cloudInfoStore, cloudInfoLogger)
+	ci, err := cloudinfo.NewCloudInfo(providers, cloudInfoStore, priceHistory, cloudInfoLogger)
 	emperror.Panic(err)
 
-	if config.Scrape.Enabled {
-		scrapingDriver := cloudinfo.NewScrapingDriver(config.Scrape.Interval, infoers, cloudInfoStore, eventBus, reporter, tracer, errorHandler, cloudInfoLogger)
+	var prodInfo types.CloudInfo = ci
+
+	if config.scraping() {
+		// every replica scrapes every provider unless a leader is elected for each of them
+		scrapeLock := cloudinfo.NewLocalLock()
+		if config.Scrape.LeaderElection.Enabled {
+			scrapeLock = cistore.NewRedisLock(config.Store.Redis, cloudInfoLogger)
+		}
+		leaderElector := cloudinfo.NewLeaderElector(scrapeLock, config.Scrape.LeaderElection, cloudInfoLogger)
+
+		scrapingDriver := cloudinfo.NewScrapingDriver(infoers, cloudInfoStore, priceHistory, config.scrapeConfigs(providers), leaderElector, eventBus, reporter, tracer, errorHandler, cloudInfoLogger)
 
 		err = scrapingDriver.StartScraping()
 		emperror.Panic(err)
 
+		// the regions requested before being scraped are scraped right away
+		if config.Scrape.OnDemand.Enabled {
+			prodInfo = cloudinfo.NewOnDemandCloudInfo(prodInfo, scrapingDriver, config.Scrape.OnDemand.Timeout,
+				config.Scrape.OnDemand.FailureCooldown, cloudInfoLogger)
+		}
+
+		if priceHistory != nil {
+			pruneTask := cloudinfo.PrunePriceHistory(priceHistory, config.Store.PriceHistory.Retention, cloudInfoLogger, errorHandler)
+
+			err = cloudinfo.NewPeriodicExecutor(config.Store.PriceHistory.PruneInterval, cloudInfoLogger).Execute(context.Background(), pruneTask)
+			emperror.Panic(err)
+		}
+
 		// start the management service
 		// TODO: management requires scraping at the moment. Let's remove that dependency.
 		if config.Management.Enabled {
@@ -202,7 +207,7 @@ func main() {
 		}
 	}
 
-	err = api.ConfigureValidator(providers, prodInfo, cloudInfoLogger)
+	err = api.ConfigureValidator(providers, prodInfo, cloudinfo.NewRegionSelector(config.scrapeConfigs(providers)), cloudInfoLogger)
 	emperror.Panic(err)
 
 	cloudinfoLogger := cloudinfoadapter.NewLogger(logger)
@@ -210,15 +215,21 @@ func main() {
 	serviceService := cloudinfo.NewServiceService(prodInfo)
 	regionService := cloudinfo.NewRegionService(prodInfo)
 	instanceTypeService := cloudinfo.NewInstanceTypeService(prodInfo)
+	priceHistoryService := cloudinfo.NewPriceHistoryService(prodInfo)
+	scrapeRunService := cloudinfo.NewScrapeRunService(prodInfo)
 	endpoints := cloudinfodriver.MakeEndpoints(instanceTypeService)
 	providerEndpoints := cloudinfodriver.MakeProviderEndpoints(providerService, cloudinfoLogger)
 	serviceEndpoints := cloudinfodriver.MakeServiceEndpoints(serviceService, cloudinfoLogger)
 	regionEndpoints := cloudinfodriver.MakeRegionEndpoints(regionService, cloudinfoLogger)
+	priceHistoryEndpoints := cloudinfodriver.MakePriceHistoryEndpoints(priceHistoryService, cloudinfoLogger)
+	scrapeRunEndpoints := cloudinfodriver.MakeScrapeRunEndpoints(scrapeRunService, cloudinfoLogger)
 	graphqlHandler := cloudinfodriver.MakeGraphQLHandler(
 		endpoints,
 		providerEndpoints,
 		serviceEndpoints,
 		regionEndpoints,
+		priceHistoryEndpoints,
+		scrapeRunEndpoints,
 		errorHandler,
 	)
 
@@ -240,7 +251,120 @@ func main() {
 	emperror.Panic(errors.Wrap(err, "failed to run router"))
 }
 
-func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]cloudinfo.CloudInfoer, []string, error) {
+// newFlagSet returns the configuration and the command line flags of a command
+func newFlagSet(name string) (*viper.Viper, *pflag.FlagSet) {
+	v, p := viper.New(), pflag.NewFlagSet(name, pflag.ExitOnError)
+	configure(v, p)
+
+	p.String("config", "", "Configuration file")
+	p.Bool("version", false, "Show version information")
+	p.Bool("dump-config", false, "Dump configuration to the console (and exit)")
+
+	return v, p
+}
+
+// loadConfiguration loads the configuration of the parsed command line, the application exits if it's invalid
+func loadConfiguration(v *viper.Viper, p *pflag.FlagSet) (configuration, logur.Logger) {
+	if v, _ := p.GetBool("version"); v {
+		fmt.Printf("%s version %s (%s) built on %s\n", friendlyAppName, version, commitHash, buildDate)
+
+		os.Exit(0)
+	}
+
+	if c, _ := p.GetString("config"); c != "" {
+		v.SetConfigFile(c)
+	}
+
+	err := v.ReadInConfig()
+	_, configFileNotFound := err.(viper.ConfigFileNotFoundError)
+	if !configFileNotFound {
+		emperror.Panic(errors.Wrap(err, "failed to read configuration"))
+	}
+
+	var metaConfig metaConfiguration
+	err = v.UnmarshalKey("config", &metaConfig)
+	emperror.Panic(errors.Wrap(err, "failed to unmarshal meta configuration"))
+
+	err = metaConfig.Validate()
+	emperror.Panic(err)
+
+	if metaConfig.Vault.Enabled {
+		vaultremote.SetErrorHandler(errorhandler.NewPanicHandler())
+
+		u, _ := url.Parse(metaConfig.Vault.Address)
+		q := u.Query()
+		q.Set("token", metaConfig.Vault.Token)
+		u.RawQuery = q.Encode()
+
+		err = v.AddRemoteProvider("bankvaults", u.String(), metaConfig.Vault.SecretPath)
+		emperror.Panic(errors.Wrap(err, "failed to add vault config provider"))
+
+		v.SetConfigType("json")
+		err = v.ReadRemoteConfig()
+		emperror.Panic(errors.Wrap(err, "failed to read remote configuration"))
+	}
+
+	var config configuration
+	err = v.Unmarshal(&config)
+	emperror.Panic(errors.Wrap(err, "failed to unmarshal configuration"))
+
+	// Create logger (first thing after configuration loading)
+	logger := log.NewLogger(config.Log)
+
+	// Provide some basic context to all log lines
+	logger = log.WithFields(logger, map[string]interface{}{"environment": config.Environment, "application": appName})
+
+	log.SetStandardLogger(logger)
+
+	if configFileNotFound {
+		logger.Warn("configuration file not found")
+	}
+
+	err = config.Validate()
+	if err != nil {
+		logger.Error(err.Error())
+
+		os.Exit(3)
+	}
+
+	if d, _ := p.GetBool("dump-config"); d {
+		fmt.Printf("%+v\n", config)
+
+		os.Exit(0)
+	}
+
+	return config, logger
+}
+
+// rateLimitedClients returns the HTTP clients of the providers sending the API requests within the configured rate limits
+// the transports of the given clients are wrapped, the clients of the providers without rate limits are left as they are
+func rateLimitedClients(config configuration, httpClients map[string]*http.Client, reporter metrics.Reporter, logger cloudinfo.Logger) map[string]*http.Client {
+	providers := []string{Amazon, Google, Alibaba, Oracle, Azure, Digitalocean}
+	scrapeConfigs := config.scrapeConfigs(providers)
+
+	clients := make(map[string]*http.Client, len(providers))
+	for _, provider := range providers {
+		client := httpClients[provider]
+
+		if rateLimit := scrapeConfigs[provider].RateLimit; rateLimit.LimitsRequests() {
+			limited := &http.Client{}
+			if client != nil {
+				*limited = *client
+			}
+			limited.Transport = cloudinfo.NewRateLimitedTransport(provider, limited.Transport, rateLimit, reporter, logger)
+
+			client = limited
+		}
+
+		clients[provider] = client
+	}
+
+	return clients
+}
+
+// loadInfoers creates the infoers of the enabled providers
+// the API calls of a provider are sent with its HTTP client if there is one, with the default clients otherwise
+func loadInfoers(config configuration, httpClients map[string]*http.Client, logger cloudinfo.Logger) (map[string]cloudinfo.CloudInfoer, []string, error) {
 	infoers := map[string]cloudinfo.CloudInfoer{}
 
 	var providers []string
@@ -249,7 +373,7 @@ func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]clou
 		providers = append(providers, Amazon)
 		logger := logger.WithFields(map[string]interface{}{"provider": Amazon})
 
-		infoer, err := amazon.NewAmazonInfoer(config.Provider.Amazon.Config, logger)
+		infoer, err := amazon.NewAmazonInfoer(config.Provider.Amazon.Config, httpClients[Amazon], logger)
 		if err != nil {
 			return nil, nil, errors.WithDetails(err, "provider", Amazon)
 		}
@@ -263,7 +387,7 @@ func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]clou
 		providers = append(providers, Google)
 		logger := logger.WithFields(map[string]interface{}{"provider": Google})
 
-		infoer, err := google.NewGoogleInfoer(config.Provider.Google.Config, logger)
+		infoer, err := google.NewGoogleInfoer(config.Provider.Google.Config, httpClients[Google], logger)
 		if err != nil {
 			return nil, nil, emperror.With(err, "provider", Google)
 		}
@@ -277,7 +401,7 @@ func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]clou
 		providers = append(providers, Alibaba)
 		logger := logger.WithFields(map[string]interface{}{"provider": Alibaba})
 
-		infoer, err := alibaba.NewAlibabaInfoer(config.Provider.Alibaba.Config, logger)
+		infoer, err := alibaba.NewAlibabaInfoer(config.Provider.Alibaba.Config, httpClients[Alibaba], logger)
 		if err != nil {
 			return nil, nil, emperror.With(err, "provider", Alibaba)
 		}
@@ -291,7 +415,7 @@ func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]clou
 		providers = append(providers, Oracle)
 		logger := logger.WithFields(map[string]interface{}{"provider": Oracle})
 
-		infoer, err := oracle.NewOracleInfoer(config.Provider.Oracle.Config, logger)
+		infoer, err := oracle.NewOracleInfoer(config.Provider.Oracle.Config, httpClients[Oracle], logger)
 		if err != nil {
 			return nil, nil, emperror.With(err, "provider", Oracle)
 		}
@@ -305,7 +429,7 @@ func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]clou
 		providers = append(providers, Azure)
 		logger := logger.WithFields(map[string]interface{}{"provider": Azure})
 
-		infoer, err := azure.NewAzureInfoer(config.Provider.Azure.Config, logger)
+		infoer, err := azure.NewAzureInfoer(config.Provider.Azure.Config, httpClients[Azure], logger)
 		if err != nil {
 			return nil, nil, emperror.With(err, "provider", Azure)
 		}
@@ -319,7 +443,7 @@ func loadInfoers(config configuration, logger cloudinfo.Logger) (map[string]clou
 		providers = append(providers, Digitalocean)
 		logger := logger.WithFields(map[string]interface{}{"provider": Digitalocean})
 
-		infoer, err := digitalocean.NewDigitaloceanInfoer(config.Provider.Digitalocean.Config, logger)
+		infoer, err := digitalocean.NewDigitaloceanInfoer(config.Provider.Digitalocean.Config, httpClients[Digitalocean], logger)
 		if err != nil {
 			return nil, nil, emperror.With(err, "provider", Digitalocean)
 		}
@@ -0,0 +1,202 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/cistore"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/loader"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
+	"github.com/banzaicloud/cloudinfo/internal/platform/errorhandler"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+const (
+	// scrapeCommand scrapes the providers once and writes the result into a snapshot file
+	scrapeCommand = "scrape"
+
+	// recordCommand scrapes the providers once and records their HTTP traffic into cassettes
+	recordCommand = "record"
+)
+
+// runScrape scrapes the selected providers, services and regions once and writes the result into a snapshot file
+// the HTTP server is not started; the application exits with 1 if a scrape failed, the snapshot is written anyway
+func runScrape(args []string) {
+	v, p := newFlagSet(fmt.Sprintf("%s %s", friendlyAppName, scrapeCommand))
+
+	addScrapeFlags(p)
+	p.String("output", "snapshot.json.gz", "the snapshot file to write")
+
+	_ = p.Parse(args)
+
+	store, logger, errorHandler, scrapeErr := scrapeOnce(v, p, nil)
+
+	output, _ := p.GetString("output")
+
+	if err := cistore.ExportSnapshotFile(store, output); err != nil {
+		errorHandler.Handle(errors.WrapIf(err, "failed to write snapshot"))
+
+		os.Exit(1)
+	}
+
+	logger.Info("snapshot written", map[string]interface{}{"file": output})
+
+	if scrapeErr != nil {
+		os.Exit(1)
+	}
+}
+
+// runRecord scrapes the selected providers, services and regions once like runScrape,
+// but records the HTTP traffic of the providers into cassettes (<output>/<provider>.json) instead of writing a snapshot
+// the cassettes are replayed by the tests of the providers, so they can be run without credentials
+func runRecord(args []string) {
+	v, p := newFlagSet(fmt.Sprintf("%s %s", friendlyAppName, recordCommand))
+
+	addScrapeFlags(p)
+	p.String("output", "testdata", "the directory the cassettes are written into")
+
+	_ = p.Parse(args)
+
+	recorders := make(map[string]*httprecord.Transport)
+	httpClients := make(map[string]*http.Client)
+	for _, provider := range []string{Amazon, Google, Alibaba, Oracle, Azure, Digitalocean} {
+		recorders[provider] = httprecord.NewRecorder(nil)
+		httpClients[provider] = recorders[provider].Client()
+	}
+
+	_, logger, errorHandler, scrapeErr := scrapeOnce(v, p, httpClients)
+
+	output, _ := p.GetString("output")
+
+	for provider, recorder := range recorders {
+		if len(recorder.Cassette().Interactions) == 0 {
+			continue
+		}
+
+		path := filepath.Join(output, fmt.Sprintf("%s.json", provider))
+		if err := recorder.Save(path); err != nil {
+			errorHandler.Handle(errors.WithDetails(err, "provider", provider))
+
+			os.Exit(1)
+		}
+
+		logger.Info("cassette written", map[string]interface{}{"provider": provider, "file": path})
+	}
+
+	if scrapeErr != nil {
+		os.Exit(1)
+	}
+}
+
+func addScrapeFlags(p *pflag.FlagSet) {
+	p.StringSlice("provider", nil, "the providers to scrape, they are enabled regardless of the provider flags (all the enabled ones by default)")
+	p.StringSlice("service", nil, "the services to scrape (all of them by default)")
+	p.StringSlice("region", nil, "the regions to scrape (all of them by default)")
+}
+
+// scrapeOnce scrapes the providers, services and regions selected by the flags once into an in-memory store
+// the API calls of the providers are sent with their HTTP clients if there are any
+// the application exits if the configuration is invalid, the error of the scrape is handled and returned
+func scrapeOnce(v *viper.Viper, p *pflag.FlagSet, httpClients map[string]*http.Client) (cloudinfo.CloudInfoStore, logur.Logger, emperror.ErrorHandler, error) {
+	selectedProviders, _ := p.GetStringSlice("provider")
+	for _, provider := range selectedProviders {
+		v.Set(fmt.Sprintf("provider.%s.enabled", provider), true)
+	}
+
+	config, logger := loadConfiguration(v, p)
+
+	errorHandler := errorhandler.New(logger)
+	defer emperror.HandleRecover(errorHandler)
+
+	buildInfo := buildinfo.New(version, commitHash, buildDate)
+
+	logger.Info("scraping providers", buildInfo.Fields())
+
+	cloudInfoLogger := cloudinfoadapter.NewLogger(logger)
+
+	// the scraped data is kept in memory till it's written into the snapshot
+	store := cistore.NewCacheProductStore(0, 0, buildInfo, cloudInfoLogger)
+
+	infoers, providers, err := loadInfoers(config, rateLimitedClients(config, httpClients, metrics.NewNoOpMetricsReporter(), cloudInfoLogger), cloudInfoLogger)
+	if err != nil {
+		errorHandler.Handle(errors.WrapIf(err, "failed to configure providers"))
+
+		os.Exit(1)
+	}
+
+	eventBus := messaging.NewDefaultEventBus(errorHandler)
+
+	serviceManager := loader.NewDefaultServiceManager(config.ServiceLoader, store, cloudInfoLogger, eventBus)
+	serviceManager.ConfigureServices(providers, config.Distribution)
+
+	serviceManager.LoadServiceInformation(providers)
+
+	leaderElector := cloudinfo.NewLeaderElector(cloudinfo.NewLocalLock(), cloudinfo.LeaderElectionConfig{}, cloudInfoLogger)
+	scrapingDriver := cloudinfo.NewScrapingDriver(infoers, store, nil, config.scrapeConfigs(providers), leaderElector, eventBus,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), errorHandler, cloudInfoLogger)
+
+	// the providers having static data only (no infoer) are not scraped, their data is loaded already
+	scrapedProviders := make([]string, 0, len(selectedProviders))
+	for _, provider := range selectedProviders {
+		if !cloudinfo.Contains(providers, provider) {
+			logger.Error("unsupported provider", map[string]interface{}{"provider": provider})
+
+			os.Exit(3)
+		}
+
+		if _, ok := infoers[provider]; ok {
+			scrapedProviders = append(scrapedProviders, provider)
+		}
+	}
+
+	services, _ := p.GetStringSlice("service")
+	regions, _ := p.GetStringSlice("region")
+
+	var scrapeErr error
+	if len(selectedProviders) == 0 || len(scrapedProviders) > 0 {
+		scrapeErr = scrapingDriver.ScrapeOnce(context.Background(), cloudinfo.ScrapeFilter{
+			Providers: scrapedProviders,
+			Services:  services,
+			Regions:   regions,
+		})
+	}
+	if scrapeErr != nil {
+		scrapeErr = errors.WrapIf(scrapeErr, "failed to scrape cloud information")
+		errorHandler.Handle(scrapeErr)
+	}
+
+	// the subscribers of the scrape events (eg. the loaders of the derived services) finish before the store is written
+	if err := eventBus.Close(); err != nil {
+		errorHandler.Handle(errors.WrapIf(err, "failed to close event bus"))
+	}
+
+	return store, logger, errorHandler, scrapeErr
+}
@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/gin-gonic/gin"
@@ -100,6 +101,62 @@ func (r *RouteHandler) getProvider() gin.HandlerFunc {
 	}
 }
 
+// swagger:route GET /providers/{provider}/runs provider getScrapeRuns
+//
+// Provides the recorded scrape runs of the provider
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: ScrapeRunsResponse
+func (r *RouteHandler) getScrapeRuns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetProviderPathParams{}
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			r.errorResponder.Respond(c, errors.WithDetails(err, "validation"))
+			return
+		}
+		queryParams := GetScrapeRunsQueryParams{}
+		if err := mapstructure.Decode(getQueryAsMap(c), &queryParams); err != nil {
+			r.errorResponder.Respond(c, errors.WithDetails(err, "validation"))
+			return
+		}
+
+		if ve := ValidatePathData(pathParams); ve != nil {
+			r.errorResponder.Respond(c, errors.WithDetails(ve, "validation"))
+			return
+		}
+
+		switch queryParams.Outcome {
+		case "", types.ScrapeRunSucceeded, types.ScrapeRunFailed, types.ScrapeRunRejected:
+		default:
+			r.errorResponder.Respond(c, errors.WithDetails(errors.New("outcome must be one of success, failure and rejected"), "validation"))
+			return
+		}
+
+		logger := log.WithFieldsForHandlers(c, r.log, map[string]interface{}{"provider": pathParams.Provider})
+		logger.Info("getting scrape runs")
+
+		runs, err := r.prod.GetScrapeRuns(pathParams.Provider, types.ScrapeRunFilter{
+			Service: queryParams.Service,
+			Region:  queryParams.Region,
+			Outcome: queryParams.Outcome,
+		})
+		if err != nil {
+			r.errorResponder.Respond(c, errors.WrapIfWithDetails(err, "failed to retrieve scrape runs", "provider", pathParams.Provider))
+			return
+		}
+
+		logger.Debug("successfully retrieved scrape runs")
+		c.JSON(http.StatusOK, ScrapeRunsResponse{runs})
+	}
+}
+
 // swagger:route GET /providers/{provider}/services services getServices
 //
 // Provides a list with the available services for the provider
@@ -282,9 +339,13 @@ func (r *RouteHandler) getRegions() gin.HandlerFunc {
 		}
 		var response RegionsResponse
 		for id, name := range regions {
+			status, _ := r.prod.GetRegionStatus(pathParams.Provider, pathParams.Service, id)
 			response = append(response, types.Region{
-				ID:   id,
-				Name: name,
+				ID:           id,
+				Name:         name,
+				Stale:        status.Stale,
+				LastSuccess:  status.LastSuccessTime(),
+				SnapshotTime: status.SnapshotTime,
 			})
 		}
 
@@ -337,7 +398,15 @@ func (r *RouteHandler) getRegion() gin.HandlerFunc {
 		}
 
 		logger.Debug("successfully retrieved region details")
-		c.JSON(http.StatusOK, GetRegionResp{pathParams.Region, regions[pathParams.Region], zones})
+		status, _ := r.prod.GetRegionStatus(pathParams.Provider, pathParams.Service, pathParams.Region)
+		c.JSON(http.StatusOK, GetRegionResp{
+			Id:           pathParams.Region,
+			Name:         regions[pathParams.Region],
+			Zones:        zones,
+			Stale:        status.Stale,
+			LastSuccess:  status.LastSuccessTime(),
+			SnapshotTime: status.SnapshotTime,
+		})
 	}
 }
 
@@ -386,7 +455,86 @@ func (r *RouteHandler) getProducts() gin.HandlerFunc {
 		}
 
 		logger.Debug("successfully retrieved product details")
-		c.JSON(http.StatusOK, ProductDetailsResponse{details, scrapingTime})
+		status, _ := r.prod.GetRegionStatus(pathParams.Provider, pathParams.Service, pathParams.Region)
+		c.JSON(http.StatusOK, ProductDetailsResponse{
+			Products:     details,
+			ScrapingTime: scrapingTime,
+			Stale:        status.Stale,
+			LastSuccess:  status.LastSuccessTime(),
+			SnapshotTime: status.SnapshotTime,
+		})
+	}
+}
+
+// swagger:route GET /providers/{provider}/services/{service}/regions/{region}/products/{instanceType}/prices products getPriceHistory
+//
+// Provides the price history of a machine type on a given provider in a specific region.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: PriceHistoryResponse
+func (r *RouteHandler) getPriceHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetPriceHistoryPathParams{}
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			r.errorResponder.Respond(c, errors.WithDetails(err, "validation"))
+			return
+		}
+		queryParams := GetPriceHistoryQueryParams{}
+		if err := mapstructure.Decode(getQueryAsMap(c), &queryParams); err != nil {
+			r.errorResponder.Respond(c, errors.WithDetails(err, "validation"))
+			return
+		}
+
+		if ve := ValidatePathData(pathParams); ve != nil {
+			r.errorResponder.Respond(c, errors.WithDetails(ve, "validation"))
+			return
+		}
+
+		to := time.Now()
+		if queryParams.To != "" {
+			t, err := time.Parse(time.RFC3339, queryParams.To)
+			if err != nil {
+				r.errorResponder.Respond(c, errors.WithDetails(errors.WrapIf(err, "invalid end of time range"), "validation"))
+				return
+			}
+			to = t
+		}
+
+		from := to.Add(-24 * time.Hour)
+		if queryParams.From != "" {
+			t, err := time.Parse(time.RFC3339, queryParams.From)
+			if err != nil {
+				r.errorResponder.Respond(c, errors.WithDetails(errors.WrapIf(err, "invalid start of time range"), "validation"))
+				return
+			}
+			from = t
+		}
+
+		if from.After(to) {
+			r.errorResponder.Respond(c, errors.WithDetails(errors.New("the start of the time range must not be after its end"), "validation"))
+			return
+		}
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "region": pathParams.Region, "instanceType": pathParams.InstanceType})
+		logger.Info("getting price history")
+
+		prices, err := r.prod.GetPriceHistory(pathParams.Provider, pathParams.Region, pathParams.InstanceType, from, to)
+		if err != nil {
+			r.errorResponder.Respond(c, errors.WrapIfWithDetails(err, "failed to retrieve price history",
+				"provider", pathParams.Provider, "region", pathParams.Region, "instanceType", pathParams.InstanceType))
+			return
+		}
+
+		logger.Debug("successfully retrieved price history")
+		c.JSON(http.StatusOK, PriceHistoryResponse{prices})
 	}
 }
 
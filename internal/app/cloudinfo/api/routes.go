@@ -108,6 +108,7 @@ func (r *RouteHandler) ConfigureRoutes(router *gin.Engine, basePath string) {
 	{
 		providerGroup.GET("/", r.getProviders())
 		providerGroup.GET("/:provider", r.getProvider())
+		providerGroup.GET("/:provider/runs", r.getScrapeRuns())
 		providerGroup.GET("/:provider/services", r.getServices())
 		providerGroup.GET("/:provider/services/:service", r.getService())
 		providerGroup.GET("/:provider/services/:service/continents", r.getContinentsData())
@@ -116,6 +117,7 @@ func (r *RouteHandler) ConfigureRoutes(router *gin.Engine, basePath string) {
 		providerGroup.GET("/:provider/services/:service/regions/:region/images", r.getImages())
 		providerGroup.GET("/:provider/services/:service/regions/:region/versions", r.getVersions())
 		providerGroup.GET("/:provider/services/:service/regions/:region/products", r.getProducts())
+		providerGroup.GET("/:provider/services/:service/regions/:region/products/:instanceType/prices", r.getPriceHistory())
 	}
 
 	base.POST("/graphql", r.query())
@@ -15,6 +15,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 )
 
@@ -66,6 +68,39 @@ type GetImagesQueryParams struct {
 	LatestOnly string `json:"latestOnly"`
 }
 
+// GetPriceHistoryPathParams is a placeholder for the price history route's path parameters
+// swagger:parameters getPriceHistory
+type GetPriceHistoryPathParams struct {
+	GetRegionPathParams `binding:"required" mapstructure:",squash"`
+	// in:path
+	InstanceType string `binding:"required" json:"instanceType"`
+}
+
+// GetPriceHistoryQueryParams is a placeholder for the price history query parameters
+// swagger:parameters getPriceHistory
+type GetPriceHistoryQueryParams struct {
+	// the start of the time range in RFC3339 format, defaults to a day before the end of the range
+	// in:query
+	From string `json:"from,omitempty"`
+	// the end of the time range in RFC3339 format, defaults to the current time
+	// in:query
+	To string `json:"to,omitempty"`
+}
+
+// GetScrapeRunsQueryParams is a placeholder for the scrape runs query parameters
+// swagger:parameters getScrapeRuns
+type GetScrapeRunsQueryParams struct {
+	// selects the runs of a service
+	// in:query
+	Service string `json:"service,omitempty"`
+	// selects the runs of a region
+	// in:query
+	Region string `json:"region,omitempty"`
+	// selects the runs with an outcome: success, failure or rejected
+	// in:query
+	Outcome string `json:"outcome,omitempty"`
+}
+
 // ProductDetailsResponse Api object to be mapped to product info response
 // swagger:model ProductDetailsResponse
 type ProductDetailsResponse struct {
@@ -73,6 +108,25 @@ type ProductDetailsResponse struct {
 	Products []types.ProductDetails `json:"products"`
 	// ScrapingTime represents scraping time for a given provider in milliseconds
 	ScrapingTime string `json:"scrapingTime"`
+	// Stale is set if the last scrape of the region failed, the products are from the last successful scrape
+	Stale bool `json:"stale"`
+	// LastSuccess the time of the last successful scrape of the region
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// SnapshotTime the time of the snapshot the data of the region was restored from, unset once it's scraped again
+	SnapshotTime *time.Time `json:"snapshotTime,omitempty"`
+}
+
+// PriceHistoryResponse holds the price points of an instance type in chronological order
+// the first point may precede the requested time range, it holds the price at the start of the range
+// swagger:model PriceHistoryResponse
+type PriceHistoryResponse struct {
+	Prices []types.PricePoint `json:"prices"`
+}
+
+// ScrapeRunsResponse holds the recorded scrape runs of a provider in chronological order
+// swagger:model ScrapeRunsResponse
+type ScrapeRunsResponse struct {
+	Runs []types.ScrapeRun `json:"runs"`
 }
 
 // RegionsResponse holds the list of available regions of a cloud provider
@@ -99,6 +153,12 @@ type GetRegionResp struct {
 	Id    string   `json:"id"`
 	Name  string   `json:"name"`
 	Zones []string `json:"zones"`
+	// Stale is set if the last scrape of the region failed, the data of the region is from the last successful scrape
+	Stale bool `json:"stale"`
+	// LastSuccess the time of the last successful scrape of the region
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// SnapshotTime the time of the snapshot the data of the region was restored from, unset once it's scraped again
+	SnapshotTime *time.Time `json:"snapshotTime,omitempty"`
 }
 
 // AttributeResponse holds attribute values
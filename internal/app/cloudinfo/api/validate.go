@@ -24,7 +24,7 @@ import (
 )
 
 // ConfigureValidator configures the Gin validator with custom validator functions
-func ConfigureValidator(providers []string, ci types.CloudInfo, logger cloudinfo.Logger) error {
+func ConfigureValidator(providers []string, ci types.CloudInfo, regions cloudinfo.RegionSelector, logger cloudinfo.Logger) error {
 	// retrieve the gin validator
 	v := binding.Validator.Engine().(*validator.Validate)
 
@@ -39,15 +39,15 @@ func ConfigureValidator(providers []string, ci types.CloudInfo, logger cloudinfo
 	}
 
 	// register validator for the region parameter in the request path
-	if err := v.RegisterValidation("region", regionValidator(ci, logger)); err != nil {
+	if err := v.RegisterValidation("region", regionValidator(ci, regions, logger)); err != nil {
 		return errors.Wrap(err, "could not register region validator")
 	}
 
 	return nil
 }
 
-// regionValidator validates the `region` path parameter
-func regionValidator(cpi types.CloudInfo, logger cloudinfo.Logger) validator.Func {
+// regionValidator validates the `region` path parameter, the regions left out by the configuration are rejected
+func regionValidator(cpi types.CloudInfo, selected cloudinfo.RegionSelector, logger cloudinfo.Logger) validator.Func {
 	return func(fl validator.FieldLevel) bool {
 		currentStruct, _, _, ok := fl.GetStructFieldOK2()
 		if !ok {
@@ -60,6 +60,11 @@ func regionValidator(cpi types.CloudInfo, logger cloudinfo.Logger) validator.Fun
 
 		logger = logger.WithFields(map[string]interface{}{"provider": regionPathParams.Provider, "service": regionPathParams.Service, "region": regionPathParams.Region})
 
+		if !selected(regionPathParams.Provider, regionPathParams.Service, regionPathParams.Region) {
+			logger.Debug("validation failed, the region is not selected by the configuration")
+			return false
+		}
+
 		regions, err := cpi.GetRegions(regionPathParams.Provider, regionPathParams.Service)
 		if err != nil {
 			logger.Error("validation failed, could not retrieve regions")
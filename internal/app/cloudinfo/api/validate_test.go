@@ -19,6 +19,11 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 )
 
 func TestGetProviderPathParamsValidation(t *testing.T) {
@@ -63,3 +68,59 @@ func TestGetProviderPathParamsValidation(t *testing.T) {
 		})
 	}
 }
+
+// regionCloudInfo lists the eu-west-1 and us-east-1 regions
+type regionCloudInfo struct {
+	// implement the interface
+	types.CloudInfo
+}
+
+func (rci regionCloudInfo) GetRegions(provider, service string) (map[string]string, error) {
+	return map[string]string{"eu-west-1": "EU (Ireland)", "us-east-1": "US East (N. Virginia)"}, nil
+}
+
+func TestGetRegionPathParamsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		valid  bool
+	}{
+		{name: "selected region", region: "eu-west-1", valid: true},
+		{name: "region left out by the configuration", region: "us-east-1", valid: false},
+		{name: "unknown region", region: "eu-central-1", valid: false},
+	}
+
+	// setup the validator
+	v := validator.New()
+	v.SetTagName("binding")
+	if err := v.RegisterValidation("provider", providerValidator([]string{"amazon"})); err != nil {
+		t.Fatal("failed to register provider validator")
+	}
+	if err := v.RegisterValidation("service", func(validator.FieldLevel) bool { return true }); err != nil {
+		t.Fatal("failed to register service validator")
+	}
+
+	selector := cloudinfo.NewRegionSelector(map[string]cloudinfo.ScrapeConfig{
+		"amazon": {Regions: cloudinfo.RegionsConfig{RegionFilter: cloudinfo.RegionFilter{Include: []string{"eu-*"}}}},
+	})
+	logger := cloudinfoadapter.NewLogger(&logur.TestLogger{})
+	if err := v.RegisterValidation("region", regionValidator(regionCloudInfo{}, selector, logger)); err != nil {
+		t.Fatal("failed to register region validator")
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := GetRegionPathParams{
+				GetServicesPathParams: GetServicesPathParams{GetProviderPathParams: GetProviderPathParams{Provider: "amazon"}, Service: "compute"},
+				Region:                test.region,
+			}
+
+			err := v.Struct(params)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,519 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"go.etcd.io/bbolt"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/bolt"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
+)
+
+const (
+	// boltImportBatchSize the number of entries written in a single transaction
+	boltImportBatchSize = 1000
+)
+
+// boltBucket the bucket holding the cloud information
+// nolint: gochecknoglobals
+var boltBucket = []byte("products")
+
+// boltProductStore file backed cloud product information storer
+// values are stored in their json representation, like in the redis and cassandra stores
+type boltProductStore struct {
+	config    bolt.Config
+	db        *bbolt.DB
+	mu        sync.Mutex
+	buildInfo buildinfo.BuildInfo
+	log       cloudinfo.Logger
+}
+
+// NewBoltProductStore creates a new store instance backed by the database file in the configuration
+// the file is opened on first use
+func NewBoltProductStore(config bolt.Config, buildInfo buildinfo.BuildInfo, log cloudinfo.Logger) cloudinfo.CloudInfoStore {
+	return &boltProductStore{
+		config:    config,
+		buildInfo: buildInfo,
+		log:       log.WithFields(map[string]interface{}{"cistore": "bolt"}),
+	}
+}
+
+func (bps *boltProductStore) Ready() bool {
+	if _, err := bps.initDB(); err != nil {
+		bps.log.Error("failure checking bolt ready", map[string]interface{}{"error": err})
+		return false
+	}
+	bps.log.Debug("bolt product store ready")
+	return true
+}
+
+func (bps *boltProductStore) StoreRegions(provider, service string, val map[string]string) {
+	bps.set(bps.getKey(cloudinfo.RegionKeyTemplate, provider, service), val)
+}
+
+func (bps *boltProductStore) GetRegions(provider, service string) (map[string]string, bool) {
+	res := make(map[string]string)
+	ok := bps.get(bps.getKey(cloudinfo.RegionKeyTemplate, provider, service), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) DeleteRegions(provider, service string) {
+	bps.delete(bps.getKey(cloudinfo.RegionKeyTemplate, provider, service))
+}
+
+func (bps *boltProductStore) StoreZones(provider, service, region string, val []string) {
+	bps.set(bps.getKey(cloudinfo.ZoneKeyTemplate, provider, service, region), val)
+}
+
+func (bps *boltProductStore) GetZones(provider, service, region string) ([]string, bool) {
+	res := make([]string, 0)
+	ok := bps.get(bps.getKey(cloudinfo.ZoneKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) DeleteZones(provider, service, region string) {
+	bps.delete(bps.getKey(cloudinfo.ZoneKeyTemplate, provider, service, region))
+}
+
+func (bps *boltProductStore) StorePrice(provider, region, instanceType string, val types.Price) {
+	bps.set(bps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType), val)
+}
+
+func (bps *boltProductStore) GetPrice(provider, region, instanceType string) (types.Price, bool) {
+	var res types.Price
+	ok := bps.get(bps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType), &res)
+
+	return res, ok
+}
+
+// GetPrices retrieves the prices in a single transaction
+func (bps *boltProductStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	prices := make(map[string]types.Price, len(instanceTypes))
+
+	db, err := bps.initDB()
+	if err != nil {
+		bps.log.Error("failed to open the database")
+		return prices, false
+	}
+
+	_ = db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		for _, instanceType := range instanceTypes {
+			key := bps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType)
+
+			value := b.Get([]byte(key))
+			if value == nil {
+				continue
+			}
+
+			var price types.Price
+			if err := json.Unmarshal(value, &price); err != nil {
+				bps.log.Debug("failed to unmarshal cache entry", map[string]interface{}{"key": key})
+				continue
+			}
+
+			prices[instanceType] = price
+		}
+
+		return nil
+	})
+
+	return prices, len(prices) > 0
+}
+
+func (bps *boltProductStore) StoreVm(provider, service, region string, val []types.VMInfo) {
+	bps.set(bps.getKey(cloudinfo.VmKeyTemplate, provider, service, region), val)
+}
+
+func (bps *boltProductStore) GetVm(provider, service, region string) ([]types.VMInfo, bool) {
+	res := make([]types.VMInfo, 0)
+	ok := bps.get(bps.getKey(cloudinfo.VmKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) DeleteVm(provider, service, region string) {
+	bps.delete(bps.getKey(cloudinfo.VmKeyTemplate, provider, service, region))
+}
+
+func (bps *boltProductStore) StoreProductDetails(provider, service, region string, val []types.ProductDetails) {
+	bps.set(bps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), val)
+}
+
+func (bps *boltProductStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	res := make([]types.ProductDetails, 0)
+	ok := bps.get(bps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) DeleteProductDetails(provider, service, region string) {
+	bps.delete(bps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region))
+}
+
+func (bps *boltProductStore) StoreImage(provider, service, regionId string, val []types.Image) {
+	bps.set(bps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId), val)
+}
+
+func (bps *boltProductStore) GetImage(provider, service, regionId string) ([]types.Image, bool) {
+	res := make([]types.Image, 0)
+	ok := bps.get(bps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) DeleteImage(provider, service, regionId string) {
+	bps.delete(bps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId))
+}
+
+func (bps *boltProductStore) StoreVersion(provider, service, region string, val []types.LocationVersion) {
+	bps.set(bps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region), val)
+}
+
+func (bps *boltProductStore) GetVersion(provider, service, region string) ([]types.LocationVersion, bool) {
+	res := make([]types.LocationVersion, 0)
+	ok := bps.get(bps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) DeleteVersion(provider, service, region string) {
+	bps.delete(bps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region))
+}
+
+// StoreRegionData replaces the region entries in a single transaction
+func (bps *boltProductStore) StoreRegionData(provider, service, region string, data cloudinfo.RegionData) error {
+	db, err := bps.initDB()
+	if err != nil {
+		return errors.WrapIf(err, "failed to open the database")
+	}
+
+	entries, err := marshalRegionEntries(provider, service, region, data)
+	if err != nil {
+		return errors.WithDetails(err, "provider", provider, "service", service, "region", region)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		for _, entry := range entries {
+			if err := b.Put([]byte(entry.Key), entry.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return errors.WrapIfWithDetails(err, "failed to store region data", "provider", provider, "service", service, "region", region)
+}
+
+func (bps *boltProductStore) GetGeneration(provider, service, region string) (int64, bool) {
+	var res int64
+	ok := bps.get(bps.getKey(cloudinfo.GenerationKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) StoreRegionStatus(provider, service, region string, val types.RegionStatus) {
+	bps.set(bps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), val)
+}
+
+func (bps *boltProductStore) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	var res types.RegionStatus
+	ok := bps.get(bps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) StoreQuarantine(provider, service, region string, val types.QuarantinedScrape) {
+	bps.set(bps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), val)
+}
+
+func (bps *boltProductStore) GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool) {
+	var res types.QuarantinedScrape
+	ok := bps.get(bps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) StoreScrapeRuns(provider string, val []types.ScrapeRun) {
+	bps.set(bps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), val)
+}
+
+func (bps *boltProductStore) GetScrapeRuns(provider string) ([]types.ScrapeRun, bool) {
+	var res []types.ScrapeRun
+	ok := bps.get(bps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) StoreStatus(provider string, val string) {
+	bps.set(bps.getKey(cloudinfo.StatusKeyTemplate, provider), val)
+}
+
+func (bps *boltProductStore) GetStatus(provider string) (string, bool) {
+	var res string
+	ok := bps.get(bps.getKey(cloudinfo.StatusKeyTemplate, provider), &res)
+
+	return res, ok
+}
+
+func (bps *boltProductStore) StoreServices(provider string, services []types.Service) {
+	bps.set(bps.getKey(cloudinfo.ServicesKeyTemplate, provider), services)
+}
+
+func (bps *boltProductStore) GetServices(provider string) ([]types.Service, bool) {
+	res := make([]types.Service, 0)
+	ok := bps.get(bps.getKey(cloudinfo.ServicesKeyTemplate, provider), &res)
+
+	return res, ok
+}
+
+// Export writes the content of the store into the passed in writer in the snapshot format
+func (bps *boltProductStore) Export(w io.Writer) error {
+	exported, err := exportSnapshot(w, bps, bps.buildInfo)
+	if err != nil {
+		bps.log.Error("failed to export the store", map[string]interface{}{"op": "export"})
+		return errors.WithDetails(err, "op", "export")
+	}
+
+	bps.log.Info("store exported", map[string]interface{}{"op": "export", "entries": exported})
+	return nil
+}
+
+// Import loads the store data from the passed in snapshot reader
+func (bps *boltProductStore) Import(r io.Reader) error {
+	header, imported, err := importSnapshot(r, bps)
+	if err != nil {
+		bps.log.Error("failed to load store data", map[string]interface{}{"op": "import"})
+		return errors.WithDetails(err, "op", "import")
+	}
+
+	bps.log.Info("store imported", map[string]interface{}{"op": "import", "entries": imported,
+		"schemaVersion": header.SchemaVersion, "createdAt": header.CreatedAt})
+	return nil
+}
+
+// walk iterates over the keys matching the pattern in key order, starting from the literal prefix of the pattern
+func (bps *boltProductStore) walk(pattern string, fn func(key string, value []byte) error) error {
+	db, err := bps.initDB()
+	if err != nil {
+		return err
+	}
+
+	prefix := []byte(strings.SplitN(pattern, "*", 2)[0])
+
+	return db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if !matchKey(pattern, string(k)) {
+				continue
+			}
+
+			// values are only valid during the transaction
+			if err := fn(string(k), append([]byte(nil), v...)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// load writes the entries in batches, a transaction per batch; existing keys are overwritten
+func (bps *boltProductStore) load(next func() (storeEntry, error)) (int, error) {
+	db, err := bps.initDB()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		batch  []storeEntry
+		loaded int
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := db.Update(func(tx *bbolt.Tx) error {
+			b := tx.Bucket(boltBucket)
+			for _, entry := range batch {
+				if err := b.Put([]byte(entry.Key), entry.Value); err != nil {
+					return errors.WrapIfWithDetails(err, "failed to store entry", "key", entry.Key)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		loaded += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		if batch = append(batch, entry); len(batch) >= boltImportBatchSize {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+
+	return loaded, flush()
+}
+
+func (bps *boltProductStore) Close() {
+	bps.mu.Lock()
+	defer bps.mu.Unlock()
+
+	if bps.db == nil {
+		return
+	}
+
+	if err := bps.db.Close(); err != nil {
+		bps.log.Error("failed to close the database", map[string]interface{}{"error": err})
+	}
+	bps.db = nil
+}
+
+// set sets the value of the given key to the json representation of the value
+func (bps *boltProductStore) set(key string, value interface{}) bool {
+	db, err := bps.initDB()
+	if err != nil {
+		bps.log.Error("failed to open the database")
+		return false
+	}
+
+	mJson, err := json.Marshal(value)
+	if err != nil {
+		bps.log.Debug("failed to marshal value into json", map[string]interface{}{"key": key, "value": value})
+		return false
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), mJson)
+	})
+	if err != nil {
+		bps.log.Error("failed to set key to value", map[string]interface{}{"key": key, "error": err})
+		return false
+	}
+
+	return true
+}
+
+// get unmarshals the value of the passed in key into toTypePtr
+func (bps *boltProductStore) get(key string, toTypePtr interface{}) bool {
+	db, err := bps.initDB()
+	if err != nil {
+		bps.log.Error("failed to open the database")
+		return false
+	}
+
+	var found bool
+	err = db.View(func(tx *bbolt.Tx) error {
+		cachedJson := tx.Bucket(boltBucket).Get([]byte(key))
+		if cachedJson == nil {
+			return nil
+		}
+		found = true
+
+		// the value is only valid during the transaction, so it's unmarshalled here
+		return json.Unmarshal(cachedJson, toTypePtr)
+	})
+	if err != nil {
+		bps.log.Debug("failed to unmarshal cache entry", map[string]interface{}{"key": key})
+		return false
+	}
+
+	if !found {
+		bps.log.Debug("nil value for key", map[string]interface{}{"key": key})
+	}
+
+	return found
+}
+
+func (bps *boltProductStore) delete(key string) {
+	db, err := bps.initDB()
+	if err != nil {
+		bps.log.Error("failed to open the database")
+		return
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		bps.log.Error("failed to delete entry", map[string]interface{}{"key": key})
+	}
+}
+
+// initDB opens the database file and creates the bucket if necessary
+func (bps *boltProductStore) initDB() (*bbolt.DB, error) {
+	bps.mu.Lock()
+	defer bps.mu.Unlock()
+
+	if bps.db != nil {
+		return bps.db, nil
+	}
+
+	bps.log.Debug("opening database...", map[string]interface{}{"path": bps.config.Path})
+	db, err := bolt.NewDB(bps.config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.WrapIf(err, "failed to create product bucket")
+	}
+
+	bps.db = db
+
+	return db, nil
+}
+
+func (bps *boltProductStore) getKey(keyTemplate string, args ...interface{}) string {
+	return fmt.Sprintf(keyTemplate, args...)
+}
@@ -0,0 +1,117 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/bolt"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
+)
+
+func newTestBoltStore(path string) *boltProductStore {
+	return NewBoltProductStore(
+		bolt.Config{Enabled: true, Path: path, Timeout: time.Second},
+		buildinfo.New("test", "", ""),
+		cloudinfoadapter.NewLogger(&logur.TestLogger{}),
+	).(*boltProductStore)
+}
+
+func TestBoltProductStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "cloudinfo.db")
+
+	bps := newTestBoltStore(path)
+	assert.True(t, bps.Ready())
+
+	bps.StoreStatus("amazon", "1234")
+	bps.StoreZones("amazon", "compute", "eu-west-1", []string{"eu-west-1a", "eu-west-1b"})
+	bps.StorePrice("amazon", "eu-west-1", "m5.large", types.Price{OnDemandPrice: 0.1})
+	bps.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.large"}})
+
+	lastSuccess := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, bps.StoreRegionData("amazon", "compute", "eu-central-1", cloudinfo.RegionData{
+		Generation: 42,
+		Status:     types.RegionStatus{LastSuccess: lastSuccess},
+		Zones:      []string{"eu-central-1a"},
+		Vms:        []types.VMInfo{{Type: "m5.large"}},
+	}))
+
+	generation, ok := bps.GetGeneration("amazon", "compute", "eu-central-1")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), generation)
+
+	bps.StoreRegionStatus("amazon", "compute", "eu-central-1", types.RegionStatus{LastSuccess: lastSuccess, Stale: true})
+	regionStatus, ok := bps.GetRegionStatus("amazon", "compute", "eu-central-1")
+	assert.True(t, ok)
+	assert.Equal(t, types.RegionStatus{LastSuccess: lastSuccess, Stale: true}, regionStatus)
+
+	zones, ok := bps.GetZones("amazon", "compute", "eu-central-1")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"eu-central-1a"}, zones)
+
+	_, ok = bps.GetImage("amazon", "compute", "eu-central-1")
+	assert.False(t, ok)
+
+	bps.DeleteZones("amazon", "compute", "eu-west-1")
+	_, ok = bps.GetZones("amazon", "compute", "eu-west-1")
+	assert.False(t, ok)
+
+	bps.Close()
+
+	// the data survives reopening the database file
+	bps = newTestBoltStore(path)
+	defer bps.Close()
+
+	status, ok := bps.GetStatus("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "1234", status)
+
+	price, ok := bps.GetPrice("amazon", "eu-west-1", "m5.large")
+	assert.True(t, ok)
+	assert.Equal(t, 0.1, price.OnDemandPrice)
+
+	prices, ok := bps.GetPrices("amazon", "eu-west-1", []string{"m5.large", "missing"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]types.Price{"m5.large": {OnDemandPrice: 0.1}}, prices)
+
+	vms, ok := bps.GetVm("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+	assert.Equal(t, "m5.large", vms[0].Type)
+
+	var buf bytes.Buffer
+	assert.NoError(t, bps.Export(&buf))
+
+	other := newTestBoltStore(filepath.Join(t.TempDir(), "other.db"))
+	defer other.Close()
+
+	assert.NoError(t, other.Import(&buf))
+
+	status, ok = other.GetStatus("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "1234", status)
+
+	vms, ok = other.GetVm("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+	assert.Equal(t, "m5.large", vms[0].Type)
+}
@@ -21,27 +21,39 @@ import (
 	"sync"
 
 	"emperror.dev/emperror"
+	"emperror.dev/errors"
 	"github.com/gocql/gocql"
 
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 	"github.com/banzaicloud/cloudinfo/internal/platform/cassandra"
 )
 
+const (
+	// cassandraPageSize the number of rows fetched in a single page when reading the whole product table
+	cassandraPageSize = 500
+
+	// cassandraImportBatchSize the number of inserts sent in a single batch
+	cassandraImportBatchSize = 100
+)
+
 type cassandraProductStore struct {
 	log       cloudinfo.Logger
 	keySpace  string
 	tableName string
 	cluster   *gocql.ClusterConfig
 	session   *gocql.Session
+	buildInfo buildinfo.BuildInfo
 }
 
-func NewCassandraProductStore(config cassandra.Config, logger cloudinfo.Logger) cloudinfo.CloudInfoStore {
+func NewCassandraProductStore(config cassandra.Config, buildInfo buildinfo.BuildInfo, logger cloudinfo.Logger) cloudinfo.CloudInfoStore {
 	return &cassandraProductStore{
 		log:       logger.WithFields(map[string]interface{}{"cistore": "cassandra"}),
 		keySpace:  config.Keyspace,
 		tableName: config.Table,
 		cluster:   cassandra.NewCluster(config),
+		buildInfo: buildInfo,
 	}
 }
 
@@ -95,6 +107,48 @@ func (cps *cassandraProductStore) GetPrice(provider, region, instanceType string
 	return res, ok
 }
 
+// GetPrices retrieves the prices with a single query
+func (cps *cassandraProductStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	prices := make(map[string]types.Price, len(instanceTypes))
+	if len(instanceTypes) == 0 {
+		return prices, false
+	}
+
+	if err := cps.initSession(); err != nil {
+		cps.log.Error("failed to connect to backend")
+		return prices, false
+	}
+
+	keys := make([]string, 0, len(instanceTypes))
+	instanceTypeByKey := make(map[string]string, len(instanceTypes))
+	for _, instanceType := range instanceTypes {
+		key := cps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType)
+		keys = append(keys, key)
+		instanceTypeByKey[key] = instanceType
+	}
+
+	var key, value string
+
+	selectQ := fmt.Sprintf("SELECT key, value FROM %s.%s WHERE key IN ?", cps.keySpace, cps.tableName)
+	iter := cps.session.Query(selectQ, keys).Iter()
+	for iter.Scan(&key, &value) {
+		var price types.Price
+		if err := json.Unmarshal([]byte(value), &price); err != nil {
+			cps.log.Debug("failed to unmarshal cache entry", map[string]interface{}{"key": key})
+			continue
+		}
+
+		prices[instanceTypeByKey[key]] = price
+	}
+
+	if err := iter.Close(); err != nil {
+		cps.log.Debug("failed to get entries", map[string]interface{}{"provider": provider, "region": region})
+		return prices, false
+	}
+
+	return prices, len(prices) > 0
+}
+
 func (cps *cassandraProductStore) StoreVm(provider, service, region string, val []types.VMInfo) {
 	cps.set(cps.getKey(cloudinfo.VmKeyTemplate, provider, service, region), val)
 }
@@ -110,6 +164,21 @@ func (cps *cassandraProductStore) DeleteVm(provider, service, region string) {
 	cps.delete(cps.getKey(cloudinfo.VmKeyTemplate, provider, service, region))
 }
 
+func (cps *cassandraProductStore) StoreProductDetails(provider, service, region string, val []types.ProductDetails) {
+	cps.set(cps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), val)
+}
+
+func (cps *cassandraProductStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	res := make([]types.ProductDetails, 0)
+	_, ok := cps.get(cps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (cps *cassandraProductStore) DeleteProductDetails(provider, service, region string) {
+	cps.delete(cps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region))
+}
+
 func (cps *cassandraProductStore) StoreImage(provider, service, regionId string, val []types.Image) {
 	cps.set(cps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId), val)
 }
@@ -140,6 +209,68 @@ func (cps *cassandraProductStore) DeleteVersion(provider, service, region string
 	cps.delete(cps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region))
 }
 
+// StoreRegionData replaces the region entries in a single logged (atomic) batch
+func (cps *cassandraProductStore) StoreRegionData(provider, service, region string, data cloudinfo.RegionData) error {
+	if err := cps.initSession(); err != nil {
+		return errors.WrapIf(err, "failed to connect to backend")
+	}
+
+	entries, err := marshalRegionEntries(provider, service, region, data)
+	if err != nil {
+		return errors.WithDetails(err, "provider", provider, "service", service, "region", region)
+	}
+
+	batch := cps.session.NewBatch(gocql.LoggedBatch)
+	insertQ := fmt.Sprintf("INSERT INTO %s.%s (key, value) VALUES (?, ?)", cps.keySpace, cps.tableName)
+	for _, entry := range entries {
+		batch.Query(insertQ, entry.Key, string(entry.Value))
+	}
+
+	err = cps.session.ExecuteBatch(batch)
+
+	return errors.WrapIfWithDetails(err, "failed to store region data", "provider", provider, "service", service, "region", region)
+}
+
+func (cps *cassandraProductStore) GetGeneration(provider, service, region string) (int64, bool) {
+	var res int64
+	_, ok := cps.get(cps.getKey(cloudinfo.GenerationKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (cps *cassandraProductStore) StoreRegionStatus(provider, service, region string, val types.RegionStatus) {
+	cps.set(cps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), val)
+}
+
+func (cps *cassandraProductStore) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	var res types.RegionStatus
+	_, ok := cps.get(cps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (cps *cassandraProductStore) StoreQuarantine(provider, service, region string, val types.QuarantinedScrape) {
+	cps.set(cps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), val)
+}
+
+func (cps *cassandraProductStore) GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool) {
+	var res types.QuarantinedScrape
+	_, ok := cps.get(cps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (cps *cassandraProductStore) StoreScrapeRuns(provider string, val []types.ScrapeRun) {
+	cps.set(cps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), val)
+}
+
+func (cps *cassandraProductStore) GetScrapeRuns(provider string) ([]types.ScrapeRun, bool) {
+	var res []types.ScrapeRun
+	_, ok := cps.get(cps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), &res)
+
+	return res, ok
+}
+
 func (cps *cassandraProductStore) StoreStatus(provider string, val string) {
 	cps.set(cps.getKey(cloudinfo.StatusKeyTemplate, provider), val)
 }
@@ -162,12 +293,105 @@ func (cps *cassandraProductStore) GetServices(provider string) ([]types.Service,
 	return res, ok
 }
 
+// Export writes the content of the store into the passed in writer in the snapshot format
 func (cps *cassandraProductStore) Export(w io.Writer) error {
-	panic("implement me")
+	exported, err := exportSnapshot(w, cps, cps.buildInfo)
+	if err != nil {
+		cps.log.Error("failed to export the store", map[string]interface{}{"op": "export"})
+		return errors.WithDetails(err, "op", "export")
+	}
+
+	cps.log.Info("store exported", map[string]interface{}{"op": "export", "entries": exported})
+	return nil
 }
 
+// Import loads the store data from the passed in snapshot reader
 func (cps *cassandraProductStore) Import(r io.Reader) error {
-	panic("implement me")
+	header, imported, err := importSnapshot(r, cps)
+	if err != nil {
+		cps.log.Error("failed to load store data", map[string]interface{}{"op": "import"})
+		return errors.WithDetails(err, "op", "import")
+	}
+
+	cps.log.Info("store imported", map[string]interface{}{"op": "import", "entries": imported,
+		"schemaVersion": header.SchemaVersion, "createdAt": header.CreatedAt})
+	return nil
+}
+
+// walk reads the product table page by page so that the whole table is never held in memory
+func (cps *cassandraProductStore) walk(pattern string, fn func(key string, value []byte) error) error {
+	if err := cps.initSession(); err != nil {
+		cps.log.Error("failed to connect to backend")
+		return err
+	}
+
+	var key, value string
+
+	selectQ := fmt.Sprintf("SELECT key, value FROM %s.%s", cps.keySpace, cps.tableName)
+	iter := cps.session.Query(selectQ).PageSize(cassandraPageSize).Iter()
+	for iter.Scan(&key, &value) {
+		if value == "" || !matchKey(pattern, key) {
+			continue
+		}
+
+		if err := fn(key, []byte(value)); err != nil {
+			_ = iter.Close()
+			return err
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return errors.WrapIf(err, "failed to read the product table")
+	}
+
+	return nil
+}
+
+// load inserts the entries in unlogged batches, existing keys are overwritten
+func (cps *cassandraProductStore) load(next func() (storeEntry, error)) (int, error) {
+	if err := cps.initSession(); err != nil {
+		cps.log.Error("failed to connect to backend")
+		return 0, err
+	}
+
+	var (
+		batch  = cps.session.NewBatch(gocql.UnloggedBatch)
+		loaded int
+	)
+
+	insertQ := fmt.Sprintf("INSERT INTO %s.%s (key, value) VALUES (?, ?)", cps.keySpace, cps.tableName)
+	flush := func() error {
+		if batch.Size() == 0 {
+			return nil
+		}
+
+		if err := cps.session.ExecuteBatch(batch); err != nil {
+			return errors.WrapIf(err, "failed to store entries")
+		}
+		loaded += batch.Size()
+		batch = cps.session.NewBatch(gocql.UnloggedBatch)
+
+		return nil
+	}
+
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		batch.Query(insertQ, entry.Key, string(entry.Value))
+		if batch.Size() >= cassandraImportBatchSize {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+
+	return loaded, flush()
 }
 
 func (cps *cassandraProductStore) Close() {
@@ -15,6 +15,7 @@
 package cistore
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -23,6 +24,8 @@ import (
 	"logur.dev/logur"
 
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 	"github.com/banzaicloud/cloudinfo/internal/platform/cassandra"
 )
 
@@ -34,6 +37,7 @@ func testCassandraStore(t *testing.T) {
 			Keyspace: "test",
 			Table:    "testPi",
 		},
+		buildinfo.New("test", "", ""),
 		cloudinfoadapter.NewLogger(&logur.TestLogger{}),
 	)
 
@@ -60,4 +64,34 @@ loop:
 	status, ok := cps.GetStatus("amazon")
 	assert.True(t, ok)
 	assert.Equal(t, "status", status)
+
+	// bulk price read
+	cps.StorePrice("amazon", "eu-west-1", "m5.large", types.Price{OnDemandPrice: 0.1})
+	prices, ok := cps.GetPrices("amazon", "eu-west-1", []string{"m5.large", "missing"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]types.Price{"m5.large": {OnDemandPrice: 0.1}}, prices)
+
+	// export the content of the store
+	var buf bytes.Buffer
+	assert.NoError(t, cps.Export(&buf))
+
+	// overwrite the entry, then restore it from the export
+	cps.StoreStatus("amazon", "changed")
+	assert.NoError(t, cps.Import(&buf))
+
+	status, ok = cps.GetStatus("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "status", status)
+}
+
+func testCassandraPriceHistoryStore(t *testing.T) {
+	testPriceHistoryStore(t, NewCassandraPriceHistoryStore(
+		cassandra.Config{
+			Hosts:    []string{"localhost"},
+			Port:     9042,
+			Keyspace: "test",
+			Table:    "testPi",
+		},
+		cloudinfoadapter.NewLogger(&logur.TestLogger{}),
+	))
 }
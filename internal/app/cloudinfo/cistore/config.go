@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/platform/bolt"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 	"github.com/banzaicloud/cloudinfo/internal/platform/cassandra"
 	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
 )
@@ -27,6 +29,31 @@ type Config struct {
 	Redis     redis.Config
 	GoCache   GoCacheConfig
 	Cassandra cassandra.Config
+	Bolt      bolt.Config
+
+	// LocalCache the in-memory tier kept in front of the Redis, Cassandra or Bolt store
+	LocalCache LocalCacheConfig
+
+	// PriceHistory the history of the instance type prices, kept in the same backend as the products
+	PriceHistory PriceHistoryConfig
+
+	// Snapshot the snapshots of the store written to the local disk, the most recent one is restored on startup
+	Snapshot SnapshotConfig
+}
+
+// LocalCacheConfig configuration
+type LocalCacheConfig struct {
+	Enabled bool
+
+	// Size the maximum number of cached entries
+	Size int
+
+	// StatusCheckInterval the interval the provider and region statuses are checked in,
+	// the cached entries of a provider (or a region) are dropped when its status changes
+	StatusCheckInterval time.Duration
+
+	// TTL the longest time an entry is cached for, the entries don't expire if it's not positive
+	TTL time.Duration
 }
 
 // GoCacheConfig configuration
@@ -37,19 +64,40 @@ type GoCacheConfig struct {
 
 // NewCloudInfoStore builds a new cloudinfo store based on the passed in configuration
 // This method is in charge to create the appropriate store instance eventually to implement a fallback mechanism to the default store
-func NewCloudInfoStore(conf Config, log cloudinfo.Logger) cloudinfo.CloudInfoStore {
+func NewCloudInfoStore(conf Config, buildInfo buildinfo.BuildInfo, log cloudinfo.Logger) cloudinfo.CloudInfoStore {
 	// use redis if enabled
 	if conf.Redis.Enabled {
 		log.Info("using Redis as product store")
-		return NewRedisProductStore(conf.Redis, log)
+		return withLocalCache(NewRedisProductStore(conf.Redis, buildInfo, log), conf.LocalCache, log)
 	}
 
 	if conf.Cassandra.Enabled {
 		log.Info("using Cassandra as product store")
-		return NewCassandraProductStore(conf.Cassandra, log)
+		return withLocalCache(NewCassandraProductStore(conf.Cassandra, buildInfo, log), conf.LocalCache, log)
+	}
+
+	if conf.Bolt.Enabled {
+		log.Info("using Bolt as product store", map[string]interface{}{"path": conf.Bolt.Path})
+		return withLocalCache(NewBoltProductStore(conf.Bolt, buildInfo, log), conf.LocalCache, log)
 	}
 
 	// fallback to the "initial" implementation
 	log.Info("using in-mem cache as product store")
-	return NewCacheProductStore(conf.GoCache.expiration, conf.GoCache.cleanupInterval, log)
+	return NewCacheProductStore(conf.GoCache.expiration, conf.GoCache.cleanupInterval, buildInfo, log)
+}
+
+// withLocalCache puts the in-memory tier in front of the store if enabled
+func withLocalCache(store cloudinfo.CloudInfoStore, conf LocalCacheConfig, log cloudinfo.Logger) cloudinfo.CloudInfoStore {
+	if !conf.Enabled {
+		return store
+	}
+
+	tiered, err := NewTieredProductStore(store, conf, log)
+	if err != nil {
+		log.Error("failed to set up local cache, using the store directly", map[string]interface{}{"error": err})
+		return store
+	}
+
+	log.Info("using local cache in front of the product store", map[string]interface{}{"size": conf.Size})
+	return tiered
 }
@@ -15,15 +15,19 @@
 package cistore
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
-	"emperror.dev/emperror"
+	"emperror.dev/errors"
 	"github.com/patrickmn/go-cache"
 
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 )
 
 // cacheProductStore in memory cloud product information storer
@@ -31,7 +35,12 @@ type cacheProductStore struct {
 	*cache.Cache
 	// all items are cached with this expiry
 	itemExpiry time.Duration
+	buildInfo  buildinfo.BuildInfo
 	log        cloudinfo.Logger
+
+	// regionMu makes the region data replaced at once, the entries of concurrent writes don't get mixed
+	// and the readers don't see the entries of a write in progress
+	regionMu sync.RWMutex
 }
 
 func (cis *cacheProductStore) Ready() bool {
@@ -88,6 +97,17 @@ func (cis *cacheProductStore) GetPrice(provider, region, instanceType string) (t
 	return types.Price{}, false
 }
 
+func (cis *cacheProductStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	prices := make(map[string]types.Price, len(instanceTypes))
+	for _, instanceType := range instanceTypes {
+		if price, ok := cis.GetPrice(provider, region, instanceType); ok {
+			prices[instanceType] = price
+		}
+	}
+
+	return prices, len(prices) > 0
+}
+
 func (cis *cacheProductStore) StoreVm(provider, service, region string, val []types.VMInfo) {
 	cis.Set(cis.getKey(cloudinfo.VmKeyTemplate, provider, service, region), val, cis.itemExpiry)
 }
@@ -100,6 +120,22 @@ func (cis *cacheProductStore) GetVm(provider, service, region string) ([]types.V
 	return nil, false
 }
 
+func (cis *cacheProductStore) StoreProductDetails(provider, service, region string, val []types.ProductDetails) {
+	cis.Set(cis.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), val, cis.itemExpiry)
+}
+
+func (cis *cacheProductStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	if res, ok := cis.get(cis.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region)); ok {
+		return res.([]types.ProductDetails), ok
+	}
+
+	return nil, false
+}
+
+func (cis *cacheProductStore) DeleteProductDetails(provider, service, region string) {
+	cis.Delete(cis.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region))
+}
+
 func (cis *cacheProductStore) StoreImage(provider, service, regionId string, val []types.Image) {
 	cis.Set(cis.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId), val, cis.itemExpiry)
 }
@@ -124,6 +160,63 @@ func (cis *cacheProductStore) GetVersion(provider, service, region string) ([]ty
 	return nil, false
 }
 
+// StoreRegionData replaces the region entries at once under the region lock; as the previous values are overwritten (not deleted),
+// readers never see the data of the region missing
+func (cis *cacheProductStore) StoreRegionData(provider, service, region string, data cloudinfo.RegionData) error {
+	cis.regionMu.Lock()
+	defer cis.regionMu.Unlock()
+
+	for _, entry := range regionEntries(provider, service, region, data) {
+		cis.Set(entry.key, entry.value, cis.itemExpiry)
+	}
+
+	return nil
+}
+
+func (cis *cacheProductStore) GetGeneration(provider, service, region string) (int64, bool) {
+	if res, ok := cis.get(cis.getKey(cloudinfo.GenerationKeyTemplate, provider, service, region)); ok {
+		return res.(int64), ok
+	}
+
+	return 0, false
+}
+
+func (cis *cacheProductStore) StoreRegionStatus(provider, service, region string, val types.RegionStatus) {
+	cis.Set(cis.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), val, cis.itemExpiry)
+}
+
+func (cis *cacheProductStore) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	if res, ok := cis.get(cis.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region)); ok {
+		return res.(types.RegionStatus), ok
+	}
+
+	return types.RegionStatus{}, false
+}
+
+func (cis *cacheProductStore) StoreQuarantine(provider, service, region string, val types.QuarantinedScrape) {
+	cis.Set(cis.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), val, cis.itemExpiry)
+}
+
+func (cis *cacheProductStore) GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool) {
+	if res, ok := cis.get(cis.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region)); ok {
+		return res.(types.QuarantinedScrape), ok
+	}
+
+	return types.QuarantinedScrape{}, false
+}
+
+func (cis *cacheProductStore) StoreScrapeRuns(provider string, val []types.ScrapeRun) {
+	cis.Set(cis.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), val, cis.itemExpiry)
+}
+
+func (cis *cacheProductStore) GetScrapeRuns(provider string) ([]types.ScrapeRun, bool) {
+	if res, ok := cis.get(cis.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider)); ok {
+		return res.([]types.ScrapeRun), ok
+	}
+
+	return nil, false
+}
+
 func (cis *cacheProductStore) StoreStatus(provider string, val string) {
 	cis.Set(cis.getKey(cloudinfo.StatusKeyTemplate, provider), val, cis.itemExpiry)
 }
@@ -136,24 +229,91 @@ func (cis *cacheProductStore) GetStatus(provider string) (string, bool) {
 	return "", false
 }
 
-// Export writes the content of the store into the passed in writer
+// Export writes the content of the store into the passed in writer in the snapshot format
 func (cis *cacheProductStore) Export(w io.Writer) error {
-	if err := cis.Save(w); err != nil {
-		cis.log.Error("failed to export the store", map[string]interface{}{"op": "export", "destination": "todo"})
-		return emperror.WrapWith(err, "failed to export the store", "op", "export", "destination", "todo")
+	exported, err := exportSnapshot(w, cis, cis.buildInfo)
+	if err != nil {
+		cis.log.Error("failed to export the store", map[string]interface{}{"op": "export"})
+		return errors.WithDetails(err, "op", "export")
 	}
+
+	cis.log.Info("store exported", map[string]interface{}{"op": "export", "entries": exported})
 	return nil
 }
 
-// Import loads the store data from the standard input
+// Import loads the store data from the passed in reader
+// besides snapshots the gob encoded content exported by earlier versions is accepted too
 func (cis *cacheProductStore) Import(r io.Reader) error {
-	if err := cis.Load(r); err != nil {
-		cis.log.Error("failed to load store data", map[string]interface{}{"op": "import", "destination": "todo"})
-		return emperror.WrapWith(err, "failed to load the store data", "op", "import", "destination", "todo")
+	br := bufio.NewReader(r)
+	if !isSnapshot(br) {
+		if err := cis.Load(br); err != nil {
+			cis.log.Error("failed to load store data", map[string]interface{}{"op": "import"})
+			return errors.WrapIfWithDetails(err, "failed to load the store data", "op", "import")
+		}
+		return nil
 	}
+
+	header, imported, err := importSnapshot(br, cis)
+	if err != nil {
+		cis.log.Error("failed to load store data", map[string]interface{}{"op": "import"})
+		return errors.WithDetails(err, "op", "import")
+	}
+
+	cis.log.Info("store imported", map[string]interface{}{"op": "import", "entries": imported,
+		"schemaVersion": header.SchemaVersion, "createdAt": header.CreatedAt})
+	return nil
+}
+
+func (cis *cacheProductStore) walk(pattern string, fn func(key string, value []byte) error) error {
+	cis.regionMu.RLock()
+	items := cis.Items()
+	cis.regionMu.RUnlock()
+
+	for key, item := range items {
+		if item.Object == nil || !matchKey(pattern, key) {
+			continue
+		}
+
+		value, err := json.Marshal(item.Object)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to encode value", "key", key)
+		}
+
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// load decodes the entries into the types the getters expect, unknown keys are skipped
+func (cis *cacheProductStore) load(next func() (storeEntry, error)) (int, error) {
+	var loaded int
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			return loaded, nil
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		val, known, err := decodeSnapshotValue(entry.Key, entry.Value)
+		if err != nil {
+			return loaded, err
+		}
+
+		if !known {
+			cis.log.Warn("skipping unknown snapshot entry", map[string]interface{}{"key": entry.Key})
+			continue
+		}
+
+		cis.Set(entry.Key, val, cis.itemExpiry)
+		loaded++
+	}
+}
+
 func (cis *cacheProductStore) DeleteVm(provider, service, region string) {
 	cis.Delete(cis.getKey(cloudinfo.VmKeyTemplate, provider, service, region))
 }
@@ -172,11 +332,12 @@ func (cis *cacheProductStore) GetServices(provider string) ([]types.Service, boo
 
 // NewCacheProductStore creates a new store instance.
 // the backing cache is initialized with the defaultExpiration and cleanupInterval
-func NewCacheProductStore(cloudInfoExpiration, cleanupInterval time.Duration, logger cloudinfo.Logger) cloudinfo.CloudInfoStore {
+func NewCacheProductStore(cloudInfoExpiration, cleanupInterval time.Duration, buildInfo buildinfo.BuildInfo, logger cloudinfo.Logger) cloudinfo.CloudInfoStore {
 	return &cacheProductStore{
-		cache.New(cloudInfoExpiration, cleanupInterval),
-		cleanupInterval,
-		logger,
+		Cache:      cache.New(cloudInfoExpiration, cleanupInterval),
+		itemExpiry: cleanupInterval,
+		buildInfo:  buildInfo,
+		log:        logger,
 	}
 }
 
@@ -185,6 +346,9 @@ func (cis *cacheProductStore) getKey(keyTemplate string, args ...interface{}) st
 }
 
 func (cis *cacheProductStore) get(key string) (interface{}, bool) {
+	cis.regionMu.RLock()
+	defer cis.regionMu.RUnlock()
+
 	if val, ok := cis.Get(key); ok && val != nil {
 		return val, true
 	}
@@ -0,0 +1,51 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+)
+
+func TestCacheProductStore_StoreRegionData(t *testing.T) {
+	store := newTestCacheStore()
+
+	// the entries of the concurrent writes of the region are not mixed
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(generation int64) {
+			defer wg.Done()
+
+			assert.NoError(t, store.StoreRegionData("amazon", "compute", "eu-west-1", cloudinfo.RegionData{
+				Generation: generation,
+				Zones:      []string{fmt.Sprintf("zone-%d", generation)},
+			}))
+		}(int64(i))
+	}
+	wg.Wait()
+
+	generation, ok := store.GetGeneration("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+
+	zones, ok := store.GetZones("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+	assert.Equal(t, []string{fmt.Sprintf("zone-%d", generation)}, zones)
+}
@@ -31,4 +31,7 @@ func TestIntegration(t *testing.T) {
 
 	t.Run("testCassandraStore", testCassandraStore)
 	t.Run("testRedisStore", testRedisStore)
+	t.Run("testCassandraPriceHistoryStore", testCassandraPriceHistoryStore)
+	t.Run("testRedisPriceHistoryStore", testRedisPriceHistoryStore)
+	t.Run("testRedisLock", testRedisLock)
 }
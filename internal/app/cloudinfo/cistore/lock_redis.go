@@ -0,0 +1,106 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"emperror.dev/errors"
+	redigo "github.com/gomodule/redigo/redis"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
+)
+
+var (
+	// redisAcquireScript renews the lock if it's held by the owner, or sets it if it's not held at all
+	redisAcquireScript = redigo.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+
+	// redisReleaseScript deletes the lock if it's held by the owner
+	redisReleaseScript = redigo.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// redisLock a lock stored in Redis, the value of the key is the owner of the lock
+type redisLock struct {
+	pool  *redigo.Pool
+	owner string
+	log   cloudinfo.Logger
+}
+
+// NewRedisLock creates a new lock stored in Redis, owned by the current process
+func NewRedisLock(config redis.Config, log cloudinfo.Logger) cloudinfo.Lock {
+	return &redisLock{
+		pool:  redis.NewPool(config),
+		owner: lockOwner(),
+		log:   log.WithFields(map[string]interface{}{"lock": "redis"}),
+	}
+}
+
+func (rl *redisLock) Acquire(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	conn, err := rl.pool.GetContext(ctx)
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "failed to connect to redis", "key", key)
+	}
+	defer conn.Close()
+
+	acquired, err := redigo.Bool(redisAcquireScript.Do(conn, key, rl.owner, lease.Milliseconds()))
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "failed to acquire lock", "key", key)
+	}
+
+	return acquired, nil
+}
+
+func (rl *redisLock) Release(ctx context.Context, key string) error {
+	conn, err := rl.pool.GetContext(ctx)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "failed to connect to redis", "key", key)
+	}
+	defer conn.Close()
+
+	if _, err := redisReleaseScript.Do(conn, key, rl.owner); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to release lock", "key", key)
+	}
+
+	rl.log.Debug("lock released", map[string]interface{}{"key": key})
+
+	return nil
+}
+
+// lockOwner returns an identifier of the process unique among the replicas
+func lockOwner() string {
+	hostname, _ := os.Hostname()
+
+	suffix := make([]byte, 8)
+	_, _ = rand.Read(suffix)
+
+	return fmt.Sprintf("%s-%s", hostname, hex.EncodeToString(suffix))
+}
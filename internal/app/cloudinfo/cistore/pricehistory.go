@@ -0,0 +1,167 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// PriceHistoryKeyPrefix the prefix of the price history keys
+// it differs from the product key prefix, so snapshots and cache purges leave the history alone
+const PriceHistoryKeyPrefix = "/banzaicloud.com/cloudinfo-history/"
+
+// priceHistoryKeyTemplate the key of the price history of an instance type
+const priceHistoryKeyTemplate = PriceHistoryKeyPrefix + "providers/%s/regions/%s/prices/%s"
+
+// PriceHistoryConfig configuration
+type PriceHistoryConfig struct {
+	Enabled bool
+
+	// Retention the period the price points are kept for
+	Retention time.Duration
+
+	// PruneInterval the interval the expired price points are removed in
+	PruneInterval time.Duration
+}
+
+// Validate checks the price history configuration
+func (c PriceHistoryConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Retention <= 0 {
+		return errors.New("price history retention must be positive")
+	}
+
+	if c.PruneInterval <= 0 {
+		return errors.New("price history prune interval must be positive")
+	}
+
+	return nil
+}
+
+// NewPriceHistoryStore builds a new price history store next to the product store selected by the configuration
+// nil is returned if the price history is disabled
+func NewPriceHistoryStore(conf Config, log cloudinfo.Logger) cloudinfo.PriceHistoryStore {
+	if !conf.PriceHistory.Enabled {
+		return nil
+	}
+
+	if conf.Redis.Enabled {
+		log.Info("using Redis as price history store")
+		return NewRedisPriceHistoryStore(conf.Redis, log)
+	}
+
+	if conf.Cassandra.Enabled {
+		log.Info("using Cassandra as price history store")
+		return NewCassandraPriceHistoryStore(conf.Cassandra, log)
+	}
+
+	if conf.Bolt.Enabled {
+		log.Info("using Bolt as price history store")
+		return NewBoltPriceHistoryStore(conf.Bolt, log)
+	}
+
+	log.Info("using in-mem price history store")
+	return NewMemoryPriceHistoryStore()
+}
+
+func priceHistoryKey(provider, region, instanceType string) string {
+	return fmt.Sprintf(priceHistoryKeyTemplate, provider, region, instanceType)
+}
+
+// memoryPriceHistoryStore in-memory price history, the points are lost on restart
+type memoryPriceHistoryStore struct {
+	series map[string][]types.PricePoint
+	mu     sync.RWMutex
+}
+
+// NewMemoryPriceHistoryStore creates a new in-memory price history store
+func NewMemoryPriceHistoryStore() cloudinfo.PriceHistoryStore {
+	return &memoryPriceHistoryStore{
+		series: make(map[string][]types.PricePoint),
+	}
+}
+
+func (s *memoryPriceHistoryStore) AppendPrices(provider, region string, at time.Time, prices map[string]types.Price) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for instanceType, price := range prices {
+		key := priceHistoryKey(provider, region, instanceType)
+		points := s.series[key]
+
+		// keep the points ordered even if an older point is appended
+		i := sort.Search(len(points), func(i int) bool { return points[i].Time.After(at) })
+		points = append(points, types.PricePoint{})
+		copy(points[i+1:], points[i:])
+		points[i] = types.NewPricePoint(at, price)
+
+		s.series[key] = points
+	}
+
+	return nil
+}
+
+func (s *memoryPriceHistoryStore) GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return pricePointsInRange(s.series[priceHistoryKey(provider, region, instanceType)], from, to), nil
+}
+
+func (s *memoryPriceHistoryStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, points := range s.series {
+		if expired := countBefore(points, before); expired > 1 {
+			s.series[key] = append([]types.PricePoint(nil), points[expired-1:]...)
+		}
+	}
+
+	return nil
+}
+
+// pricePointsInRange selects the points of the time range from the ordered points
+// the last point before the range is included, as it holds the price at the start of the range
+func pricePointsInRange(points []types.PricePoint, from, to time.Time) []types.PricePoint {
+	start := countBefore(points, from)
+	if start > 0 && (start == len(points) || !points[start].Time.Equal(from)) {
+		start--
+	}
+	end := sort.Search(len(points), func(i int) bool { return points[i].Time.After(to) })
+
+	res := make([]types.PricePoint, 0)
+	if start < end {
+		res = append(res, points[start:end]...)
+	}
+
+	return res
+}
+
+// countBefore returns the number of the ordered points that are older than the given time
+func countBefore(points []types.PricePoint, before time.Time) int {
+	return sort.Search(len(points), func(i int) bool { return !points[i].Time.Before(before) })
+}
@@ -0,0 +1,212 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"go.etcd.io/bbolt"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/bolt"
+)
+
+// boltPriceHistoryBucket the bucket holding the price points
+// nolint: gochecknoglobals
+var boltPriceHistoryBucket = []byte("prices")
+
+// boltPriceHistoryStore keeps the price points in a database file next to the product database
+// the keys are made of the instance type key and the zero padded time in milliseconds, so they are ordered by time
+type boltPriceHistoryStore struct {
+	config bolt.Config
+	db     *bbolt.DB
+	mu     sync.Mutex
+	log    cloudinfo.Logger
+}
+
+// NewBoltPriceHistoryStore creates a new price history store backed by a database file
+// the file is the product database file with the .history suffix, it is opened on first use
+func NewBoltPriceHistoryStore(config bolt.Config, log cloudinfo.Logger) cloudinfo.PriceHistoryStore {
+	config.Path += ".history"
+
+	return &boltPriceHistoryStore{
+		config: config,
+		log:    log.WithFields(map[string]interface{}{"pricehistory": "bolt"}),
+	}
+}
+
+func (s *boltPriceHistoryStore) AppendPrices(provider, region string, at time.Time, prices map[string]types.Price) error {
+	db, err := s.initDB()
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltPriceHistoryBucket)
+
+		for instanceType, price := range prices {
+			value, err := json.Marshal(types.NewPricePoint(at, price))
+			if err != nil {
+				return errors.WrapIfWithDetails(err, "failed to marshal price point", "instanceType", instanceType)
+			}
+
+			if err := bucket.Put(pricePointKey(priceHistoryKey(provider, region, instanceType), at), value); err != nil {
+				return errors.WrapIfWithDetails(err, "failed to store price point", "instanceType", instanceType)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *boltPriceHistoryStore) GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error) {
+	db, err := s.initDB()
+	if err != nil {
+		return nil, err
+	}
+
+	series := priceHistoryKey(provider, region, instanceType)
+	prefix := []byte(series + "/")
+	fromKey, toKey := pricePointKey(series, from), pricePointKey(series, to)
+
+	points := make([]types.PricePoint, 0)
+	err = db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(boltPriceHistoryBucket).Cursor()
+
+		// step back to the last point before the range, it holds the price at the start of the range
+		k, v := c.Seek(fromKey)
+		if k == nil || !bytes.Equal(k, fromKey) {
+			var pk, pv []byte
+			if k == nil {
+				pk, pv = c.Last()
+			} else {
+				pk, pv = c.Prev()
+			}
+
+			if pk != nil && bytes.HasPrefix(pk, prefix) {
+				k, v = pk, pv
+			} else {
+				k, v = c.Seek(fromKey)
+			}
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix) && bytes.Compare(k, toKey) <= 0; k, v = c.Next() {
+			var point types.PricePoint
+			if err := json.Unmarshal(v, &point); err != nil {
+				return errors.WrapIfWithDetails(err, "failed to unmarshal price point", "key", string(k))
+			}
+			points = append(points, point)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to get price history")
+	}
+
+	return points, nil
+}
+
+func (s *boltPriceHistoryStore) Prune(before time.Time) error {
+	db, err := s.initDB()
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltPriceHistoryBucket)
+
+		// the keys of a series are next to each other, the newest expired point of every series is kept
+		var series, expired []byte
+		var obsolete [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if current := k[:bytes.LastIndexByte(k, '/')]; !bytes.Equal(current, series) {
+				series, expired = current, nil
+			}
+
+			if bytes.Compare(k, pricePointKey(string(series), before)) >= 0 {
+				continue
+			}
+
+			if expired != nil {
+				obsolete = append(obsolete, expired)
+			}
+			expired = append([]byte(nil), k...)
+		}
+
+		// deleting while iterating would move the cursor
+		for _, k := range obsolete {
+			if err := bucket.Delete(k); err != nil {
+				return errors.WrapIfWithDetails(err, "failed to remove expired price point", "key", string(k))
+			}
+		}
+
+		return nil
+	})
+}
+
+// Close closes the database file
+func (s *boltPriceHistoryStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return
+	}
+
+	if err := s.db.Close(); err != nil {
+		s.log.Error("failed to close the database", map[string]interface{}{"error": err})
+	}
+	s.db = nil
+}
+
+func (s *boltPriceHistoryStore) initDB() (*bbolt.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	db, err := bolt.NewDB(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltPriceHistoryBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.WrapIf(err, "failed to create price history bucket")
+	}
+
+	s.db = db
+
+	return db, nil
+}
+
+// pricePointKey the key of the price point of the series at the given time
+func pricePointKey(series string, at time.Time) []byte {
+	return []byte(fmt.Sprintf("%s/%020d", series, at.UnixNano()/int64(time.Millisecond)))
+}
@@ -0,0 +1,183 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/gocql/gocql"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/cassandra"
+)
+
+// cassandraPriceHistoryStore keeps the price points of an instance type in a partition clustered by time
+// the table is named after the product table with the _price_history suffix
+type cassandraPriceHistoryStore struct {
+	keySpace  string
+	tableName string
+	cluster   *gocql.ClusterConfig
+	session   *gocql.Session
+	mu        sync.Mutex
+	log       cloudinfo.Logger
+}
+
+// NewCassandraPriceHistoryStore creates a new price history store backed by Cassandra
+func NewCassandraPriceHistoryStore(config cassandra.Config, log cloudinfo.Logger) cloudinfo.PriceHistoryStore {
+	return &cassandraPriceHistoryStore{
+		keySpace:  config.Keyspace,
+		tableName: config.Table + "_price_history",
+		cluster:   cassandra.NewCluster(config),
+		log:       log.WithFields(map[string]interface{}{"pricehistory": "cassandra"}),
+	}
+}
+
+func (s *cassandraPriceHistoryStore) AppendPrices(provider, region string, at time.Time, prices map[string]types.Price) error {
+	session, err := s.initSession()
+	if err != nil {
+		return err
+	}
+
+	insertQ := fmt.Sprintf("INSERT INTO %s.%s (provider, region, instance_type, ts, value) VALUES (?, ?, ?, ?, ?)", s.keySpace, s.tableName)
+
+	batch := session.NewBatch(gocql.UnloggedBatch)
+	for instanceType, price := range prices {
+		value, err := json.Marshal(types.NewPricePoint(at, price))
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to marshal price point", "instanceType", instanceType)
+		}
+
+		batch.Query(insertQ, provider, region, instanceType, at, string(value))
+
+		if batch.Size() >= cassandraImportBatchSize {
+			if err := session.ExecuteBatch(batch); err != nil {
+				return errors.WrapIfWithDetails(err, "failed to append price points", "provider", provider, "region", region)
+			}
+			batch = session.NewBatch(gocql.UnloggedBatch)
+		}
+	}
+
+	if batch.Size() > 0 {
+		if err := session.ExecuteBatch(batch); err != nil {
+			return errors.WrapIfWithDetails(err, "failed to append price points", "provider", provider, "region", region)
+		}
+	}
+
+	return nil
+}
+
+func (s *cassandraPriceHistoryStore) GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error) {
+	session, err := s.initSession()
+	if err != nil {
+		return nil, err
+	}
+
+	partition := fmt.Sprintf("SELECT value FROM %s.%s WHERE provider = ? AND region = ? AND instance_type = ?", s.keySpace, s.tableName)
+
+	// the last point before the range holds the price at the start of the range
+	values := make([]string, 0)
+	var value string
+	if err := session.Query(partition+" AND ts <= ? ORDER BY ts DESC LIMIT 1", provider, region, instanceType, from).Scan(&value); err == nil {
+		values = append(values, value)
+	} else if err != gocql.ErrNotFound {
+		return nil, errors.WrapIf(err, "failed to get price history")
+	}
+
+	iter := session.Query(partition+" AND ts > ? AND ts <= ?", provider, region, instanceType, from, to).Iter()
+	for iter.Scan(&value) {
+		values = append(values, value)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.WrapIf(err, "failed to get price history")
+	}
+
+	points := make([]types.PricePoint, 0, len(values))
+	for _, value := range values {
+		var point types.PricePoint
+		if err := json.Unmarshal([]byte(value), &point); err != nil {
+			return nil, errors.WrapIf(err, "failed to unmarshal price point")
+		}
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+func (s *cassandraPriceHistoryStore) Prune(before time.Time) error {
+	session, err := s.initSession()
+	if err != nil {
+		return err
+	}
+
+	partitionsQ := fmt.Sprintf("SELECT DISTINCT provider, region, instance_type FROM %s.%s", s.keySpace, s.tableName)
+	newestQ := fmt.Sprintf("SELECT ts FROM %s.%s WHERE provider = ? AND region = ? AND instance_type = ? AND ts < ? ORDER BY ts DESC LIMIT 1", s.keySpace, s.tableName)
+	deleteQ := fmt.Sprintf("DELETE FROM %s.%s WHERE provider = ? AND region = ? AND instance_type = ? AND ts < ?", s.keySpace, s.tableName)
+
+	var provider, region, instanceType string
+	iter := session.Query(partitionsQ).PageSize(cassandraPageSize).Iter()
+	for iter.Scan(&provider, &region, &instanceType) {
+		// the newest expired point is kept, it holds the price at the start of the retention period
+		var newest time.Time
+		if err := session.Query(newestQ, provider, region, instanceType, before).Scan(&newest); err != nil {
+			if err == gocql.ErrNotFound {
+				continue
+			}
+
+			return errors.WrapIf(err, "failed to find expired price points")
+		}
+
+		if err := session.Query(deleteQ, provider, region, instanceType, newest).Exec(); err != nil {
+			return errors.WrapIf(err, "failed to remove expired price points")
+		}
+	}
+
+	return errors.WrapIf(iter.Close(), "failed to list price histories")
+}
+
+func (s *cassandraPriceHistoryStore) initSession() (*gocql.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session != nil && !s.session.Closed() {
+		return s.session, nil
+	}
+
+	session, err := s.cluster.CreateSession()
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to create cassandra session")
+	}
+
+	keyspaceQuery := fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor' : 1}", s.keySpace)
+	tableQuery := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (provider text, region text, instance_type text, ts timestamp, value text, PRIMARY KEY((provider, region, instance_type), ts))", s.keySpace, s.tableName)
+
+	if err := session.Query(keyspaceQuery).Exec(); err != nil {
+		session.Close()
+		return nil, errors.WrapIf(err, "failed to create keyspace")
+	}
+
+	if err := session.Query(tableQuery).Exec(); err != nil {
+		session.Close()
+		return nil, errors.WrapIf(err, "failed to create price history table")
+	}
+
+	s.session = session
+
+	return session, nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	redigo "github.com/gomodule/redigo/redis"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
+)
+
+// redisPriceHistoryStore keeps the price points of an instance type in a sorted set scored by the time in milliseconds
+type redisPriceHistoryStore struct {
+	pool *redigo.Pool
+	log  cloudinfo.Logger
+}
+
+// NewRedisPriceHistoryStore creates a new price history store backed by Redis
+func NewRedisPriceHistoryStore(config redis.Config, log cloudinfo.Logger) cloudinfo.PriceHistoryStore {
+	return &redisPriceHistoryStore{
+		pool: redis.NewPool(config),
+		log:  log.WithFields(map[string]interface{}{"pricehistory": "redis"}),
+	}
+}
+
+func (s *redisPriceHistoryStore) AppendPrices(provider, region string, at time.Time, prices map[string]types.Price) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	for instanceType, price := range prices {
+		member, err := json.Marshal(types.NewPricePoint(at, price))
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to marshal price point", "instanceType", instanceType)
+		}
+
+		if err := conn.Send("ZADD", priceHistoryKey(provider, region, instanceType), at.UnixNano()/int64(time.Millisecond), member); err != nil {
+			return errors.WrapIf(err, "failed to send price points")
+		}
+	}
+
+	if _, err := conn.Do(""); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to append price points", "provider", provider, "region", region)
+	}
+
+	return nil
+}
+
+func (s *redisPriceHistoryStore) GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	key := priceHistoryKey(provider, region, instanceType)
+	fromScore, toScore := from.UnixNano()/int64(time.Millisecond), to.UnixNano()/int64(time.Millisecond)
+
+	// the last point before the range holds the price at the start of the range
+	previous, err := redigo.ByteSlices(conn.Do("ZREVRANGEBYSCORE", key, fromScore, "-inf", "LIMIT", 0, 1))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to get price history", "key", key)
+	}
+
+	members, err := redigo.ByteSlices(conn.Do("ZRANGEBYSCORE", key, "("+formatScore(fromScore), toScore))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to get price history", "key", key)
+	}
+
+	points := make([]types.PricePoint, 0, len(previous)+len(members))
+	for _, member := range append(previous, members...) {
+		var point types.PricePoint
+		if err := json.Unmarshal(member, &point); err != nil {
+			return nil, errors.WrapIfWithDetails(err, "failed to unmarshal price point", "key", key)
+		}
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+func (s *redisPriceHistoryStore) Prune(before time.Time) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	score := before.UnixNano() / int64(time.Millisecond)
+	cursor := 0
+	for {
+		reply, err := redigo.Values(conn.Do("SCAN", cursor, "MATCH", PriceHistoryKeyPrefix+"*", "COUNT", redisScanCount))
+		if err != nil {
+			return errors.WrapIf(err, "failed to scan price history keys")
+		}
+
+		var keys []string
+		if _, err := redigo.Scan(reply, &cursor, &keys); err != nil {
+			return errors.WrapIf(err, "failed to scan price history keys")
+		}
+
+		for _, key := range keys {
+			expired, err := redigo.Int(conn.Do("ZCOUNT", key, "-inf", "("+formatScore(score)))
+			if err != nil {
+				return errors.WrapIfWithDetails(err, "failed to count expired price points", "key", key)
+			}
+
+			// the newest expired point is kept, it holds the price at the start of the retention period
+			if expired > 1 {
+				if _, err := conn.Do("ZREMRANGEBYRANK", key, 0, expired-2); err != nil {
+					return errors.WrapIfWithDetails(err, "failed to remove expired price points", "key", key)
+				}
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func formatScore(score int64) string {
+	return strconv.FormatInt(score, 10)
+}
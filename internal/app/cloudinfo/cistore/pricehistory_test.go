@@ -0,0 +1,113 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/bolt"
+)
+
+func testPriceHistoryStore(t *testing.T, store cloudinfo.PriceHistoryStore) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+
+	for i, price := range []float64{0.1, 0.2, 0.3, 0.4} {
+		assert.NoError(t, store.AppendPrices("amazon", "eu-west-1", at(i*10), map[string]types.Price{
+			"m5.large":  {OnDemandPrice: price, SpotPrice: types.SpotPriceInfo{"eu-west-1b": price / 2, "eu-west-1a": price / 4}},
+			"m5.xlarge": {OnDemandPrice: price * 2},
+		}))
+	}
+	assert.NoError(t, store.AppendPrices("amazon", "eu-west-2", at(0), map[string]types.Price{"m5.large": {OnDemandPrice: 1}}))
+
+	prices := func(from, to time.Time) []float64 {
+		points, err := store.GetPriceHistory("amazon", "eu-west-1", "m5.large", from, to)
+		assert.NoError(t, err)
+
+		res := make([]float64, 0, len(points))
+		for _, point := range points {
+			res = append(res, point.OnDemandPrice)
+		}
+
+		return res
+	}
+
+	// the point before the range holds the price at the start of the range
+	assert.Equal(t, []float64{0.2, 0.3}, prices(at(15), at(25)))
+	assert.Equal(t, []float64{0.2, 0.3}, prices(at(10), at(20)))
+	assert.Equal(t, []float64{0.4}, prices(at(40), at(50)))
+	assert.Equal(t, []float64{}, prices(at(-10), at(-5)))
+
+	points, err := store.GetPriceHistory("amazon", "eu-west-1", "m5.large", at(0), at(0))
+	assert.NoError(t, err)
+	assert.Equal(t, []types.PricePoint{{
+		Time:          at(0),
+		OnDemandPrice: 0.1,
+		SpotPrice:     []types.ZonePrice{{Zone: "eu-west-1a", Price: 0.025}, {Zone: "eu-west-1b", Price: 0.05}},
+	}}, points)
+
+	// the newest expired point is kept
+	assert.NoError(t, store.Prune(at(25)))
+	assert.Equal(t, []float64{0.3, 0.4}, prices(at(-10), at(50)))
+
+	points, err = store.GetPriceHistory("amazon", "eu-west-2", "m5.large", at(0), at(50))
+	assert.NoError(t, err)
+	assert.Len(t, points, 1)
+}
+
+func TestMemoryPriceHistoryStore(t *testing.T) {
+	testPriceHistoryStore(t, NewMemoryPriceHistoryStore())
+}
+
+func TestBoltPriceHistoryStore(t *testing.T) {
+	store := NewBoltPriceHistoryStore(
+		bolt.Config{Enabled: true, Path: filepath.Join(t.TempDir(), "cloudinfo.db"), Timeout: time.Second},
+		cloudinfoadapter.NewLogger(&logur.TestLogger{}),
+	)
+	defer store.(*boltPriceHistoryStore).Close()
+
+	testPriceHistoryStore(t, store)
+}
+
+func TestPriceHistoryConfig_Validate(t *testing.T) {
+	tests := map[string]PriceHistoryConfig{
+		"price history retention must be positive": {
+			Enabled:       true,
+			PruneInterval: time.Hour,
+		},
+		"price history prune interval must be positive": {
+			Enabled:   true,
+			Retention: time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, test.Validate(), name)
+		})
+	}
+}
@@ -19,16 +19,27 @@ import (
 	"fmt"
 	"io"
 
+	"emperror.dev/errors"
 	redigo "github.com/gomodule/redigo/redis"
 
 	cloudinfo "github.com/banzaicloud/cloudinfo/internal/cloudinfo"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
 )
 
+const (
+	// redisScanCount the number of keys requested by a SCAN iteration
+	redisScanCount = 500
+
+	// redisImportBatchSize the number of SET commands sent in a single pipeline
+	redisImportBatchSize = 500
+)
+
 type redisProductStore struct {
-	pool *redigo.Pool
-	log  cloudinfo.Logger
+	pool      *redigo.Pool
+	buildInfo buildinfo.BuildInfo
+	log       cloudinfo.Logger
 }
 
 func (rps *redisProductStore) Ready() bool {
@@ -126,27 +137,135 @@ func (rps *redisProductStore) delete(key string) {
 	}
 }
 
-func NewRedisProductStore(config redis.Config, log cloudinfo.Logger) cloudinfo.CloudInfoStore {
+func NewRedisProductStore(config redis.Config, buildInfo buildinfo.BuildInfo, log cloudinfo.Logger) cloudinfo.CloudInfoStore {
 	pool := redis.NewPool(config)
 
 	return &redisProductStore{
-		pool: pool,
-		log:  log.WithFields(map[string]interface{}{"cistore": "redis"}),
+		pool:      pool,
+		buildInfo: buildInfo,
+		log:       log.WithFields(map[string]interface{}{"cistore": "redis"}),
 	}
 }
 
-// Export writes the content of the store into the passed in writer
+// Export writes the content of the store into the passed in writer in the snapshot format
 func (rps *redisProductStore) Export(w io.Writer) error {
-	// todo implement the export
+	exported, err := exportSnapshot(w, rps, rps.buildInfo)
+	if err != nil {
+		rps.log.Error("failed to export the store", map[string]interface{}{"op": "export"})
+		return errors.WithDetails(err, "op", "export")
+	}
+
+	rps.log.Info("store exported", map[string]interface{}{"op": "export", "entries": exported})
 	return nil
 }
 
-// Import loads the store data from the standard input
+// Import loads the store data from the passed in snapshot reader
 func (rps *redisProductStore) Import(r io.Reader) error {
-	// todo implement the import
+	header, imported, err := importSnapshot(r, rps)
+	if err != nil {
+		rps.log.Error("failed to load store data", map[string]interface{}{"op": "import"})
+		return errors.WithDetails(err, "op", "import")
+	}
+
+	rps.log.Info("store imported", map[string]interface{}{"op": "import", "entries": imported,
+		"schemaVersion": header.SchemaVersion, "createdAt": header.CreatedAt})
 	return nil
 }
 
+// walk iterates over the keys matching the pattern using SCAN so that the redis server is not blocked
+func (rps *redisProductStore) walk(pattern string, fn func(key string, value []byte) error) error {
+	conn := rps.pool.Get()
+	defer conn.Close()
+
+	var cursor int64
+	for {
+		reply, err := redigo.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", redisScanCount))
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to scan keys", "cursor", cursor)
+		}
+
+		if cursor, err = redigo.Int64(reply[0], nil); err != nil {
+			return errors.WrapIf(err, "invalid scan cursor")
+		}
+
+		keys, err := redigo.Strings(reply[1], nil)
+		if err != nil {
+			return errors.WrapIf(err, "invalid scan reply")
+		}
+
+		if len(keys) > 0 {
+			values, err := redigo.ByteSlices(conn.Do("MGET", redigo.Args{}.AddFlat(keys)...))
+			if err != nil {
+				return errors.WrapIf(err, "failed to get values")
+			}
+
+			for i, key := range keys {
+				// the key may have been deleted since it was scanned
+				if values[i] == nil {
+					continue
+				}
+
+				if err := fn(key, values[i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// load writes the entries in pipelined batches, existing keys are overwritten
+func (rps *redisProductStore) load(next func() (storeEntry, error)) (int, error) {
+	conn := rps.pool.Get()
+	defer conn.Close()
+
+	var batch, loaded int
+
+	flush := func() error {
+		if batch == 0 {
+			return nil
+		}
+
+		if err := conn.Flush(); err != nil {
+			return errors.WrapIf(err, "failed to flush entries")
+		}
+
+		for ; batch > 0; batch-- {
+			if _, err := conn.Receive(); err != nil {
+				return errors.WrapIf(err, "failed to store entry")
+			}
+			loaded++
+		}
+
+		return nil
+	}
+
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		if err := conn.Send("SET", entry.Key, []byte(entry.Value)); err != nil {
+			return loaded, errors.WrapIfWithDetails(err, "failed to send entry", "key", entry.Key)
+		}
+
+		if batch++; batch >= redisImportBatchSize {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+
+	return loaded, flush()
+}
+
 func (rps *redisProductStore) StoreRegions(provider, service string, val map[string]string) {
 	rps.set(rps.getKey(cloudinfo.RegionKeyTemplate, provider, service), val)
 }
@@ -186,6 +305,44 @@ func (rps *redisProductStore) GetPrice(provider, region, instanceType string) (t
 	return res, ok
 }
 
+// GetPrices retrieves the prices with a single MGET
+func (rps *redisProductStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	prices := make(map[string]types.Price, len(instanceTypes))
+	if len(instanceTypes) == 0 {
+		return prices, false
+	}
+
+	keys := make([]string, 0, len(instanceTypes))
+	for _, instanceType := range instanceTypes {
+		keys = append(keys, rps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType))
+	}
+
+	conn := rps.pool.Get()
+	defer conn.Close()
+
+	values, err := redigo.ByteSlices(conn.Do("MGET", redigo.Args{}.AddFlat(keys)...))
+	if err != nil {
+		rps.log.Debug("failed to get entries", map[string]interface{}{"provider": provider, "region": region})
+		return prices, false
+	}
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+
+		var price types.Price
+		if err := json.Unmarshal(value, &price); err != nil {
+			rps.log.Debug("failed to unmarshal cache entry", map[string]interface{}{"key": keys[i]})
+			continue
+		}
+
+		prices[instanceTypes[i]] = price
+	}
+
+	return prices, len(prices) > 0
+}
+
 func (rps *redisProductStore) StoreVm(provider, service, region string, val []types.VMInfo) {
 	rps.set(rps.getKey(cloudinfo.VmKeyTemplate, provider, service, region), val)
 }
@@ -203,6 +360,23 @@ func (rps *redisProductStore) DeleteVm(provider, service, region string) {
 	rps.delete(rps.getKey(cloudinfo.VmKeyTemplate, provider, service, region))
 }
 
+func (rps *redisProductStore) StoreProductDetails(provider, service, region string, val []types.ProductDetails) {
+	rps.set(rps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), val)
+}
+
+func (rps *redisProductStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	var (
+		res = make([]types.ProductDetails, 0)
+	)
+	_, ok := rps.get(rps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (rps *redisProductStore) DeleteProductDetails(provider, service, region string) {
+	rps.delete(rps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region))
+}
+
 func (rps *redisProductStore) StoreImage(provider, service, regionId string, val []types.Image) {
 	rps.set(rps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId), val)
 }
@@ -229,6 +403,79 @@ func (rps *redisProductStore) GetVersion(provider, service, region string) ([]ty
 	return res, ok
 }
 
+// StoreRegionData replaces the region entries in a single MULTI / EXEC transaction
+func (rps *redisProductStore) StoreRegionData(provider, service, region string, data cloudinfo.RegionData) error {
+	entries, err := marshalRegionEntries(provider, service, region, data)
+	if err != nil {
+		return errors.WithDetails(err, "provider", provider, "service", service, "region", region)
+	}
+
+	conn := rps.pool.Get()
+	defer conn.Close()
+
+	if err := conn.Send("MULTI"); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to start transaction", "provider", provider, "service", service, "region", region)
+	}
+
+	for _, entry := range entries {
+		if err := conn.Send("SET", entry.Key, []byte(entry.Value)); err != nil {
+			return errors.WrapIfWithDetails(err, "failed to send entry", "provider", provider, "service", service, "region", region)
+		}
+	}
+
+	_, err = conn.Do("EXEC")
+
+	return errors.WrapIfWithDetails(err, "failed to store region data", "provider", provider, "service", service, "region", region)
+}
+
+func (rps *redisProductStore) GetGeneration(provider, service, region string) (int64, bool) {
+	var (
+		res int64
+	)
+	_, ok := rps.get(rps.getKey(cloudinfo.GenerationKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (rps *redisProductStore) StoreRegionStatus(provider, service, region string, val types.RegionStatus) {
+	rps.set(rps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), val)
+}
+
+func (rps *redisProductStore) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	var (
+		res types.RegionStatus
+	)
+	_, ok := rps.get(rps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (rps *redisProductStore) StoreQuarantine(provider, service, region string, val types.QuarantinedScrape) {
+	rps.set(rps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), val)
+}
+
+func (rps *redisProductStore) GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool) {
+	var (
+		res types.QuarantinedScrape
+	)
+	_, ok := rps.get(rps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), &res)
+
+	return res, ok
+}
+
+func (rps *redisProductStore) StoreScrapeRuns(provider string, val []types.ScrapeRun) {
+	rps.set(rps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), val)
+}
+
+func (rps *redisProductStore) GetScrapeRuns(provider string) ([]types.ScrapeRun, bool) {
+	var (
+		res []types.ScrapeRun
+	)
+	_, ok := rps.get(rps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), &res)
+
+	return res, ok
+}
+
 func (rps *redisProductStore) StoreStatus(provider string, val string) {
 	rps.set(rps.getKey(cloudinfo.StatusKeyTemplate, provider), val)
 }
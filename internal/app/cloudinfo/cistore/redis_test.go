@@ -15,6 +15,7 @@
 package cistore
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -23,6 +24,8 @@ import (
 	"logur.dev/logur"
 
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
 )
 
@@ -34,7 +37,7 @@ func testRedisStore(t *testing.T) {
 		Port: 6379,
 	}
 
-	ps := NewRedisProductStore(cfg, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	ps := NewRedisProductStore(cfg, buildinfo.New("test", "", ""), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
 
 	ctx, cancelFunction := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFunction()
@@ -59,4 +62,68 @@ loop:
 	status, ok := ps.GetStatus("amazon")
 	assert.True(t, ok)
 	assert.Equal(t, "status", status)
+
+	// bulk price read
+	ps.StorePrice("amazon", "eu-west-1", "m5.large", types.Price{OnDemandPrice: 0.1})
+	prices, ok := ps.GetPrices("amazon", "eu-west-1", []string{"m5.large", "missing"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]types.Price{"m5.large": {OnDemandPrice: 0.1}}, prices)
+
+	// export the content of the store
+	var buf bytes.Buffer
+	assert.NoError(t, ps.Export(&buf))
+
+	// overwrite the entry, then restore it from the export
+	ps.StoreStatus("amazon", "changed")
+	assert.NoError(t, ps.Import(&buf))
+
+	status, ok = ps.GetStatus("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "status", status)
+}
+
+func testRedisPriceHistoryStore(t *testing.T) {
+	cfg := redis.Config{
+		Host: "localhost",
+		Port: 6379,
+	}
+
+	testPriceHistoryStore(t, NewRedisPriceHistoryStore(cfg, cloudinfoadapter.NewLogger(&logur.TestLogger{})))
+}
+
+func testRedisLock(t *testing.T) {
+	cfg := redis.Config{
+		Host: "localhost",
+		Port: 6379,
+	}
+
+	var (
+		ctx    = context.Background()
+		key    = "/banzaicloud.com/cloudinfo-lock/providers/test/scrape"
+		first  = NewRedisLock(cfg, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+		second = NewRedisLock(cfg, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	)
+
+	acquired, err := first.Acquire(ctx, key, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// renewal by the owner
+	acquired, err = first.Acquire(ctx, key, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = second.Acquire(ctx, key, time.Second)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// only the owner releases the lock
+	assert.NoError(t, second.Release(ctx, key))
+	assert.NoError(t, first.Release(ctx, key))
+
+	acquired, err = second.Acquire(ctx, key, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	assert.NoError(t, second.Release(ctx, key))
 }
@@ -0,0 +1,69 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+)
+
+// regionEntry a key and the value stored under it
+type regionEntry struct {
+	key   string
+	value interface{}
+}
+
+// regionEntries lists the entries the region data is stored in, the status and the generation are the last ones
+func regionEntries(provider, service, region string, data cloudinfo.RegionData) []regionEntry {
+	entries := []regionEntry{
+		{key: fmt.Sprintf(cloudinfo.ZoneKeyTemplate, provider, service, region), value: data.Zones},
+		{key: fmt.Sprintf(cloudinfo.VmKeyTemplate, provider, service, region), value: data.Vms},
+		{key: fmt.Sprintf(cloudinfo.VersionKeyTemplate, provider, service, region), value: data.Versions},
+	}
+
+	if data.ProductDetails != nil {
+		entries = append(entries, regionEntry{key: fmt.Sprintf(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), value: data.ProductDetails})
+	}
+
+	if data.Images != nil {
+		entries = append(entries, regionEntry{key: fmt.Sprintf(cloudinfo.ImageKeyTemplate, provider, service, region), value: data.Images})
+	}
+
+	return append(entries,
+		regionEntry{key: fmt.Sprintf(cloudinfo.RegionStatusKeyTemplate, provider, service, region), value: data.Status},
+		regionEntry{key: fmt.Sprintf(cloudinfo.GenerationKeyTemplate, provider, service, region), value: data.Generation},
+	)
+}
+
+// marshalRegionEntries returns the region entries with the json representation of their values
+func marshalRegionEntries(provider, service, region string, data cloudinfo.RegionData) ([]storeEntry, error) {
+	entries := regionEntries(provider, service, region, data)
+
+	marshaled := make([]storeEntry, 0, len(entries))
+	for _, entry := range entries {
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "failed to marshal value into json", "key", entry.key)
+		}
+
+		marshaled = append(marshaled, storeEntry{Key: entry.key, Value: value})
+	}
+
+	return marshaled, nil
+}
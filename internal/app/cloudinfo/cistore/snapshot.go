@@ -0,0 +1,395 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
+)
+
+const (
+	// SnapshotFormat identifies cloud info snapshots
+	SnapshotFormat = "cloudinfo-snapshot"
+
+	// SnapshotSchemaVersion the version of the snapshot layout written by this application
+	// increment it (and register a migration) whenever the layout of the entries changes
+	SnapshotSchemaVersion = 1
+)
+
+// SnapshotHeader is the first document of a snapshot, it describes the content that follows
+type SnapshotHeader struct {
+	// Format is always SnapshotFormat
+	Format string `json:"format"`
+
+	// SchemaVersion the version of the snapshot layout
+	SchemaVersion int `json:"schemaVersion"`
+
+	// CreatedAt the time the snapshot was taken
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Build information of the application that took the snapshot
+	Build buildinfo.BuildInfo `json:"build"`
+
+	// Providers holds the status (last scrape timestamp in milliseconds) of the providers in the snapshot
+	Providers map[string]string `json:"providers"`
+}
+
+// ProviderNames returns the providers in the snapshot in alphabetical order
+func (h SnapshotHeader) ProviderNames() []string {
+	providers := make([]string, 0, len(h.Providers))
+	for provider := range h.Providers {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	return providers
+}
+
+// storeEntry is the snapshot representation of a single store item
+// the value is the json representation of the stored value
+type storeEntry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// snapshotStore is implemented by the stores in order to be exported to / imported from snapshots
+type snapshotStore interface {
+	// walk calls fn with the key and the json representation of the value of every entry with a key matching the pattern
+	walk(pattern string, fn func(key string, value []byte) error) error
+
+	// load stores the entries returned by next till it returns io.EOF; returns the number of stored entries
+	load(next func() (storeEntry, error)) (int, error)
+}
+
+// snapshotMigrations upgrade entries written with older schema versions, indexed by the version they upgrade from
+// nolint: gochecknoglobals
+var snapshotMigrations = map[int]func(storeEntry) (storeEntry, error){
+	// version 0: headerless, uncompressed entries exported by the first redis / cassandra export implementations
+	0: func(entry storeEntry) (storeEntry, error) { return entry, nil },
+}
+
+// exportSnapshot writes the content of the store into the passed in writer in the snapshot format
+func exportSnapshot(w io.Writer, store snapshotStore, buildInfo buildinfo.BuildInfo) (int, error) {
+	header := SnapshotHeader{
+		Format:        SnapshotFormat,
+		SchemaVersion: SnapshotSchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+		Build:         buildInfo,
+		Providers:     make(map[string]string),
+	}
+
+	statusPattern := fmt.Sprintf(cloudinfo.StatusKeyTemplate, "*")
+	err := store.walk(statusPattern, func(key string, value []byte) error {
+		var status string
+		if err := json.Unmarshal(value, &status); err != nil {
+			return errors.WrapIfWithDetails(err, "invalid provider status", "key", key)
+		}
+
+		provider := strings.Split(strings.TrimPrefix(key, cloudinfo.KeyPrefix+"providers/"), "/")[0]
+		header.Providers[provider] = status
+
+		return nil
+	})
+	if err != nil {
+		return 0, errors.WrapIf(err, "failed to collect provider status")
+	}
+
+	gw := gzip.NewWriter(w)
+	enc := json.NewEncoder(gw)
+
+	if err := enc.Encode(header); err != nil {
+		return 0, errors.WrapIf(err, "failed to write snapshot header")
+	}
+
+	var exported int
+	err = store.walk(cloudinfo.KeyPrefix+"*", func(key string, value []byte) error {
+		if err := enc.Encode(storeEntry{Key: key, Value: value}); err != nil {
+			return errors.WrapIfWithDetails(err, "failed to write snapshot entry", "key", key)
+		}
+		exported++
+
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	if err := gw.Close(); err != nil {
+		return exported, errors.WrapIf(err, "failed to finish snapshot")
+	}
+
+	return exported, nil
+}
+
+// importSnapshot loads the content of the snapshot read from the passed in reader into the store
+func importSnapshot(r io.Reader, store snapshotStore) (SnapshotHeader, int, error) {
+	sr, err := newSnapshotReader(r)
+	if err != nil {
+		return SnapshotHeader{}, 0, err
+	}
+
+	imported, err := store.load(sr.Next)
+
+	return sr.Header(), imported, err
+}
+
+// snapshotReader reads the entries of a snapshot upgrading them to the current schema version
+type snapshotReader struct {
+	header  SnapshotHeader
+	dec     *json.Decoder
+	pending *storeEntry
+}
+
+func newSnapshotReader(r io.Reader) (*snapshotReader, error) {
+	br := bufio.NewReader(r)
+
+	var src io.Reader = br
+	if isGzip(br) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.WrapIf(err, "failed to decompress snapshot")
+		}
+		src = gr
+	}
+
+	sr := &snapshotReader{dec: json.NewDecoder(src)}
+
+	var first json.RawMessage
+	if err := sr.dec.Decode(&first); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("empty snapshot")
+		}
+		return nil, errors.WrapIf(err, "failed to read snapshot")
+	}
+
+	if err := json.Unmarshal(first, &sr.header); err != nil {
+		return nil, errors.WrapIf(err, "failed to read snapshot header")
+	}
+
+	if sr.header.Format != SnapshotFormat {
+		// headerless snapshot, the first document is already an entry
+		var entry storeEntry
+		if err := json.Unmarshal(first, &entry); err != nil {
+			return nil, errors.WrapIf(err, "failed to read snapshot entry")
+		}
+
+		sr.header = SnapshotHeader{}
+		sr.pending = &entry
+	}
+
+	if sr.header.SchemaVersion > SnapshotSchemaVersion {
+		return nil, errors.NewWithDetails("unsupported snapshot schema version",
+			"schemaVersion", sr.header.SchemaVersion, "supported", SnapshotSchemaVersion)
+	}
+
+	return sr, nil
+}
+
+// Header returns the header of the snapshot
+func (sr *snapshotReader) Header() SnapshotHeader {
+	return sr.header
+}
+
+// Next returns the next entry of the snapshot, io.EOF is returned when there are no more entries
+func (sr *snapshotReader) Next() (storeEntry, error) {
+	var entry storeEntry
+
+	if sr.pending != nil {
+		entry, sr.pending = *sr.pending, nil
+	} else if err := sr.dec.Decode(&entry); err != nil {
+		if err == io.EOF {
+			return entry, err
+		}
+		return entry, errors.WrapIf(err, "failed to read snapshot entry")
+	}
+
+	if !strings.HasPrefix(entry.Key, cloudinfo.KeyPrefix) {
+		return entry, errors.NewWithDetails("invalid snapshot entry key", "key", entry.Key)
+	}
+
+	if len(entry.Value) == 0 {
+		return entry, errors.NewWithDetails("missing snapshot entry value", "key", entry.Key)
+	}
+
+	for version := sr.header.SchemaVersion; version < SnapshotSchemaVersion; version++ {
+		var err error
+		if entry, err = snapshotMigrations[version](entry); err != nil {
+			return entry, errors.WrapIfWithDetails(err, "failed to migrate snapshot entry",
+				"key", entry.Key, "schemaVersion", version)
+		}
+	}
+
+	return entry, nil
+}
+
+// isGzip checks whether the buffered content is gzip compressed
+func isGzip(br *bufio.Reader) bool {
+	magic, err := br.Peek(2)
+
+	return err == nil && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+// isSnapshot checks whether the buffered content is a (possibly headerless) snapshot
+func isSnapshot(br *bufio.Reader) bool {
+	if isGzip(br) {
+		return true
+	}
+
+	start, _ := br.Peek(64)
+
+	return bytes.HasPrefix(bytes.TrimSpace(start), []byte("{"))
+}
+
+// matchKey checks whether the key matches the pattern; like in redis globs '*' matches any sequence of characters
+func matchKey(pattern, key string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return key == pattern
+	}
+
+	if !strings.HasPrefix(key, parts[0]) {
+		return false
+	}
+	key = key[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(key, part)
+		if idx < 0 {
+			return false
+		}
+		key = key[idx+len(part):]
+	}
+
+	return strings.HasSuffix(key, parts[len(parts)-1])
+}
+
+// snapshotValue binds a key format to the type of the values stored under it
+type snapshotValue struct {
+	key    *regexp.Regexp
+	decode func(raw []byte) (interface{}, error)
+}
+
+// snapshotValues describes the typed values held by the stores
+// nolint: gochecknoglobals
+var snapshotValues = []snapshotValue{
+	newSnapshotValue(cloudinfo.RegionKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val map[string]string
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.ZoneKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []string
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.PriceKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val types.Price
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.VmKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []types.VMInfo
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.ProductDetailsKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []types.ProductDetails
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.ImageKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []types.Image
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.VersionKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []types.LocationVersion
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.GenerationKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val int64
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.RegionStatusKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val types.RegionStatus
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.QuarantineKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val types.QuarantinedScrape
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.ScrapeRunsKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []types.ScrapeRun
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.StatusKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val string
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+	newSnapshotValue(cloudinfo.ServicesKeyTemplate, func(raw []byte) (interface{}, error) {
+		var val []types.Service
+		err := json.Unmarshal(raw, &val)
+		return val, err
+	}),
+}
+
+// newSnapshotValue builds a snapshotValue matching the keys generated from the template
+func newSnapshotValue(keyTemplate string, decode func(raw []byte) (interface{}, error)) snapshotValue {
+	pattern := strings.ReplaceAll(regexp.QuoteMeta(keyTemplate), "%s", "[^/]+")
+
+	return snapshotValue{
+		key:    regexp.MustCompile("^" + pattern + "$"),
+		decode: decode,
+	}
+}
+
+// decodeSnapshotValue decodes the raw json value into the type stored under the given key
+// the second return value is false if the key is unknown
+func decodeSnapshotValue(key string, raw []byte) (interface{}, bool, error) {
+	for _, sv := range snapshotValues {
+		if !sv.key.MatchString(key) {
+			continue
+		}
+
+		val, err := sv.decode(raw)
+		if err != nil {
+			return nil, true, errors.WrapIfWithDetails(err, "failed to decode snapshot value", "key", key)
+		}
+
+		return val, true, nil
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,270 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+)
+
+const (
+	// snapshotFilePrefix the prefix of the snapshot files written to the snapshot directory
+	snapshotFilePrefix = "cloudinfo-"
+	// snapshotFileSuffix the suffix of the snapshot files written to the snapshot directory
+	snapshotFileSuffix = ".json.gz"
+	// snapshotFileTimeFormat the format of the time in the snapshot file names, they sort in chronological order
+	snapshotFileTimeFormat = "20060102T150405Z"
+)
+
+// SnapshotConfig configuration of the snapshots of the store written periodically to the local disk
+type SnapshotConfig struct {
+	Enabled bool
+
+	// Dir the directory the snapshots are written to and restored from
+	Dir string
+
+	// Interval the interval the snapshots are written in
+	Interval time.Duration
+
+	// Keep the number of snapshots kept in the directory
+	Keep int
+}
+
+// Validate checks the snapshot configuration
+func (c SnapshotConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Dir == "" {
+		return errors.New("snapshot directory is required")
+	}
+
+	if c.Interval <= 0 {
+		return errors.New("snapshot interval must be positive")
+	}
+
+	if c.Keep < 1 {
+		return errors.New("at least one snapshot must be kept")
+	}
+
+	return nil
+}
+
+// WriteSnapshotFile writes the content of the store into a new snapshot file in the directory
+// the file is renamed into place once complete, the snapshots beyond the configured number are removed
+func WriteSnapshotFile(store cloudinfo.CloudInfoStore, conf SnapshotConfig, now time.Time) (string, error) {
+	if err := os.MkdirAll(conf.Dir, 0755); err != nil {
+		return "", errors.WrapIfWithDetails(err, "failed to create snapshot directory", "dir", conf.Dir)
+	}
+
+	path := filepath.Join(conf.Dir, snapshotFilePrefix+now.UTC().Format(snapshotFileTimeFormat)+snapshotFileSuffix)
+	if err := ExportSnapshotFile(store, path); err != nil {
+		return "", err
+	}
+
+	files, err := snapshotFiles(conf.Dir)
+	if err != nil {
+		return path, err
+	}
+
+	for i := conf.Keep; i < len(files); i++ {
+		if err := os.Remove(files[i]); err != nil {
+			return path, errors.WrapIfWithDetails(err, "failed to remove old snapshot file", "file", files[i])
+		}
+	}
+
+	return path, nil
+}
+
+// ExportSnapshotFile writes the content of the store into the snapshot file
+// the snapshot is written into a temporary file next to it first, which is renamed once complete
+func ExportSnapshotFile(store cloudinfo.CloudInfoStore, path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+snapshotFilePrefix+"*.tmp")
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "failed to create snapshot file", "file", path)
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if err := store.Export(tmp); err != nil {
+		_ = tmp.Close()
+		return errors.WrapIf(err, "failed to export store")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to write snapshot file", "file", tmp.Name())
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to rename snapshot file", "file", path)
+	}
+
+	return nil
+}
+
+// WriteSnapshots returns a task writing the content of the store into the snapshot directory
+// nothing is written till the store holds the data of at least one of the providers
+func WriteSnapshots(store cloudinfo.CloudInfoStore, conf SnapshotConfig, providers []string, log cloudinfo.Logger, errorHandler cloudinfo.ErrorHandler) cloudinfo.TaskFn {
+	return func(ctx context.Context) {
+		if !hasProviderData(store, providers) {
+			log.Debug("no provider data to snapshot yet")
+			return
+		}
+
+		path, err := WriteSnapshotFile(store, conf, time.Now())
+		if err != nil {
+			errorHandler.Handle(errors.WrapIf(err, "failed to write snapshot"))
+			return
+		}
+
+		log.Debug("snapshot written", map[string]interface{}{"file": path})
+	}
+}
+
+// RestoreSnapshot loads the most recent readable snapshot of the directory into the store
+// the restored regions are marked with the time of the snapshot till fresh scrapes replace their data
+// nothing is restored if the store already holds the data of a provider of the snapshot
+func RestoreSnapshot(store cloudinfo.CloudInfoStore, dir string, log cloudinfo.Logger) (SnapshotHeader, bool, error) {
+	files, err := snapshotFiles(dir)
+	if err != nil {
+		return SnapshotHeader{}, false, err
+	}
+
+	for _, file := range files {
+		header, err := readSnapshotHeader(file)
+		if err != nil {
+			log.Warn("skipping unreadable snapshot", map[string]interface{}{"file": file, "error": err})
+			continue
+		}
+
+		for provider := range header.Providers {
+			if _, ok := store.GetStatus(provider); ok {
+				log.Info("store already holds provider data, skipping snapshot restore", map[string]interface{}{"provider": provider})
+				return SnapshotHeader{}, false, nil
+			}
+		}
+
+		if err := importSnapshotFile(store, file); err != nil {
+			log.Warn("skipping unreadable snapshot", map[string]interface{}{"file": file, "error": err})
+			continue
+		}
+
+		markSnapshotRegions(store, header)
+
+		log.Info("restored snapshot", map[string]interface{}{"file": file, "createdAt": header.CreatedAt})
+
+		return header, true, nil
+	}
+
+	return SnapshotHeader{}, false, nil
+}
+
+// LoadSnapshotFile imports the snapshot file into the store, headerless snapshots are not accepted
+func LoadSnapshotFile(store cloudinfo.CloudInfoStore, path string) (SnapshotHeader, error) {
+	header, err := readSnapshotHeader(path)
+	if err != nil {
+		return SnapshotHeader{}, errors.WithDetails(err, "file", path)
+	}
+
+	if err := importSnapshotFile(store, path); err != nil {
+		return SnapshotHeader{}, errors.WrapIfWithDetails(err, "failed to import snapshot", "file", path)
+	}
+
+	return header, nil
+}
+
+// markSnapshotRegions sets the snapshot time on the status of the restored regions not marked already
+// the mark of the regions restored from an earlier snapshot is kept, it tells the age of their data
+func markSnapshotRegions(store cloudinfo.CloudInfoStore, header SnapshotHeader) {
+	snapshotTime := header.CreatedAt
+
+	for provider := range header.Providers {
+		services, _ := store.GetServices(provider)
+
+		for _, service := range services {
+			regions, _ := store.GetRegions(provider, service.Service)
+
+			for region := range regions {
+				status, ok := store.GetRegionStatus(provider, service.Service, region)
+				if !ok || status.SnapshotTime != nil {
+					continue
+				}
+
+				status.SnapshotTime = &snapshotTime
+				store.StoreRegionStatus(provider, service.Service, region, status)
+			}
+		}
+	}
+}
+
+// hasProviderData checks whether the store holds the data of at least one of the providers
+func hasProviderData(store cloudinfo.CloudInfoStore, providers []string) bool {
+	for _, provider := range providers {
+		if _, ok := store.GetStatus(provider); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// snapshotFiles lists the snapshot files of the directory, the most recent first
+func snapshotFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, snapshotFilePrefix+"*"+snapshotFileSuffix))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to list snapshot files", "dir", dir)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	return files, nil
+}
+
+func readSnapshotHeader(path string) (SnapshotHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SnapshotHeader{}, errors.WrapIf(err, "failed to open snapshot file")
+	}
+	defer f.Close()
+
+	sr, err := newSnapshotReader(f)
+	if err != nil {
+		return SnapshotHeader{}, err
+	}
+
+	if sr.Header().Format != SnapshotFormat {
+		return SnapshotHeader{}, errors.NewWithDetails("not a snapshot file", "format", sr.Header().Format)
+	}
+
+	return sr.Header(), nil
+}
+
+func importSnapshotFile(store cloudinfo.CloudInfoStore, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WrapIf(err, "failed to open snapshot file")
+	}
+	defer f.Close()
+
+	return store.Import(f)
+}
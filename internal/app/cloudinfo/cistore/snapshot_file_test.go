@@ -0,0 +1,181 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+func newSnapshotSourceStore(lastSuccess time.Time) *cacheProductStore {
+	store := newTestCacheStore()
+	store.StoreStatus("amazon", "1234")
+	store.StoreServices("amazon", []types.Service{{Service: "compute"}})
+	store.StoreRegions("amazon", "compute", map[string]string{"eu-west-1": "EU (Ireland)"})
+	store.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.large", Cpus: 2, Mem: 8}})
+	store.StoreRegionStatus("amazon", "compute", "eu-west-1", types.RegionStatus{LastSuccess: lastSuccess})
+
+	return store
+}
+
+func TestWriteSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := SnapshotConfig{Enabled: true, Dir: dir, Interval: time.Minute, Keep: 2}
+	store := newSnapshotSourceStore(time.Now())
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		_, err := WriteSnapshotFile(store, conf, now.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+
+	files, err := snapshotFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "cloudinfo-20210301T100200Z.json.gz"),
+		filepath.Join(dir, "cloudinfo-20210301T100100Z.json.gz"),
+	}, files)
+
+	all, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, all, 2, "temporary files are removed")
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	log := cloudinfoadapter.NewLogger(&logur.TestLogger{})
+	lastSuccess := time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	writeSnapshot := func(t *testing.T, dir string, now time.Time) {
+		_, err := WriteSnapshotFile(newSnapshotSourceStore(lastSuccess), SnapshotConfig{Dir: dir, Keep: 5}, now)
+		require.NoError(t, err)
+	}
+
+	t.Run("most recent snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+
+		writeSnapshot(t, dir, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC))
+		writeSnapshot(t, dir, time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC))
+		newest, err := snapshotFiles(dir)
+		require.NoError(t, err)
+		expected, err := readSnapshotHeader(newest[0])
+		require.NoError(t, err)
+
+		store := newTestCacheStore()
+		header, restored, err := RestoreSnapshot(store, dir, log)
+		require.NoError(t, err)
+		assert.True(t, restored)
+		assert.Equal(t, expected.CreatedAt, header.CreatedAt)
+
+		vms, ok := store.GetVm("amazon", "compute", "eu-west-1")
+		assert.True(t, ok)
+		assert.Equal(t, "m5.large", vms[0].Type)
+
+		status, ok := store.GetRegionStatus("amazon", "compute", "eu-west-1")
+		assert.True(t, ok)
+		assert.True(t, lastSuccess.Equal(status.LastSuccess))
+		require.NotNil(t, status.SnapshotTime)
+		assert.True(t, header.CreatedAt.Equal(*status.SnapshotTime))
+	})
+
+	t.Run("unreadable snapshot skipped", func(t *testing.T) {
+		dir := t.TempDir()
+
+		writeSnapshot(t, dir, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cloudinfo-20210301T110000Z.json.gz"), []byte("garbage"), 0644))
+
+		store := newTestCacheStore()
+		_, restored, err := RestoreSnapshot(store, dir, log)
+		require.NoError(t, err)
+		assert.True(t, restored)
+
+		_, ok := store.GetVm("amazon", "compute", "eu-west-1")
+		assert.True(t, ok)
+	})
+
+	t.Run("store holding provider data", func(t *testing.T) {
+		dir := t.TempDir()
+
+		writeSnapshot(t, dir, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC))
+
+		store := newTestCacheStore()
+		store.StoreStatus("amazon", "5678")
+
+		_, restored, err := RestoreSnapshot(store, dir, log)
+		require.NoError(t, err)
+		assert.False(t, restored)
+
+		_, ok := store.GetVm("amazon", "compute", "eu-west-1")
+		assert.False(t, ok)
+	})
+
+	t.Run("earlier snapshot time kept", func(t *testing.T) {
+		dir := t.TempDir()
+
+		earlier := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+		src := newSnapshotSourceStore(lastSuccess)
+		src.StoreRegionStatus("amazon", "compute", "eu-west-1", types.RegionStatus{LastSuccess: lastSuccess, SnapshotTime: &earlier})
+		_, err := WriteSnapshotFile(src, SnapshotConfig{Dir: dir, Keep: 1}, time.Now())
+		require.NoError(t, err)
+
+		store := newTestCacheStore()
+		_, restored, err := RestoreSnapshot(store, dir, log)
+		require.NoError(t, err)
+		assert.True(t, restored)
+
+		status, _ := store.GetRegionStatus("amazon", "compute", "eu-west-1")
+		require.NotNil(t, status.SnapshotTime)
+		assert.True(t, earlier.Equal(*status.SnapshotTime))
+	})
+
+	t.Run("no snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+
+		_, restored, err := RestoreSnapshot(newTestCacheStore(), dir, log)
+		require.NoError(t, err)
+		assert.False(t, restored)
+	})
+}
+
+func TestLoadSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := WriteSnapshotFile(newSnapshotSourceStore(time.Now()), SnapshotConfig{Dir: dir, Keep: 1}, time.Now())
+	require.NoError(t, err)
+
+	store := newTestCacheStore()
+	header, err := LoadSnapshotFile(store, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"amazon"}, header.ProviderNames())
+
+	vms, ok := store.GetVm("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+	assert.Equal(t, "m5.large", vms[0].Type)
+
+	headerless := filepath.Join(dir, "headerless.json")
+	require.NoError(t, ioutil.WriteFile(headerless, []byte(`{"key":"/banzaicloud.com/cloudinfo/providers/amazon/status/","value":"1234"}`), 0644))
+
+	_, err = LoadSnapshotFile(newTestCacheStore(), headerless)
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
+)
+
+func newTestCacheStore() *cacheProductStore {
+	return NewCacheProductStore(time.Hour, time.Hour, buildinfo.New("test", "abc", "today"),
+		cloudinfoadapter.NewLogger(&logur.TestLogger{})).(*cacheProductStore)
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	src := newTestCacheStore()
+	src.StoreStatus("amazon", "1234")
+	src.StoreRegions("amazon", "compute", map[string]string{"eu-west-1": "EU (Ireland)"})
+	src.StoreZones("amazon", "compute", "eu-west-1", []string{"eu-west-1a"})
+	src.StorePrice("amazon", "eu-west-1", "m5.large", types.Price{OnDemandPrice: 0.1, SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.05}})
+	src.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.large", Cpus: 2, Mem: 8}})
+	src.StoreServices("amazon", []types.Service{{Service: "compute"}})
+
+	var buf bytes.Buffer
+	assert.NoError(t, src.Export(&buf))
+
+	gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	header, err := bufio.NewReader(gr).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Contains(t, header, `"format":"cloudinfo-snapshot"`)
+	assert.Contains(t, header, `"schemaVersion":1`)
+	assert.Contains(t, header, `"providers":{"amazon":"1234"}`)
+	assert.Contains(t, header, `"commit_hash":"abc"`)
+
+	dst := newTestCacheStore()
+	assert.NoError(t, dst.Import(&buf))
+
+	status, ok := dst.GetStatus("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "1234", status)
+
+	regions, ok := dst.GetRegions("amazon", "compute")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"eu-west-1": "EU (Ireland)"}, regions)
+
+	zones, ok := dst.GetZones("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"eu-west-1a"}, zones)
+
+	price, ok := dst.GetPrice("amazon", "eu-west-1", "m5.large")
+	assert.True(t, ok)
+	assert.Equal(t, 0.05, price.SpotPrice["eu-west-1a"])
+
+	vms, ok := dst.GetVm("amazon", "compute", "eu-west-1")
+	assert.True(t, ok)
+	assert.Equal(t, "m5.large", vms[0].Type)
+
+	services, ok := dst.GetServices("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "compute", services[0].Service)
+}
+
+func TestSnapshot_Import(t *testing.T) {
+	tests := map[string]struct {
+		snapshot string
+		check    func(t *testing.T, store *cacheProductStore, err error)
+	}{
+		"headerless entries": {
+			snapshot: `{"key":"/banzaicloud.com/cloudinfo/providers/amazon/status/","value":"1234"}
+{"key":"/banzaicloud.com/cloudinfo/providers/amazon/services/compute/regions/","value":{"eu-west-1":"EU (Ireland)"}}`,
+			check: func(t *testing.T, store *cacheProductStore, err error) {
+				assert.NoError(t, err)
+
+				status, _ := store.GetStatus("amazon")
+				assert.Equal(t, "1234", status)
+
+				regions, _ := store.GetRegions("amazon", "compute")
+				assert.Equal(t, "EU (Ireland)", regions["eu-west-1"])
+			},
+		},
+		"unsupported schema version": {
+			snapshot: `{"format":"cloudinfo-snapshot","schemaVersion":99}`,
+			check: func(t *testing.T, store *cacheProductStore, err error) {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "unsupported snapshot schema version")
+			},
+		},
+		"invalid entry key": {
+			snapshot: `{"format":"cloudinfo-snapshot","schemaVersion":1}
+{"key":"/other/key","value":"1"}`,
+			check: func(t *testing.T, store *cacheProductStore, err error) {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid snapshot entry key")
+			},
+		},
+		"invalid entry value": {
+			snapshot: `{"format":"cloudinfo-snapshot","schemaVersion":1}
+{"key":"/banzaicloud.com/cloudinfo/providers/amazon/services","value":"compute"}`,
+			check: func(t *testing.T, store *cacheProductStore, err error) {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to decode snapshot value")
+			},
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			store := newTestCacheStore()
+
+			test.check(t, store, store.Import(strings.NewReader(test.snapshot)))
+		})
+	}
+}
+
+func TestCacheProductStore_ImportGob(t *testing.T) {
+	src := newTestCacheStore()
+	src.StoreStatus("amazon", "1234")
+
+	var buf bytes.Buffer
+	assert.NoError(t, src.Save(&buf))
+
+	dst := newTestCacheStore()
+	assert.NoError(t, dst.Import(&buf))
+
+	status, ok := dst.GetStatus("amazon")
+	assert.True(t, ok)
+	assert.Equal(t, "1234", status)
+}
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{pattern: "/banzaicloud.com/cloudinfo/*", key: "/banzaicloud.com/cloudinfo/providers/amazon/status/", match: true},
+		{pattern: "/banzaicloud.com/cloudinfo/providers/*/status/", key: "/banzaicloud.com/cloudinfo/providers/amazon/status/", match: true},
+		{pattern: "/banzaicloud.com/cloudinfo/providers/*/status/", key: "/banzaicloud.com/cloudinfo/providers/amazon/services", match: false},
+		{pattern: "/banzaicloud.com/cloudinfo/providers/amazon/services", key: "/banzaicloud.com/cloudinfo/providers/amazon/services", match: true},
+		{pattern: "/other/*", key: "/banzaicloud.com/cloudinfo/providers/amazon/services", match: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.match, matchKey(test.pattern, test.key), test.pattern+" "+test.key)
+	}
+}
@@ -0,0 +1,505 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// providerStatus the last seen status of a provider
+type providerStatus struct {
+	status    string
+	checkedAt time.Time
+}
+
+// regionStatus the last seen status of a service region
+type regionStatus struct {
+	status    types.RegionStatus
+	checkedAt time.Time
+}
+
+// cachedEntry a value of the local cache, it's reloaded from the remote store once expired
+type cachedEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// tieredProductStore keeps a bounded in-memory cache in front of a (remote) store
+// reads are served from the cache and loaded from the remote store on miss, writes go to the remote store and
+// invalidate the cached entry; all the cached entries of a provider are dropped when its status changes and
+// the entries of a service region are dropped when the status of the region changes (eg. the region is scraped on demand),
+// so entries written by other instances (eg. the scraper) are picked up within the status check interval;
+// the rest of the changes (eg. the spot prices) are picked up when the entries expire
+type tieredProductStore struct {
+	remote cloudinfo.CloudInfoStore
+	local  *lru.Cache
+	ttl    time.Duration
+
+	statusCheckInterval time.Duration
+	statuses            map[string]providerStatus
+	regionStatuses      map[string]regionStatus
+	mu                  sync.Mutex
+
+	log cloudinfo.Logger
+}
+
+// NewTieredProductStore wraps the passed in store with a bounded in-memory cache
+func NewTieredProductStore(remote cloudinfo.CloudInfoStore, config LocalCacheConfig, log cloudinfo.Logger) (cloudinfo.CloudInfoStore, error) {
+	local, err := lru.New(config.Size)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to create local cache", "size", config.Size)
+	}
+
+	return &tieredProductStore{
+		remote:              remote,
+		local:               local,
+		ttl:                 config.TTL,
+		statusCheckInterval: config.StatusCheckInterval,
+		statuses:            make(map[string]providerStatus),
+		regionStatuses:      make(map[string]regionStatus),
+		log:                 log.WithFields(map[string]interface{}{"cistore": "tiered"}),
+	}, nil
+}
+
+func (tps *tieredProductStore) Ready() bool {
+	return tps.remote.Ready()
+}
+
+func (tps *tieredProductStore) StoreRegions(provider, service string, val map[string]string) {
+	tps.remote.StoreRegions(provider, service, val)
+	tps.local.Remove(tps.getKey(cloudinfo.RegionKeyTemplate, provider, service))
+}
+
+func (tps *tieredProductStore) GetRegions(provider, service string) (map[string]string, bool) {
+	res, ok := tps.get(provider, tps.getKey(cloudinfo.RegionKeyTemplate, provider, service), func() (interface{}, bool) {
+		return tps.remote.GetRegions(provider, service)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.(map[string]string), true
+}
+
+func (tps *tieredProductStore) DeleteRegions(provider, service string) {
+	tps.remote.DeleteRegions(provider, service)
+	tps.local.Remove(tps.getKey(cloudinfo.RegionKeyTemplate, provider, service))
+}
+
+func (tps *tieredProductStore) StoreZones(provider, service, region string, val []string) {
+	tps.remote.StoreZones(provider, service, region, val)
+	tps.local.Remove(tps.getKey(cloudinfo.ZoneKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) GetZones(provider, service, region string) ([]string, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.ZoneKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetZones(provider, service, region)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]string), true
+}
+
+func (tps *tieredProductStore) DeleteZones(provider, service, region string) {
+	tps.remote.DeleteZones(provider, service, region)
+	tps.local.Remove(tps.getKey(cloudinfo.ZoneKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) StorePrice(provider, region, instanceType string, val types.Price) {
+	tps.remote.StorePrice(provider, region, instanceType, val)
+	tps.local.Remove(tps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType))
+}
+
+func (tps *tieredProductStore) GetPrice(provider, region, instanceType string) (types.Price, bool) {
+	res, ok := tps.get(provider, tps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType), func() (interface{}, bool) {
+		return tps.remote.GetPrice(provider, region, instanceType)
+	})
+	if !ok {
+		return types.Price{}, false
+	}
+
+	return res.(types.Price), true
+}
+
+// GetPrices serves the cached prices from the local cache and loads the missing ones with a single bulk read
+func (tps *tieredProductStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	tps.checkStatus(provider)
+
+	prices := make(map[string]types.Price, len(instanceTypes))
+	missing := make([]string, 0)
+	for _, instanceType := range instanceTypes {
+		if val, ok := tps.cached(tps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType)); ok {
+			prices[instanceType] = val.(types.Price)
+			continue
+		}
+		missing = append(missing, instanceType)
+	}
+
+	if len(missing) > 0 {
+		loaded, _ := tps.remote.GetPrices(provider, region, missing)
+		for instanceType, price := range loaded {
+			tps.cache(tps.getKey(cloudinfo.PriceKeyTemplate, provider, region, instanceType), price)
+			prices[instanceType] = price
+		}
+	}
+
+	return prices, len(prices) > 0
+}
+
+func (tps *tieredProductStore) StoreVm(provider, service, region string, val []types.VMInfo) {
+	tps.remote.StoreVm(provider, service, region, val)
+	tps.local.Remove(tps.getKey(cloudinfo.VmKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) GetVm(provider, service, region string) ([]types.VMInfo, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.VmKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetVm(provider, service, region)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]types.VMInfo), true
+}
+
+func (tps *tieredProductStore) DeleteVm(provider, service, region string) {
+	tps.remote.DeleteVm(provider, service, region)
+	tps.local.Remove(tps.getKey(cloudinfo.VmKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) StoreProductDetails(provider, service, region string, val []types.ProductDetails) {
+	tps.remote.StoreProductDetails(provider, service, region, val)
+	tps.local.Remove(tps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetProductDetails(provider, service, region)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]types.ProductDetails), true
+}
+
+func (tps *tieredProductStore) DeleteProductDetails(provider, service, region string) {
+	tps.remote.DeleteProductDetails(provider, service, region)
+	tps.local.Remove(tps.getKey(cloudinfo.ProductDetailsKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) StoreImage(provider, service, regionId string, val []types.Image) {
+	tps.remote.StoreImage(provider, service, regionId, val)
+	tps.local.Remove(tps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId))
+}
+
+func (tps *tieredProductStore) GetImage(provider, service, regionId string) ([]types.Image, bool) {
+	res, ok := tps.getRegional(provider, service, regionId, tps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId), func() (interface{}, bool) {
+		return tps.remote.GetImage(provider, service, regionId)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]types.Image), true
+}
+
+func (tps *tieredProductStore) DeleteImage(provider, service, regionId string) {
+	tps.remote.DeleteImage(provider, service, regionId)
+	tps.local.Remove(tps.getKey(cloudinfo.ImageKeyTemplate, provider, service, regionId))
+}
+
+func (tps *tieredProductStore) StoreVersion(provider, service, region string, val []types.LocationVersion) {
+	tps.remote.StoreVersion(provider, service, region, val)
+	tps.local.Remove(tps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) GetVersion(provider, service, region string) ([]types.LocationVersion, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetVersion(provider, service, region)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]types.LocationVersion), true
+}
+
+func (tps *tieredProductStore) DeleteVersion(provider, service, region string) {
+	tps.remote.DeleteVersion(provider, service, region)
+	tps.local.Remove(tps.getKey(cloudinfo.VersionKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) StoreRegionData(provider, service, region string, data cloudinfo.RegionData) error {
+	err := tps.remote.StoreRegionData(provider, service, region, data)
+
+	// the local entries are dropped even if the write failed, as it may have been applied anyway
+	for _, entry := range regionEntries(provider, service, region, data) {
+		tps.local.Remove(entry.key)
+	}
+
+	return err
+}
+
+func (tps *tieredProductStore) GetGeneration(provider, service, region string) (int64, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.GenerationKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetGeneration(provider, service, region)
+	})
+	if !ok {
+		return 0, false
+	}
+
+	return res.(int64), true
+}
+
+func (tps *tieredProductStore) StoreRegionStatus(provider, service, region string, val types.RegionStatus) {
+	tps.remote.StoreRegionStatus(provider, service, region, val)
+	tps.local.Remove(tps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetRegionStatus(provider, service, region)
+	})
+	if !ok {
+		return types.RegionStatus{}, false
+	}
+
+	return res.(types.RegionStatus), true
+}
+
+func (tps *tieredProductStore) StoreQuarantine(provider, service, region string, val types.QuarantinedScrape) {
+	tps.remote.StoreQuarantine(provider, service, region, val)
+	tps.local.Remove(tps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region))
+}
+
+func (tps *tieredProductStore) GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool) {
+	res, ok := tps.getRegional(provider, service, region, tps.getKey(cloudinfo.QuarantineKeyTemplate, provider, service, region), func() (interface{}, bool) {
+		return tps.remote.GetQuarantine(provider, service, region)
+	})
+	if !ok {
+		return types.QuarantinedScrape{}, false
+	}
+
+	return res.(types.QuarantinedScrape), true
+}
+
+func (tps *tieredProductStore) StoreScrapeRuns(provider string, val []types.ScrapeRun) {
+	tps.remote.StoreScrapeRuns(provider, val)
+	tps.local.Remove(tps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider))
+}
+
+func (tps *tieredProductStore) GetScrapeRuns(provider string) ([]types.ScrapeRun, bool) {
+	res, ok := tps.get(provider, tps.getKey(cloudinfo.ScrapeRunsKeyTemplate, provider), func() (interface{}, bool) {
+		return tps.remote.GetScrapeRuns(provider)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]types.ScrapeRun), true
+}
+
+func (tps *tieredProductStore) StoreStatus(provider string, val string) {
+	tps.remote.StoreStatus(provider, val)
+	tps.observeStatus(provider, val)
+}
+
+// GetStatus always reads the status from the remote store, the cache of the provider is dropped if it changed
+func (tps *tieredProductStore) GetStatus(provider string) (string, bool) {
+	status, ok := tps.remote.GetStatus(provider)
+	tps.observeStatus(provider, status)
+
+	return status, ok
+}
+
+func (tps *tieredProductStore) StoreServices(provider string, services []types.Service) {
+	tps.remote.StoreServices(provider, services)
+	tps.local.Remove(tps.getKey(cloudinfo.ServicesKeyTemplate, provider))
+}
+
+func (tps *tieredProductStore) GetServices(provider string) ([]types.Service, bool) {
+	res, ok := tps.get(provider, tps.getKey(cloudinfo.ServicesKeyTemplate, provider), func() (interface{}, bool) {
+		return tps.remote.GetServices(provider)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return res.([]types.Service), true
+}
+
+func (tps *tieredProductStore) Export(w io.Writer) error {
+	return tps.remote.Export(w)
+}
+
+// Import loads the data into the remote store and drops the whole cache
+func (tps *tieredProductStore) Import(r io.Reader) error {
+	defer tps.purge()
+
+	return tps.remote.Import(r)
+}
+
+func (tps *tieredProductStore) Close() {
+	tps.purge()
+	tps.remote.Close()
+}
+
+// get returns the cached value of the key, on miss the value is loaded (and cached if found)
+func (tps *tieredProductStore) get(provider, key string, load func() (interface{}, bool)) (interface{}, bool) {
+	tps.checkStatus(provider)
+
+	if val, ok := tps.cached(key); ok {
+		return val, true
+	}
+
+	val, ok := load()
+	if !ok {
+		return nil, false
+	}
+
+	tps.cache(key, val)
+
+	return val, true
+}
+
+// getRegional returns the cached value of a key of the service region like get, the status of the region is checked first
+func (tps *tieredProductStore) getRegional(provider, service, region, key string, load func() (interface{}, bool)) (interface{}, bool) {
+	tps.checkRegionStatus(provider, service, region)
+
+	return tps.get(provider, key, load)
+}
+
+// cached returns the cached value of the key if it's not expired
+func (tps *tieredProductStore) cached(key string) (interface{}, bool) {
+	val, ok := tps.local.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	entry := val.(cachedEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		tps.local.Remove(key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// cache adds the value to the local cache, it expires after the configured TTL
+func (tps *tieredProductStore) cache(key string, val interface{}) {
+	entry := cachedEntry{value: val}
+	if tps.ttl > 0 {
+		entry.expiresAt = time.Now().Add(tps.ttl)
+	}
+
+	tps.local.Add(key, entry)
+}
+
+// checkStatus reads the status of the provider from the remote store if it wasn't checked in the configured interval
+func (tps *tieredProductStore) checkStatus(provider string) {
+	tps.mu.Lock()
+	last, ok := tps.statuses[provider]
+	tps.mu.Unlock()
+
+	if ok && time.Since(last.checkedAt) < tps.statusCheckInterval {
+		return
+	}
+
+	status, _ := tps.remote.GetStatus(provider)
+	tps.observeStatus(provider, status)
+}
+
+// checkRegionStatus reads the status of the service region from the remote store if it wasn't checked in the configured interval,
+// the cached entries of the region are dropped if the status changed
+func (tps *tieredProductStore) checkRegionStatus(provider, service, region string) {
+	key := tps.getKey(cloudinfo.RegionStatusKeyTemplate, provider, service, region)
+
+	tps.mu.Lock()
+	last, ok := tps.regionStatuses[key]
+	tps.mu.Unlock()
+
+	if ok && time.Since(last.checkedAt) < tps.statusCheckInterval {
+		return
+	}
+
+	status, _ := tps.remote.GetRegionStatus(provider, service, region)
+
+	tps.mu.Lock()
+	defer tps.mu.Unlock()
+
+	tps.regionStatuses[key] = regionStatus{status: status, checkedAt: time.Now()}
+
+	if ok && !reflect.DeepEqual(last.status, status) {
+		tps.log.Debug("region status changed, dropping cached entries",
+			map[string]interface{}{"provider": provider, "service": service, "region": region})
+		tps.removePrefix(fmt.Sprintf("%sproviders/%s/services/%s/regions/%s/", cloudinfo.KeyPrefix, provider, service, region))
+	}
+}
+
+// observeStatus records the status of the provider and drops its cached entries if the status changed
+func (tps *tieredProductStore) observeStatus(provider, status string) {
+	tps.mu.Lock()
+	defer tps.mu.Unlock()
+
+	last, ok := tps.statuses[provider]
+	tps.statuses[provider] = providerStatus{status: status, checkedAt: time.Now()}
+
+	if ok && last.status != status {
+		tps.log.Debug("provider status changed, dropping cached entries", map[string]interface{}{"provider": provider})
+		tps.purgeProvider(provider)
+	}
+}
+
+// purgeProvider removes the cached entries of the provider
+func (tps *tieredProductStore) purgeProvider(provider string) {
+	tps.removePrefix(fmt.Sprintf("%sproviders/%s/", cloudinfo.KeyPrefix, provider))
+}
+
+// removePrefix removes the cached entries having keys with the prefix
+func (tps *tieredProductStore) removePrefix(prefix string) {
+	for _, key := range tps.local.Keys() {
+		if strings.HasPrefix(key.(string), prefix) {
+			tps.local.Remove(key)
+		}
+	}
+}
+
+// purge removes all the cached entries
+func (tps *tieredProductStore) purge() {
+	tps.mu.Lock()
+	defer tps.mu.Unlock()
+
+	tps.local.Purge()
+	tps.statuses = make(map[string]providerStatus)
+	tps.regionStatuses = make(map[string]regionStatus)
+}
+
+func (tps *tieredProductStore) getKey(keyTemplate string, args ...interface{}) string {
+	return fmt.Sprintf(keyTemplate, args...)
+}
@@ -0,0 +1,147 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cistore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// countingStore counts the vm reads hitting the wrapped store
+type countingStore struct {
+	cloudinfo.CloudInfoStore
+	vmReads int
+}
+
+func (cs *countingStore) GetVm(provider, service, region string) ([]types.VMInfo, bool) {
+	cs.vmReads++
+	return cs.CloudInfoStore.GetVm(provider, service, region)
+}
+
+func newTestTieredStore(t *testing.T, statusCheckInterval, ttl time.Duration) (*countingStore, cloudinfo.CloudInfoStore) {
+	remote := &countingStore{CloudInfoStore: newTestCacheStore()}
+
+	store, err := NewTieredProductStore(remote, LocalCacheConfig{Size: 10, StatusCheckInterval: statusCheckInterval, TTL: ttl},
+		cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	assert.NoError(t, err)
+
+	return remote, store
+}
+
+func TestTieredProductStore(t *testing.T) {
+	tests := map[string]struct {
+		statusCheckInterval time.Duration
+		ttl                 time.Duration
+		change              func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore)
+		vmReads             int
+		vmType              string
+	}{
+		"cached read": {
+			statusCheckInterval: time.Hour,
+			change:              func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore) {},
+			vmReads:             1,
+			vmType:              "m5.large",
+		},
+		"write invalidates the entry": {
+			statusCheckInterval: time.Hour,
+			change: func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore) {
+				store.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.xlarge"}})
+			},
+			vmReads: 2,
+			vmType:  "m5.xlarge",
+		},
+		"remote write is not seen before the status check": {
+			statusCheckInterval: time.Hour,
+			change: func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore) {
+				remote.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.xlarge"}})
+				remote.StoreStatus("amazon", "2")
+			},
+			vmReads: 1,
+			vmType:  "m5.large",
+		},
+		"status change drops the provider entries": {
+			statusCheckInterval: 0,
+			change: func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore) {
+				remote.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.xlarge"}})
+				remote.StoreStatus("amazon", "2")
+			},
+			vmReads: 2,
+			vmType:  "m5.xlarge",
+		},
+		"region status change drops the region entries": {
+			statusCheckInterval: 0,
+			change: func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore) {
+				remote.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.xlarge"}})
+				remote.StoreRegionStatus("amazon", "compute", "eu-west-1", types.RegionStatus{LastSuccess: time.Now()})
+			},
+			vmReads: 2,
+			vmType:  "m5.xlarge",
+		},
+		"expired entry is reloaded": {
+			statusCheckInterval: time.Hour,
+			ttl:                 time.Millisecond,
+			change: func(remote cloudinfo.CloudInfoStore, store cloudinfo.CloudInfoStore) {
+				remote.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.xlarge"}})
+				time.Sleep(5 * time.Millisecond)
+			},
+			vmReads: 2,
+			vmType:  "m5.xlarge",
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			remote, store := newTestTieredStore(t, test.statusCheckInterval, test.ttl)
+			remote.StoreStatus("amazon", "1")
+			remote.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.large"}})
+
+			_, ok := store.GetVm("amazon", "compute", "eu-west-1")
+			assert.True(t, ok)
+
+			test.change(remote, store)
+
+			vms, ok := store.GetVm("amazon", "compute", "eu-west-1")
+			assert.True(t, ok)
+			assert.Equal(t, test.vmType, vms[0].Type)
+			assert.Equal(t, test.vmReads, remote.vmReads)
+		})
+	}
+}
+
+func TestTieredProductStore_GetPrices(t *testing.T) {
+	remote, store := newTestTieredStore(t, time.Hour, time.Hour)
+	remote.StorePrice("amazon", "eu-west-1", "m5.large", types.Price{OnDemandPrice: 0.1})
+
+	prices, ok := store.GetPrices("amazon", "eu-west-1", []string{"m5.large", "m5.xlarge"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]types.Price{"m5.large": {OnDemandPrice: 0.1}}, prices)
+
+	// the cached price is served even though the remote one changed, the missing one is loaded
+	remote.StorePrice("amazon", "eu-west-1", "m5.large", types.Price{OnDemandPrice: 0.2})
+	remote.StorePrice("amazon", "eu-west-1", "m5.xlarge", types.Price{OnDemandPrice: 0.3})
+
+	prices, ok = store.GetPrices("amazon", "eu-west-1", []string{"m5.large", "m5.xlarge"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]types.Price{"m5.large": {OnDemandPrice: 0.1}, "m5.xlarge": {OnDemandPrice: 0.3}}, prices)
+}
@@ -15,6 +15,9 @@
 package loader
 
 import (
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 )
 
@@ -24,6 +27,19 @@ const (
 	include = "include"
 )
 
+// data sources of the data kinds of a static service
+const (
+	// the data is loaded from the service data file
+	staticData = "static"
+	// the data is derived from the source service using the strategy set in the service data file
+	derivedData = "derived"
+	// the data is scraped from the provider, it's not loaded
+	scrapedData = "scraped"
+)
+
+// dataKinds lists the data kinds of a service
+var dataKinds = []string{types.ZonesData, types.VmsData, types.ImagesData, types.VersionsData}
+
 // ServiceData service data representation corresponding to the data to parsed from the external yaml / json
 type ServiceData struct {
 	// embedded service
@@ -82,4 +98,55 @@ type Service struct {
 	DataLocation string
 	DataFile     string
 	DataType     string
+	// the service is only enabled if the distribution is enabled for the provider
+	Distribution string
+	// the data source (static, derived or scraped) per data kind (zones, vms, images, versions)
+	Data map[string]string
+}
+
+// dataSource returns the source of the given data kind
+// undeclared data kinds are derived if the service has a source service, they are static otherwise
+func (s Service) dataSource(kind string) string {
+	if source, ok := s.Data[kind]; ok {
+		return source
+	}
+
+	if s.Source != "" {
+		return derivedData
+	}
+
+	return staticData
+}
+
+// scrapedData returns the data kinds declared to be scraped
+func (s Service) scrapedData() []string {
+	var kinds []string
+	for _, kind := range dataKinds {
+		if s.Data[kind] == scrapedData {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	return kinds
+}
+
+// validate checks the declared data sources of the service
+func (s Service) validate() error {
+	for kind, source := range s.Data {
+		if !cloudinfo.Contains(dataKinds, kind) {
+			return errors.NewWithDetails("unsupported service data kind", "service", s.Name, "kind", kind)
+		}
+
+		switch source {
+		case staticData, scrapedData:
+		case derivedData:
+			if s.Source == "" {
+				return errors.NewWithDetails("derived service data requires a source service", "service", s.Name, "kind", kind)
+			}
+		default:
+			return errors.NewWithDetails("unsupported service data source", "service", s.Name, "kind", kind, "source", source)
+		}
+	}
+
+	return nil
 }
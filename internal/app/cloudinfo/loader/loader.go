@@ -82,6 +82,11 @@ func (dl *defaultCloudInfoLoader) LoadRegions() {
 // loadZones loads zones for a given region in the store
 func (dl *defaultCloudInfoLoader) LoadZones(provider, service string, region Region) {
 	log := dl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
+	if dl.serviceData.dataSource(types.ZonesData) == scrapedData {
+		log.Debug("skip loading zones, scraped data")
+		return
+	}
+
 	log.Debug("loading zones...")
 	defer log.Debug("loading zones... DONE.")
 
@@ -91,6 +96,11 @@ func (dl *defaultCloudInfoLoader) LoadZones(provider, service string, region Reg
 // loadVersions loads versions for a given region into the store
 func (dl *defaultCloudInfoLoader) LoadVersions(provider string, service string, region Region) {
 	log := dl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
+	if dl.serviceData.dataSource(types.VersionsData) == scrapedData {
+		log.Debug("skip loading versions, scraped data")
+		return
+	}
+
 	log.Debug("loading versions...")
 	defer log.Debug("loading versions... DONE.")
 
@@ -100,17 +110,25 @@ func (dl *defaultCloudInfoLoader) LoadVersions(provider string, service string,
 // loadImages loads images for a given region into the store
 func (dl *defaultCloudInfoLoader) LoadImages(provider string, service string, region Region) {
 	log := dl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
-	log.Debug("loading images...")
-	defer log.Debug("loading images... DONE.")
-	if service == "pke" {
+	if dl.serviceData.dataSource(types.ImagesData) == scrapedData {
+		log.Debug("skip loading images, scraped data")
 		return
 	}
+
+	log.Debug("loading images...")
+	defer log.Debug("loading images... DONE.")
+
 	dl.store.StoreImage(provider, service, region.Id, region.Data.Images.Data)
 }
 
 // loadVms loads vms for a given region into the store
 func (dl *defaultCloudInfoLoader) LoadVms(provider string, service string, region Region) {
 	log := dl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
+	if dl.serviceData.dataSource(types.VmsData) == scrapedData {
+		log.Debug("skip loading VMs, scraped data")
+		return
+	}
+
 	log.Debug("loading VMs...")
 	defer log.Debug("loading VMs... DONE.")
 
@@ -126,7 +144,9 @@ type storeCloudInfoLoader struct {
 }
 
 func (sl *storeCloudInfoLoader) Load() {
-	sl.eventBus.SubscribeScrapingComplete(sl.serviceData.Provider, sl.LoadRegions)
+	sl.eventBus.Subscribe(sl.serviceData.Provider, messaging.ScrapeCompleted, func(messaging.Event) {
+		sl.LoadRegions()
+	})
 }
 
 // loadRegions loads regions in the cloud info store
@@ -172,6 +192,17 @@ func (sl *storeCloudInfoLoader) LoadRegions() {
 func (sl *storeCloudInfoLoader) LoadZones(provider string, service string, region Region) {
 	// add method context to the logger
 	log := sl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
+	switch sl.serviceData.dataSource(types.ZonesData) {
+	case scrapedData:
+		log.Debug("skip loading zones, scraped data")
+		return
+
+	case staticData:
+		log.Debug("loading static zones")
+		sl.store.StoreZones(provider, service, region.Id, region.Data.Zones.Data)
+		return
+	}
+
 	log.Debug("loading zones...")
 	defer log.Debug("loading zones... DONE.")
 
@@ -220,6 +251,17 @@ func (sl *storeCloudInfoLoader) LoadZones(provider string, service string, regio
 func (sl *storeCloudInfoLoader) LoadVersions(provider string, service string, region Region) {
 	// add method context to the logger
 	log := sl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
+	switch sl.serviceData.dataSource(types.VersionsData) {
+	case scrapedData:
+		log.Debug("skip loading versions, scraped data")
+		return
+
+	case staticData:
+		log.Debug("loading static versions")
+		sl.store.StoreVersion(provider, service, region.Id, region.Data.Versions.Data)
+		return
+	}
+
 	log.Debug("loading versions...")
 	defer log.Debug("loading versions... DONE.")
 
@@ -285,8 +327,14 @@ func (sl *storeCloudInfoLoader) LoadImages(provider string, service string, regi
 	// add method context to the logger
 	log := sl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
 
-	if service == "pke" {
-		log.Debug("skip loading images - special case pke")
+	switch sl.serviceData.dataSource(types.ImagesData) {
+	case scrapedData:
+		log.Debug("skip loading images, scraped data")
+		return
+
+	case staticData:
+		log.Debug("loading static images")
+		sl.store.StoreImage(provider, service, region.Id, region.Data.Images.Data)
 		return
 	}
 
@@ -343,6 +391,17 @@ func (sl *storeCloudInfoLoader) LoadImages(provider string, service string, regi
 func (sl *storeCloudInfoLoader) LoadVms(provider string, service string, region Region) {
 	// add method context to the logger
 	log := sl.log.WithFields(map[string]interface{}{"provider": provider, "service": service, "region": region.Id})
+	switch sl.serviceData.dataSource(types.VmsData) {
+	case scrapedData:
+		log.Debug("skip loading VMs, scraped data")
+		return
+
+	case staticData:
+		log.Debug("loading static VMs")
+		sl.store.StoreVm(provider, service, region.Id, region.Data.Vms.Data)
+		return
+	}
+
 	log.Debug("loading VMs...")
 	defer log.Debug("loading VMs... DONE.")
 
@@ -389,12 +448,13 @@ func (sl *storeCloudInfoLoader) LoadVms(provider string, service string, region
 	}
 }
 
-func NewCloudInfoLoader(datapath, datafile, datatype string, store cloudinfo.CloudInfoStore, log cloudinfo.Logger,
+// NewCloudInfoLoader creates a loader for the data file of the static service
+func NewCloudInfoLoader(service Service, store cloudinfo.CloudInfoStore, log cloudinfo.Logger,
 	eventBus messaging.EventBus) CloudInfoLoader {
 	dataViper := viper.New()
-	dataViper.SetConfigName(datafile)
-	dataViper.SetConfigType(datatype)
-	dataViper.AddConfigPath(datapath)
+	dataViper.SetConfigName(service.DataFile)
+	dataViper.SetConfigType(service.DataType)
+	dataViper.AddConfigPath(service.DataLocation)
 
 	if err := dataViper.ReadInConfig(); err != nil { // Find and read the config file
 		// Handle errors
@@ -406,6 +466,18 @@ func NewCloudInfoLoader(datapath, datafile, datatype string, store cloudinfo.Clo
 		emperror.Panic(err)
 	}
 
+	// the data sources declared in the service definition take precedence over the data file
+	for kind, source := range service.Data {
+		if serviceData.Data == nil {
+			serviceData.Data = make(map[string]string, len(service.Data))
+		}
+		serviceData.Data[kind] = source
+	}
+
+	if err := serviceData.validate(); err != nil {
+		emperror.Panic(err)
+	}
+
 	if serviceData.Source != "" {
 		// serviceloader implementation that uses another service as source
 		return &storeCloudInfoLoader{
@@ -15,7 +15,26 @@
 package loader
 
 import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"emperror.dev/emperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/cistore"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/distribution"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+	"github.com/banzaicloud/cloudinfo/internal/platform/buildinfo"
 )
 
 func TestDefaultServiceLoader_Load(t *testing.T) {
@@ -42,3 +61,253 @@ func TestDefaultServiceLoader_Load(t *testing.T) {
 	// log.Info("stored", map[string]interface{}{"cnt": reg})
 
 }
+
+const testServiceData = `
+name: pke
+provider: amazon
+source: compute
+regions:
+    -   name: EU (Ireland)
+        id: eu-west-1
+        data:
+            zones:
+                strategy: exclude
+                data:
+                    - eu-west-1c
+            images:
+                strategy: exact
+                data:
+                    -   name: ami-static
+            versions:
+                data:
+                    -   location: eu-west-1
+                        versions:
+                            - 1.21.14
+            vms:
+                strategy: include
+                data:
+                    -   type: m5.large
+`
+
+func newTestLoaderStore() cloudinfo.CloudInfoStore {
+	return cistore.NewCacheProductStore(time.Hour, time.Hour, buildinfo.New("test", "abc", "today"),
+		cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+}
+
+func TestNewCloudInfoLoader_DataSources(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pke-data.yaml"), []byte(testServiceData), 0644))
+
+	store := newTestLoaderStore()
+	store.StoreRegions("amazon", "compute", map[string]string{"eu-west-1": "EU (Ireland)"})
+	store.StoreZones("amazon", "compute", "eu-west-1", []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"})
+	store.StoreVm("amazon", "compute", "eu-west-1", []types.VMInfo{{Type: "m5.large"}, {Type: "m5.xlarge"}})
+	store.StoreImage("amazon", "pke", "eu-west-1", []types.Image{{Name: "ami-scraped"}})
+
+	service := Service{
+		Name:         "pke",
+		IsStatic:     true,
+		DataLocation: dir,
+		DataFile:     "pke-data",
+		DataType:     "yaml",
+		Data:         map[string]string{types.ImagesData: scrapedData, types.VersionsData: staticData},
+	}
+
+	loader := NewCloudInfoLoader(service, store, cloudinfoadapter.NewLogger(&logur.TestLogger{}), messaging.NewDefaultEventBus(nil))
+	loader.LoadRegions()
+
+	zones, ok := store.GetZones("amazon", "pke", "eu-west-1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"eu-west-1a", "eu-west-1b"}, zones, "derived data is filtered from the source service")
+
+	vms, ok := store.GetVm("amazon", "pke", "eu-west-1")
+	require.True(t, ok)
+	require.Len(t, vms, 1)
+	assert.Equal(t, "m5.large", vms[0].Type)
+
+	versions, ok := store.GetVersion("amazon", "pke", "eu-west-1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"1.21.14"}, versions[0].Versions, "static data is loaded as is")
+
+	images, ok := store.GetImage("amazon", "pke", "eu-west-1")
+	require.True(t, ok)
+	assert.Equal(t, []types.Image{{Name: "ami-scraped"}}, images, "scraped data is not loaded")
+}
+
+func TestService_validate(t *testing.T) {
+	tests := map[string]struct {
+		service Service
+		err     string
+	}{
+		"undeclared data": {
+			service: Service{Name: "pke"},
+		},
+		"declared data": {
+			service: Service{Name: "pke", Source: "compute", Data: map[string]string{"zones": "derived", "images": "scraped", "vms": "static"}},
+		},
+		"unsupported kind": {
+			service: Service{Name: "pke", Data: map[string]string{"prices": "scraped"}},
+			err:     "unsupported service data kind",
+		},
+		"unsupported source": {
+			service: Service{Name: "pke", Data: map[string]string{"images": "remote"}},
+			err:     "unsupported service data source",
+		},
+		"derived without source": {
+			service: Service{Name: "pke", Data: map[string]string{"images": "derived"}},
+			err:     "derived service data requires a source service",
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			err := test.service.validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestDefaultServiceManager_ConfigureServices(t *testing.T) {
+	store := newTestLoaderStore()
+	sm := &defaultServiceManager{
+		services: map[string][]ServiceData{
+			"amazon": {
+				{Service: Service{Name: "compute"}},
+				{Service: Service{Name: "pke", IsStatic: true, Distribution: "pke", Data: map[string]string{"images": "scraped", "zones": "derived"}}},
+			},
+			"azure": {
+				{Service: Service{Name: "pke", IsStatic: true, Distribution: "pke"}},
+			},
+		},
+		store: store,
+		log:   cloudinfoadapter.NewLogger(&logur.TestLogger{}),
+	}
+
+	sm.ConfigureServices([]string{"amazon", "azure"}, map[string]map[string]distribution.ProviderConfig{"pke": {"amazon": {Enabled: true}}})
+
+	services, ok := store.GetServices("amazon")
+	require.True(t, ok)
+	assert.Equal(t, []types.Service{{Service: "compute"}, {Service: "pke", IsStatic: true, ScrapedData: []string{"images"}}}, services)
+
+	services, ok = store.GetServices("azure")
+	require.True(t, ok)
+	assert.Empty(t, services, "the distribution is not enabled on the provider")
+}
+
+// scrapedCloudInfoer returns fixed compute information of a single region
+type scrapedCloudInfoer struct {
+	// implement the interface
+	cloudinfo.CloudInfoer
+}
+
+func (sci *scrapedCloudInfoer) Initialize() (map[string]map[string]types.Price, error) {
+	return nil, nil
+}
+
+func (sci *scrapedCloudInfoer) HasShortLivedPriceInfo() bool {
+	return false
+}
+
+func (sci *scrapedCloudInfoer) HasImages() bool {
+	return false
+}
+
+func (sci *scrapedCloudInfoer) GetRegions(service string) (map[string]string, error) {
+	return map[string]string{"eu-west-1": "EU (Ireland)"}, nil
+}
+
+func (sci *scrapedCloudInfoer) GetZones(region string) ([]string, error) {
+	return []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"}, nil
+}
+
+func (sci *scrapedCloudInfoer) GetProducts(vms []types.VMInfo, service, regionId string) ([]types.VMInfo, error) {
+	return []types.VMInfo{{Type: "m5.large", OnDemandPrice: 0.1}, {Type: "m5.xlarge", OnDemandPrice: 0.2}}, nil
+}
+
+func (sci *scrapedCloudInfoer) GetVersions(service, region string) ([]types.LocationVersion, error) {
+	return nil, nil
+}
+
+func TestScrapeOnce_DerivedData(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pke-data.yaml"), []byte(testServiceData), 0644))
+
+	logger := cloudinfoadapter.NewLogger(&logur.TestLogger{})
+	store := newTestLoaderStore()
+	store.StoreServices("amazon", []types.Service{{Service: "compute"}, {Service: "pke", IsStatic: true}})
+
+	eventBus := messaging.NewDefaultEventBus(nil)
+	NewCloudInfoLoader(Service{Name: "pke", IsStatic: true, DataLocation: dir, DataFile: "pke-data", DataType: "yaml"}, store, logger, eventBus).Load()
+
+	config := cloudinfo.ScrapeConfig{
+		Concurrency:    1,
+		Retry:          cloudinfo.RetryConfig{MaxRetries: -1},
+		CircuitBreaker: cloudinfo.CircuitBreakerConfig{Threshold: -1},
+		Sanity:         cloudinfo.SanityConfig{},
+	}
+	driver := cloudinfo.NewScrapingDriver(map[string]cloudinfo.CloudInfoer{"amazon": &scrapedCloudInfoer{}}, store, nil,
+		map[string]cloudinfo.ScrapeConfig{"amazon": config}, cloudinfo.NewLeaderElector(cloudinfo.NewLocalLock(), cloudinfo.LeaderElectionConfig{}, logger),
+		eventBus, metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), emperror.NoopHandler{}, logger)
+
+	require.NoError(t, driver.ScrapeOnce(context.Background(), cloudinfo.ScrapeFilter{}))
+	require.NoError(t, eventBus.Close())
+
+	path := filepath.Join(dir, "snapshot.json.gz")
+	require.NoError(t, cistore.ExportSnapshotFile(store, path))
+
+	snapshot := newTestLoaderStore()
+	_, err := cistore.LoadSnapshotFile(snapshot, path)
+	require.NoError(t, err)
+
+	vms, ok := snapshot.GetVm("amazon", "pke", "eu-west-1")
+	require.True(t, ok, "the derived data is in the snapshot")
+	require.Len(t, vms, 1)
+	assert.Equal(t, "m5.large", vms[0].Type)
+
+	zones, ok := snapshot.GetZones("amazon", "pke", "eu-west-1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"eu-west-1a", "eu-west-1b"}, zones)
+}
+
+func TestScrapeOnce_StaticServiceProductDetails(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pke-data.yaml"), []byte(testServiceData), 0644))
+
+	logger := cloudinfoadapter.NewLogger(&logur.TestLogger{})
+	store := newTestLoaderStore()
+	store.StoreServices("amazon", []types.Service{{Service: "compute"}, {Service: "pke", IsStatic: true, ScrapedData: []string{types.VersionsData}}})
+
+	eventBus := messaging.NewDefaultEventBus(nil)
+	NewCloudInfoLoader(Service{Name: "pke", IsStatic: true, DataLocation: dir, DataFile: "pke-data", DataType: "yaml"}, store, logger, eventBus).Load()
+
+	config := cloudinfo.ScrapeConfig{
+		Concurrency:    1,
+		Retry:          cloudinfo.RetryConfig{MaxRetries: -1},
+		CircuitBreaker: cloudinfo.CircuitBreakerConfig{Threshold: -1},
+	}
+	driver := cloudinfo.NewScrapingDriver(map[string]cloudinfo.CloudInfoer{"amazon": &scrapedCloudInfoer{}}, store, nil,
+		map[string]cloudinfo.ScrapeConfig{"amazon": config}, cloudinfo.NewLeaderElector(cloudinfo.NewLocalLock(), cloudinfo.LeaderElectionConfig{}, logger),
+		eventBus, metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), emperror.NoopHandler{}, logger)
+
+	require.NoError(t, driver.ScrapeOnce(context.Background(), cloudinfo.ScrapeFilter{}))
+	require.NoError(t, eventBus.Close())
+
+	// the scraped versions don't replace the products of the static service with an empty view
+	_, ok := store.GetProductDetails("amazon", "pke", "eu-west-1")
+	assert.False(t, ok, "no product view is stored for the static service")
+
+	ci, err := cloudinfo.NewCloudInfo([]string{"amazon"}, store, nil, logger)
+	require.NoError(t, err)
+
+	details, err := ci.GetProductDetails("amazon", "pke", "eu-west-1")
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+	assert.Equal(t, "m5.large", details[0].Type)
+}
@@ -57,7 +57,7 @@ func (sm *defaultServiceManager) LoadServiceInformation(providers []string) {
 				continue
 			}
 
-			cloudInfoLoader := NewCloudInfoLoader(service.DataLocation, service.DataFile, service.DataType, sm.store, sm.log, sm.eventBus)
+			cloudInfoLoader := NewCloudInfoLoader(service.Service, sm.store, sm.log, sm.eventBus)
 
 			cloudInfoLoader.Load()
 		}
@@ -74,15 +74,11 @@ func (sm *defaultServiceManager) ConfigureServices(providers []string, distribut
 
 		services := make([]types.Service, 0, len(providerServices))
 		for _, psvc := range providerServices {
-			if provider == "amazon" && !distributionConfig.Pke.Amazon.Enabled && psvc.Name == "pke" {
-				sm.log.Debug("service not enabled", map[string]interface{}{"provider": provider, "service": psvc.Name})
+			if psvc.Distribution != "" && !distributionConfig.Enabled(psvc.Distribution, provider) {
+				sm.log.Debug("service not enabled", map[string]interface{}{"provider": provider, "service": psvc.Name, "distribution": psvc.Distribution})
 				continue
 			}
-			if provider == "azure" && !distributionConfig.Pke.Azure.Enabled && psvc.Name == "pke" {
-				sm.log.Debug("service not enabled", map[string]interface{}{"provider": provider, "service": psvc.Name})
-				continue
-			}
-			services = append(services, types.Service{Service: psvc.Name, IsStatic: psvc.IsStatic})
+			services = append(services, types.Service{Service: psvc.Name, IsStatic: psvc.IsStatic, ScrapedData: psvc.scrapedData()})
 		}
 		sm.log.Debug("initialized provider services", map[string]interface{}{"provider": provider, "services #": len(services)})
 		sm.store.StoreServices(provider, services)
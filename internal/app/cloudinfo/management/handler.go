@@ -37,6 +37,7 @@ type mngmntRouteHandler struct {
 func (mrh *mngmntRouteHandler) Export() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		mrh.log.Info("exporting cloud information")
+		c.Header("Content-Type", "application/gzip")
 		if err := mrh.cis.Export(c.Writer); err != nil {
 			c.JSON(http.StatusInternalServerError, err.Error())
 			return
@@ -90,6 +91,48 @@ func (mrh *mngmntRouteHandler) Refresh() gin.HandlerFunc {
 	}
 }
 
+// Accept handler that makes the next scrape result of a region stored even if it fails the sanity checks
+func (mrh *mngmntRouteHandler) Accept() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := api.GetRegionPathParams{}
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			mrh.log.Error("failed to get region")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get the region from path"})
+			return
+		}
+
+		mrh.log.Info("accepting the next scrape result", map[string]interface{}{
+			"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+		if err := mrh.sd.AcceptRegion(pathParams.Provider, pathParams.Service, pathParams.Region); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"operation": "accept", "provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+	}
+}
+
+// Promote handler that stores the quarantined scrape result of a region in place of the stored data
+func (mrh *mngmntRouteHandler) Promote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := api.GetRegionPathParams{}
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			mrh.log.Error("failed to get region")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get the region from path"})
+			return
+		}
+
+		mrh.log.Info("promoting the quarantined scrape result", map[string]interface{}{
+			"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+		if err := mrh.sd.PromoteQuarantine(pathParams.Provider, pathParams.Service, pathParams.Region); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"operation": "promote", "provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+	}
+}
+
 func StartManagementEngine(cfg Config, cis cloudinfo.CloudInfoStore, sd cloudinfo.ScrapingDriver, log cloudinfo.Logger) *gin.Engine {
 	if err := cfg.Validate(); err != nil {
 		emperror.Panic(err)
@@ -102,6 +145,8 @@ func StartManagementEngine(cfg Config, cis cloudinfo.CloudInfoStore, sd cloudinf
 	base.GET("export", rh.Export())
 	base.PUT("import", rh.Import())
 	base.PUT("refresh/:provider", rh.Refresh())
+	base.PUT("accept/:provider/:service/:region", rh.Accept())
+	base.PUT("promote/:provider/:service/:region", rh.Promote())
 	if err := router.Run(cfg.Address); err != nil {
 		emperror.Panic(err)
 	}
@@ -0,0 +1,100 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
+)
+
+const (
+	// BackendInMemory keeps the events in the process
+	BackendInMemory = "inmemory"
+	// BackendRedis writes the events into Redis streams
+	BackendRedis = "redis"
+	// BackendNATS publishes the events on NATS subjects
+	BackendNATS = "nats"
+)
+
+// Config holds the event bus settings
+type Config struct {
+	// Backend the event bus is backed by: inmemory, redis or nats
+	Backend string
+
+	// Redis the Redis server of the redis backend
+	Redis RedisConfig
+
+	// NATS the NATS server of the nats backend
+	NATS NATSConfig
+}
+
+// RedisConfig holds the settings of the Redis backend
+type RedisConfig struct {
+	redis.Config `mapstructure:",squash"`
+
+	// MaxLen the approximate number of events kept per stream, the streams are not trimmed if it's not positive
+	MaxLen int
+}
+
+// NATSConfig holds the settings of the NATS backend
+type NATSConfig struct {
+	// URL the NATS server URL, more servers are separated by commas
+	URL string
+}
+
+// Validate validates the event bus configuration
+func (c Config) Validate() error {
+	switch c.Backend {
+	case "", BackendInMemory:
+		return nil
+
+	case BackendRedis:
+		if c.Redis.Host == "" {
+			return errors.New("event bus redis host is required")
+		}
+
+		if c.Redis.Port == 0 {
+			return errors.New("event bus redis port is required")
+		}
+
+		return nil
+
+	case BackendNATS:
+		if c.NATS.URL == "" {
+			return errors.New("event bus nats url is required")
+		}
+
+		return nil
+
+	default:
+		return errors.NewWithDetails("invalid event bus backend", "backend", c.Backend)
+	}
+}
+
+// NewEventBus creates the event bus of the configured backend
+func NewEventBus(config Config, errorHandler emperror.ErrorHandler) (EventBus, error) {
+	switch config.Backend {
+	case BackendRedis:
+		return NewRedisEventBus(config.Redis, errorHandler), nil
+
+	case BackendNATS:
+		return NewNATSEventBus(config.NATS, errorHandler)
+
+	default:
+		return NewDefaultEventBus(errorHandler), nil
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+	assert.NoError(t, Config{Backend: BackendRedis, Redis: RedisConfig{Config: redis.Config{Host: "localhost", Port: 6379}}}.Validate())
+	assert.NoError(t, Config{Backend: BackendNATS, NATS: NATSConfig{URL: "nats://localhost:4222"}}.Validate())
+	assert.Error(t, Config{Backend: BackendRedis, Redis: RedisConfig{Config: redis.Config{Host: "localhost"}}}.Validate())
+	assert.Error(t, Config{Backend: BackendNATS}.Validate())
+	assert.Error(t, Config{Backend: "kafka"}.Validate())
+}
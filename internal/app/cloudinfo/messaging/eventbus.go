@@ -24,16 +24,18 @@ import (
 // EventBus event bus abstraction for the application to decouple vendor or lib specifics
 
 type EventBus interface {
-	// PublishScrapingComplete emits a "scraping complete" message for the given provider
-	PublishScrapingComplete(provider string)
+	// Publish emits the event to the subscribers of its type and provider
+	Publish(event Event)
 
-	// SubscribeScrapingComplete
-	SubscribeScrapingComplete(provider string, callback interface{})
+	// Subscribe subscribes the handler to the events of the given type published for the provider
+	// the handler is called asynchronously
+	Subscribe(provider string, eventType EventType, handler func(event Event))
+
+	// Close stops the subscriptions and releases the resources of the event bus
+	Close() error
 }
 
-const (
-	topicPrefix = "load:service"
-)
+const topicPrefix = "event"
 
 // defaultEventBus default EventBus component implementation backed by https://github.com/asaskevich/EventBus
 type defaultEventBus struct {
@@ -41,23 +43,36 @@ type defaultEventBus struct {
 	errorHandler emperror.ErrorHandler
 }
 
-func (eb *defaultEventBus) PublishScrapingComplete(provider string) {
-	eb.eventBus.Publish(eb.providerScrapingTopic(provider))
+func (eb *defaultEventBus) Publish(event Event) {
+	eb.eventBus.Publish(eventTopic(event.Provider, event.Type), event)
 }
 
-func (eb *defaultEventBus) SubscribeScrapingComplete(provider string, callback interface{}) {
-	if err := eb.eventBus.SubscribeAsync(eb.providerScrapingTopic(provider), callback, false); err != nil {
+func (eb *defaultEventBus) Subscribe(provider string, eventType EventType, handler func(event Event)) {
+	if err := eb.eventBus.SubscribeAsync(eventTopic(provider, eventType), handler, false); err != nil {
 		eb.errorHandler.Handle(err)
 	}
 }
 
-func (eb *defaultEventBus) providerScrapingTopic(provider string) string {
-	return strings.Join([]string{topicPrefix, provider}, ":")
+// Close waits for the running handlers
+func (eb *defaultEventBus) Close() error {
+	eb.eventBus.WaitAsync()
+
+	return nil
+}
+
+// eventTopic returns the topic of the events of the given type published for the provider
+func eventTopic(provider string, eventType EventType) string {
+	return strings.Join([]string{topicPrefix, string(eventType), provider}, ":")
 }
 
 // NewDefaultEventBus creates an event bus backed by  https://github.com/asaskevich/EventBus
-func NewDefaultEventBus(_ emperror.ErrorHandler) EventBus {
+func NewDefaultEventBus(errorHandler emperror.ErrorHandler) EventBus {
+	if errorHandler == nil {
+		errorHandler = emperror.NoopHandler{}
+	}
+
 	return &defaultEventBus{
-		eventBus: evbus.New(),
+		eventBus:     evbus.New(),
+		errorHandler: errorHandler,
 	}
 }
@@ -0,0 +1,74 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultEventBus(t *testing.T) {
+	eventBus := NewDefaultEventBus(nil)
+
+	received := make(chan Event, 10)
+	eventBus.Subscribe("amazon", SpotPriceChanged, func(event Event) {
+		received <- event
+	})
+
+	eventBus.Publish(NewEvent(SpotPriceChanged, "google", "", "europe-west1", nil))
+	eventBus.Publish(NewEvent(OnDemandPriceChanged, "amazon", "compute", "eu-west-1", nil))
+	eventBus.Publish(NewEvent(SpotPriceChanged, "amazon", "", "eu-west-1", SpotPricePayload{InstanceType: "m5.large", Zone: "eu-west-1a"}))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, SpotPriceChanged, event.Type)
+		assert.Equal(t, "amazon", event.Provider)
+		assert.Equal(t, SpotPricePayload{InstanceType: "m5.large", Zone: "eu-west-1a"}, event.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("the event is not received")
+	}
+
+	select {
+	case event := <-received:
+		t.Fatalf("unexpected event: %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEncodeEvent(t *testing.T) {
+	events := []Event{
+		NewEvent(ScrapeCompleted, "amazon", "", "", nil),
+		NewEvent(ScrapeRejected, "amazon", "compute", "eu-west-1", ScrapeRejectedPayload{Reasons: []string{"number of instance types dropped from 4 to 1"}}),
+		NewEvent(SpotPriceChanged, "amazon", "", "eu-west-1", SpotPricePayload{InstanceType: "m5.large", Zone: "eu-west-1a", OldPrice: 0.5, NewPrice: 0.25, Change: -50}),
+		NewEvent(VersionAvailable, "amazon", "eks", "eu-west-1", VersionPayload{Location: "eu-west-1", Version: "1.21"}),
+	}
+
+	for _, event := range events {
+		data, err := encodeEvent(event)
+		assert.NoError(t, err)
+
+		decoded, err := decodeEvent(data)
+		assert.NoError(t, err)
+		assert.True(t, event.Time.Equal(decoded.Time))
+
+		decoded.Time = event.Time
+		assert.Equal(t, event, decoded)
+	}
+
+	_, err := decodeEvent([]byte(`{"type":"price.spot.changed","payload":"m5.large"}`))
+	assert.Error(t, err)
+}
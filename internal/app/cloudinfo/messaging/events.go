@@ -0,0 +1,179 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// EventType identifies the kind of a domain event
+type EventType string
+
+const (
+	// ScrapeStarted is published when the scrape of a provider (or one of its services) starts
+	ScrapeStarted EventType = "scrape.started"
+	// ScrapeCompleted is published when the scrape of a provider (or one of its services) is finished, even if some regions failed
+	ScrapeCompleted EventType = "scrape.completed"
+	// ScrapeFailed is published for every service or region that failed to be scraped, the payload is a ScrapeFailedPayload
+	ScrapeFailed EventType = "scrape.failed"
+	// ScrapeRejected is published when the scraped data of a region fails the sanity checks, the payload is a ScrapeRejectedPayload
+	ScrapeRejected EventType = "scrape.rejected"
+
+	// InstanceTypeAdded is published for the instance types appearing in a region, the payload is an InstanceTypePayload
+	InstanceTypeAdded EventType = "instanceType.added"
+	// InstanceTypeRemoved is published for the instance types disappearing from a region, the payload is an InstanceTypePayload
+	InstanceTypeRemoved EventType = "instanceType.removed"
+
+	// OnDemandPriceChanged is published for the changed on demand prices, the payload is an OnDemandPricePayload
+	OnDemandPriceChanged EventType = "price.onDemand.changed"
+	// SpotPriceChanged is published for the spot prices changed beyond the configured threshold, the payload is a SpotPricePayload
+	SpotPriceChanged EventType = "price.spot.changed"
+
+	// ImageAvailable is published for the new images of a region, the payload is an ImagePayload
+	ImageAvailable EventType = "image.available"
+	// VersionAvailable is published for the new versions of a region, the payload is a VersionPayload
+	VersionAvailable EventType = "version.available"
+)
+
+// EventTypes lists every event type
+var EventTypes = []EventType{
+	ScrapeStarted, ScrapeCompleted, ScrapeFailed, ScrapeRejected,
+	InstanceTypeAdded, InstanceTypeRemoved,
+	OnDemandPriceChanged, SpotPriceChanged,
+	ImageAvailable, VersionAvailable,
+}
+
+// payloadTypes the payload types of the events, the events without payload are left out
+var payloadTypes = map[EventType]reflect.Type{
+	ScrapeFailed:         reflect.TypeOf(ScrapeFailedPayload{}),
+	ScrapeRejected:       reflect.TypeOf(ScrapeRejectedPayload{}),
+	InstanceTypeAdded:    reflect.TypeOf(InstanceTypePayload{}),
+	InstanceTypeRemoved:  reflect.TypeOf(InstanceTypePayload{}),
+	OnDemandPriceChanged: reflect.TypeOf(OnDemandPricePayload{}),
+	SpotPriceChanged:     reflect.TypeOf(SpotPricePayload{}),
+	ImageAvailable:       reflect.TypeOf(ImagePayload{}),
+	VersionAvailable:     reflect.TypeOf(VersionPayload{}),
+}
+
+// Event is a domain event published on the event bus
+// the service and the region are empty if the event concerns the whole provider (or service)
+type Event struct {
+	Type     EventType   `json:"type"`
+	Time     time.Time   `json:"time"`
+	Provider string      `json:"provider"`
+	Service  string      `json:"service,omitempty"`
+	Region   string      `json:"region,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// NewEvent creates a new event of the given type happened now
+func NewEvent(eventType EventType, provider, service, region string, payload interface{}) Event {
+	return Event{
+		Type:     eventType,
+		Time:     time.Now(),
+		Provider: provider,
+		Service:  service,
+		Region:   region,
+		Payload:  payload,
+	}
+}
+
+// ScrapeFailedPayload describes why a scrape failed
+type ScrapeFailedPayload struct {
+	Error string `json:"error"`
+}
+
+// ScrapeRejectedPayload holds the reasons the scraped data of a region was rejected for
+type ScrapeRejectedPayload struct {
+	Reasons []string `json:"reasons"`
+}
+
+// InstanceTypePayload describes an added or removed instance type
+type InstanceTypePayload struct {
+	InstanceType  string  `json:"instanceType"`
+	Category      string  `json:"category,omitempty"`
+	Cpus          float64 `json:"cpus"`
+	Mem           float64 `json:"mem"`
+	OnDemandPrice float64 `json:"onDemandPrice"`
+}
+
+// OnDemandPricePayload describes the change of the on demand price of an instance type
+type OnDemandPricePayload struct {
+	InstanceType string  `json:"instanceType"`
+	OldPrice     float64 `json:"oldPrice"`
+	NewPrice     float64 `json:"newPrice"`
+}
+
+// SpotPricePayload describes the change of the spot price of an instance type in a zone
+type SpotPricePayload struct {
+	InstanceType string  `json:"instanceType"`
+	Zone         string  `json:"zone"`
+	OldPrice     float64 `json:"oldPrice"`
+	NewPrice     float64 `json:"newPrice"`
+	// Change is the relative change of the price in percent
+	Change float64 `json:"change"`
+}
+
+// ImagePayload describes a new image
+type ImagePayload struct {
+	Name         string `json:"name"`
+	Version      string `json:"version,omitempty"`
+	GpuAvailable bool   `json:"gpu,omitempty"`
+}
+
+// VersionPayload describes a new version available in a location
+type VersionPayload struct {
+	Location string `json:"location"`
+	Version  string `json:"version"`
+}
+
+// encodeEvent encodes the event for the external backends
+func encodeEvent(event Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to encode event", "type", event.Type)
+	}
+
+	return data, nil
+}
+
+// decodeEvent decodes an event received from an external backend, the payload gets the type belonging to the event type
+func decodeEvent(data []byte) (Event, error) {
+	var raw struct {
+		Event
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Event{}, errors.WrapIf(err, "failed to decode event")
+	}
+
+	event := raw.Event
+	payloadType, ok := payloadTypes[event.Type]
+	if !ok || len(raw.Payload) == 0 {
+		return event, nil
+	}
+
+	payload := reflect.New(payloadType)
+	if err := json.Unmarshal(raw.Payload, payload.Interface()); err != nil {
+		return Event{}, errors.WrapIfWithDetails(err, "failed to decode event payload", "type", event.Type)
+	}
+	event.Payload = payload.Elem().Interface()
+
+	return event, nil
+}
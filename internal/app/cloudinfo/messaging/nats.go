@@ -0,0 +1,98 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"strings"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/nats-io/nats.go"
+)
+
+const natsSubjectPrefix = "cloudinfo.events"
+
+// natsEventBus EventBus implementation publishing the events on NATS subjects, a subject per event type and provider
+// every subscriber of every replica receives every event published while it's connected
+type natsEventBus struct {
+	conn         *nats.Conn
+	errorHandler emperror.ErrorHandler
+}
+
+// NewNATSEventBus creates an event bus backed by NATS, the connection is reestablished whenever it's lost
+func NewNATSEventBus(config NATSConfig, errorHandler emperror.ErrorHandler) (EventBus, error) {
+	if errorHandler == nil {
+		errorHandler = emperror.NoopHandler{}
+	}
+
+	conn, err := nats.Connect(config.URL,
+		nats.Name("cloudinfo"),
+		nats.MaxReconnects(-1),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			if sub != nil {
+				err = errors.WithDetails(err, "subject", sub.Subject)
+			}
+
+			errorHandler.Handle(errors.WrapIf(err, "nats error"))
+		}),
+	)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to connect to nats", "url", config.URL)
+	}
+
+	return &natsEventBus{
+		conn:         conn,
+		errorHandler: errorHandler,
+	}, nil
+}
+
+func (eb *natsEventBus) Publish(event Event) {
+	data, err := encodeEvent(event)
+	if err != nil {
+		eb.errorHandler.Handle(err)
+		return
+	}
+
+	if err := eb.conn.Publish(natsSubject(event.Provider, event.Type), data); err != nil {
+		eb.errorHandler.Handle(errors.WrapIfWithDetails(err, "failed to publish event", "type", event.Type, "provider", event.Provider))
+	}
+}
+
+func (eb *natsEventBus) Subscribe(provider string, eventType EventType, handler func(event Event)) {
+	subject := natsSubject(provider, eventType)
+
+	_, err := eb.conn.Subscribe(subject, func(msg *nats.Msg) {
+		event, err := decodeEvent(msg.Data)
+		if err != nil {
+			eb.errorHandler.Handle(errors.WithDetails(err, "subject", subject))
+			return
+		}
+
+		go handler(event)
+	})
+	if err != nil {
+		eb.errorHandler.Handle(errors.WrapIfWithDetails(err, "failed to subscribe to events", "subject", subject))
+	}
+}
+
+// Close delivers the pending messages, then closes the connection
+func (eb *natsEventBus) Close() error {
+	return eb.conn.Drain()
+}
+
+// natsSubject returns the subject of the events of the given type published for the provider
+func natsSubject(provider string, eventType EventType) string {
+	return strings.Join([]string{natsSubjectPrefix, string(eventType), provider}, ".")
+}
@@ -0,0 +1,239 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	redigo "github.com/gomodule/redigo/redis"
+
+	"github.com/banzaicloud/cloudinfo/internal/platform/redis"
+)
+
+const (
+	redisStreamPrefix = "cloudinfo:events"
+
+	// redisEventField the field of the stream entries holding the encoded event
+	redisEventField = "event"
+
+	// redisBlockTimeout the longest a read waits for new events, the subscriptions are stopped within this time on close
+	redisBlockTimeout = 5 * time.Second
+
+	// redisRetryInterval the wait after a failed read
+	redisRetryInterval = time.Second
+)
+
+// redisEventBus EventBus implementation backed by Redis streams, a stream per event type and provider
+// the subscribers read the streams independently (no consumer groups), so every subscriber of every replica receives every event
+type redisEventBus struct {
+	pool         *redigo.Pool
+	maxLen       int
+	errorHandler emperror.ErrorHandler
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRedisEventBus creates an event bus backed by Redis streams
+func NewRedisEventBus(config RedisConfig, errorHandler emperror.ErrorHandler) EventBus {
+	if errorHandler == nil {
+		errorHandler = emperror.NoopHandler{}
+	}
+
+	return &redisEventBus{
+		pool:         redis.NewPool(config.Config),
+		maxLen:       config.MaxLen,
+		errorHandler: errorHandler,
+		done:         make(chan struct{}),
+	}
+}
+
+func (eb *redisEventBus) Publish(event Event) {
+	data, err := encodeEvent(event)
+	if err != nil {
+		eb.errorHandler.Handle(err)
+		return
+	}
+
+	conn := eb.pool.Get()
+	defer conn.Close()
+
+	args := redigo.Args{}.Add(redisStream(event.Provider, event.Type))
+	if eb.maxLen > 0 {
+		args = args.Add("MAXLEN", "~", eb.maxLen)
+	}
+	args = args.Add("*", redisEventField, data)
+
+	if _, err := conn.Do("XADD", args...); err != nil {
+		eb.errorHandler.Handle(errors.WrapIfWithDetails(err, "failed to publish event", "type", event.Type, "provider", event.Provider))
+	}
+}
+
+func (eb *redisEventBus) Subscribe(provider string, eventType EventType, handler func(event Event)) {
+	stream := redisStream(provider, eventType)
+
+	// the events appended from now on are read
+	lastID, err := eb.lastEntryID(stream)
+	if err != nil {
+		eb.errorHandler.Handle(err)
+		lastID = "$"
+	}
+
+	eb.wg.Add(1)
+	go eb.consume(stream, lastID, handler)
+}
+
+// Close stops the subscriptions and closes the connections
+func (eb *redisEventBus) Close() error {
+	close(eb.done)
+	eb.wg.Wait()
+
+	return eb.pool.Close()
+}
+
+// consume passes the events appended to the stream after the given entry to the handler till the event bus is closed
+func (eb *redisEventBus) consume(stream, lastID string, handler func(event Event)) {
+	defer eb.wg.Done()
+
+	for {
+		select {
+		case <-eb.done:
+			return
+		default:
+		}
+
+		entries, err := eb.read(stream, lastID)
+		if err != nil {
+			eb.errorHandler.Handle(err)
+
+			select {
+			case <-eb.done:
+				return
+			case <-time.After(redisRetryInterval):
+			}
+
+			continue
+		}
+
+		for _, entry := range entries {
+			lastID = entry.id
+
+			event, err := decodeEvent(entry.data)
+			if err != nil {
+				eb.errorHandler.Handle(errors.WithDetails(err, "stream", stream, "id", entry.id))
+				continue
+			}
+
+			go handler(event)
+		}
+	}
+}
+
+// read waits for the entries of the stream after the given one
+func (eb *redisEventBus) read(stream, lastID string) ([]redisStreamEntry, error) {
+	conn := eb.pool.Get()
+	defer conn.Close()
+
+	reply, err := conn.Do("XREAD", "BLOCK", redisBlockTimeout.Milliseconds(), "STREAMS", stream, lastID)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to read events", "stream", stream)
+	}
+
+	return parseStreamEntries(reply)
+}
+
+// lastEntryID returns the id of the last entry of the stream, the id preceding every entry if the stream is empty
+func (eb *redisEventBus) lastEntryID(stream string) (string, error) {
+	conn := eb.pool.Get()
+	defer conn.Close()
+
+	entries, err := redigo.Values(conn.Do("XREVRANGE", stream, "+", "-", "COUNT", 1))
+	if err != nil {
+		return "", errors.WrapIfWithDetails(err, "failed to read the last event", "stream", stream)
+	}
+
+	if len(entries) == 0 {
+		return "0-0", nil
+	}
+
+	entry, err := redigo.Values(entries[0], nil)
+	if err != nil || len(entry) == 0 {
+		return "", errors.NewWithDetails("invalid stream entry", "stream", stream)
+	}
+
+	return redigo.String(entry[0], nil)
+}
+
+// redisStreamEntry an entry of a stream holding an encoded event
+type redisStreamEntry struct {
+	id   string
+	data []byte
+}
+
+// parseStreamEntries parses the reply of XREAD: the entries of the streams, each of them is an id and a list of fields and values
+// the reply is empty if the read timed out
+func parseStreamEntries(reply interface{}) ([]redisStreamEntry, error) {
+	if reply == nil {
+		return nil, nil
+	}
+
+	streams, err := redigo.Values(reply, nil)
+	if err != nil {
+		return nil, errors.WrapIf(err, "invalid stream reply")
+	}
+
+	var entries []redisStreamEntry
+	for _, stream := range streams {
+		streamReply, err := redigo.Values(stream, nil)
+		if err != nil || len(streamReply) != 2 {
+			return nil, errors.New("invalid stream reply")
+		}
+
+		streamEntries, err := redigo.Values(streamReply[1], nil)
+		if err != nil {
+			return nil, errors.WrapIf(err, "invalid stream reply")
+		}
+
+		for _, streamEntry := range streamEntries {
+			entry, err := redigo.Values(streamEntry, nil)
+			if err != nil || len(entry) != 2 {
+				return nil, errors.New("invalid stream entry")
+			}
+
+			id, err := redigo.String(entry[0], nil)
+			if err != nil {
+				return nil, errors.WrapIf(err, "invalid stream entry id")
+			}
+
+			fields, err := redigo.StringMap(entry[1], nil)
+			if err != nil {
+				return nil, errors.WrapIfWithDetails(err, "invalid stream entry fields", "id", id)
+			}
+
+			entries = append(entries, redisStreamEntry{id: id, data: []byte(fields[redisEventField])})
+		}
+	}
+
+	return entries, nil
+}
+
+// redisStream returns the stream of the events of the given type published for the provider
+func redisStream(provider string, eventType EventType) string {
+	return strings.Join([]string{redisStreamPrefix, string(eventType), provider}, ":")
+}
@@ -0,0 +1,50 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStreamEntries(t *testing.T) {
+	entries, err := parseStreamEntries(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries, "the read timed out")
+
+	entries, err = parseStreamEntries([]interface{}{
+		[]interface{}{
+			[]byte("cloudinfo:events:scrape.completed:amazon"),
+			[]interface{}{
+				[]interface{}{[]byte("1626272400000-0"), []interface{}{[]byte("event"), []byte(`{"type":"scrape.completed"}`)}},
+				[]interface{}{[]byte("1626272400000-1"), []interface{}{[]byte("event"), []byte(`{"type":"scrape.failed"}`)}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []redisStreamEntry{
+		{id: "1626272400000-0", data: []byte(`{"type":"scrape.completed"}`)},
+		{id: "1626272400000-1", data: []byte(`{"type":"scrape.failed"}`)},
+	}, entries)
+
+	_, err = parseStreamEntries([]interface{}{[]interface{}{[]byte("stream")}})
+	assert.Error(t, err)
+}
+
+func TestEventTopics(t *testing.T) {
+	assert.Equal(t, "cloudinfo:events:price.spot.changed:amazon", redisStream("amazon", SpotPriceChanged))
+	assert.Equal(t, "cloudinfo.events.price.spot.changed.amazon", natsSubject("amazon", SpotPriceChanged))
+}
@@ -16,6 +16,7 @@ package cloudinfo
 
 import (
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 
@@ -29,10 +30,12 @@ type cloudInfo struct {
 	log            Logger
 	providers      []string
 	cloudInfoStore CloudInfoStore
+	priceHistory   PriceHistoryStore
 }
 
 // NewCloudInfo creates a new cloudInfo instance
-func NewCloudInfo(providers []string, ciStore CloudInfoStore, logger Logger) (*cloudInfo, error) {
+// the price history store is optional, price histories are not available without it
+func NewCloudInfo(providers []string, ciStore CloudInfoStore, priceHistory PriceHistoryStore, logger Logger) (*cloudInfo, error) {
 	if providers == nil || ciStore == nil {
 		return nil, errors.New("could not create product infoer")
 	}
@@ -40,6 +43,7 @@ func NewCloudInfo(providers []string, ciStore CloudInfoStore, logger Logger) (*c
 	pi := cloudInfo{
 		providers:      providers,
 		cloudInfoStore: ciStore,
+		priceHistory:   priceHistory,
 		log:            logger.WithFields(map[string]interface{}{"component": "cloudInfo"}),
 	}
 	return &pi, nil
@@ -118,6 +122,25 @@ func (cpi *cloudInfo) GetRegions(provider, service string) (map[string]string, e
 	return nil, errors.NewWithDetails("regions not yet cached", "provider", provider, "services", service)
 }
 
+// GetRegionStatus retrieves the scrape status of the service in the region
+func (cpi *cloudInfo) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	return cpi.cloudInfoStore.GetRegionStatus(provider, service, region)
+}
+
+// GetScrapeRuns retrieves the recorded scrape runs of the provider matching the filter
+func (cpi *cloudInfo) GetScrapeRuns(provider string, filter types.ScrapeRunFilter) ([]types.ScrapeRun, error) {
+	recorded, _ := cpi.cloudInfoStore.GetScrapeRuns(provider)
+
+	runs := make([]types.ScrapeRun, 0, len(recorded))
+	for _, run := range recorded {
+		if filter.Match(run) {
+			runs = append(runs, run)
+		}
+	}
+
+	return runs, nil
+}
+
 func (cpi *cloudInfo) GetServices(provider string) ([]types.Service, error) {
 	if cachedVal, ok := cpi.cloudInfoStore.GetServices(provider); ok {
 		return cachedVal, nil
@@ -127,29 +150,52 @@ func (cpi *cloudInfo) GetServices(provider string) ([]types.Service, error) {
 }
 
 // GetProductDetails retrieves product details form the given provider and region
+// the view precomputed by the scraper is used if present, otherwise the details are merged from the vms and prices
 func (cpi *cloudInfo) GetProductDetails(provider, service, region string) ([]types.ProductDetails, error) {
+	// a nil view is not authoritative (eg. written by an earlier version for a service without a precomputed view)
+	if details, ok := cpi.cloudInfoStore.GetProductDetails(provider, service, region); ok && details != nil {
+		return details, nil
+	}
+
 	vms, ok := cpi.cloudInfoStore.GetVm(provider, service, region)
 	if !ok {
 		cpi.log.Debug("VMs not yet cached")
 		return nil, errors.NewWithDetails("VMs not yet cached", "provider", provider, "service", service, "region", region)
 	}
 
+	prices, _ := cpi.cloudInfoStore.GetPrices(provider, region, instanceTypes(vms))
+
+	return mergeProductDetails(vms, prices, cpi.log), nil
+}
+
+// mergeProductDetails decorates the vms with the spot prices
+func mergeProductDetails(vms []types.VMInfo, prices map[string]types.Price, log Logger) []types.ProductDetails {
 	details := make([]types.ProductDetails, 0, len(vms))
 	for _, vm := range vms {
 		pd := types.NewProductDetails(vm)
-		cachedVal, ok := cpi.cloudInfoStore.GetPrice(provider, region, vm.Type)
+		price, ok := prices[vm.Type]
 		if !ok {
-			cpi.log.Debug("price info not yet cached", map[string]interface{}{"instanceType": vm.Type})
+			log.Debug("price info not yet cached", map[string]interface{}{"instanceType": vm.Type})
 		}
 
-		for zone, price := range cachedVal.SpotPrice {
-			pd.SpotPrice = append(pd.SpotPrice, *types.NewZonePrice(zone, price))
+		for zone, zonePrice := range price.SpotPrice {
+			pd.SpotPrice = append(pd.SpotPrice, *types.NewZonePrice(zone, zonePrice))
 		}
 
 		details = append(details, *pd)
 	}
 
-	return details, nil
+	return details
+}
+
+// instanceTypes collects the types of the vms
+func instanceTypes(vms []types.VMInfo) []string {
+	instTypes := make([]string, 0, len(vms))
+	for _, vm := range vms {
+		instTypes = append(instTypes, vm.Type)
+	}
+
+	return instTypes
 }
 
 // GetStatus retrieves status form the given provider
@@ -179,6 +225,19 @@ func (cpi *cloudInfo) GetVersions(provider, service, region string) ([]types.Loc
 		"service", service, "region", region)
 }
 
+// GetPriceHistory retrieves the recorded prices of an instance type in the given time range
+func (cpi *cloudInfo) GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error) {
+	if cpi.priceHistory == nil {
+		return nil, errors.New("price history is not enabled")
+	}
+
+	if !cpi.providerEnabled(provider) {
+		return nil, errors.NewWithDetails("unsupported provider", "provider", provider)
+	}
+
+	return cpi.priceHistory.GetPriceHistory(provider, region, instanceType, from, to)
+}
+
 // GetContinents retrieves available continents
 func (cpi *cloudInfo) GetContinents() []string {
 	return []string{types.ContinentAsia, types.ContinentAustralia, types.ContinentEurope, types.ContinentNorthAmerica, types.ContinentSouthAmerica}
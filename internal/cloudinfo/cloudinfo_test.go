@@ -30,7 +30,11 @@ type DummyCloudInfoStore struct {
 	CloudInfoStore
 }
 
-const notCached = "error"
+const (
+	notCached = "error"
+	noView    = "noView"
+	nilView   = "nilView"
+)
 
 var cloudinfoLogger = NoOpLogger()
 
@@ -127,6 +131,53 @@ func (dcis *DummyCloudInfoStore) GetServices(provider string) ([]types.Service,
 	}
 }
 
+func (dcis *DummyCloudInfoStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	switch dcis.TcId {
+	case notCached, noView:
+		return nil, false
+	case nilView:
+		return nil, true
+	default:
+		return []types.ProductDetails{
+				{
+					VMInfo: types.VMInfo{Type: "view"},
+				},
+			},
+			true
+	}
+}
+
+func (dcis *DummyCloudInfoStore) GetVm(provider, service, region string) ([]types.VMInfo, bool) {
+	switch dcis.TcId {
+	case notCached:
+		return nil, false
+	default:
+		return []types.VMInfo{
+				{
+					Type: "c5.large",
+				},
+				{
+					Type: "t2.small",
+				},
+			},
+			true
+	}
+}
+
+func (dcis *DummyCloudInfoStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	switch dcis.TcId {
+	case notCached:
+		return nil, false
+	default:
+		return map[string]types.Price{
+				"c5.large": {
+					SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.04},
+				},
+			},
+			true
+	}
+}
+
 func TestNewCachingCloudInfo(t *testing.T) {
 	tests := []struct {
 		Name        string
@@ -153,7 +204,7 @@ func TestNewCachingCloudInfo(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			test.checker(NewCloudInfo(test.CloudInfoer, &DummyCloudInfoStore{}, cloudinfoLogger))
+			test.checker(NewCloudInfo(test.CloudInfoer, &DummyCloudInfoStore{}, nil, cloudinfoLogger))
 		})
 	}
 }
@@ -186,7 +237,7 @@ func TestCachingCloudInfo_GetRegions(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, cloudinfoLogger)
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
 			info.cloudInfoStore = test.ciStore
 			test.checker(info.GetRegions("dummyProvider", "dummyService"))
 		})
@@ -218,7 +269,7 @@ func TestCachingCloudInfo_GetVersions(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, cloudinfoLogger)
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
 			info.cloudInfoStore = test.ciStore
 			test.checker(info.GetVersions("dummyProvider", "dummyService", "dummyRegion"))
 		})
@@ -250,7 +301,7 @@ func TestCachingCloudInfo_GetServiceImages(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, cloudinfoLogger)
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
 			info.cloudInfoStore = test.ciStore
 			test.checker(info.GetServiceImages("dummyProvider", "dummyService", "dummyRegion"))
 		})
@@ -282,7 +333,7 @@ func TestCachingCloudInfo_GetZones(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, cloudinfoLogger)
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
 			info.cloudInfoStore = test.ciStore
 			test.checker(info.GetZones("dummyProvider", "dummyService", "dummyRegion"))
 		})
@@ -314,7 +365,7 @@ func TestCachingCloudInfo_GetServices(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, cloudinfoLogger)
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
 			info.cloudInfoStore = test.ciStore
 			test.checker(info.GetServices("dummyProvider"))
 		})
@@ -346,9 +397,60 @@ func TestCachingCloudInfo_GetStatus(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, cloudinfoLogger)
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
 			info.cloudInfoStore = test.ciStore
 			test.checker(info.GetStatus("dummyProvider"))
 		})
 	}
 }
+
+func TestCachingCloudInfo_GetProductDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		ciStore CloudInfoStore
+		checker func(details []types.ProductDetails, err error)
+	}{
+		{
+			name:    "successfully retrieved the precomputed view",
+			ciStore: &DummyCloudInfoStore{},
+			checker: func(details []types.ProductDetails, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 1, len(details))
+				assert.Equal(t, "view", details[0].Type)
+			},
+		},
+		{
+			name:    "successfully merged the vms and prices",
+			ciStore: &DummyCloudInfoStore{TcId: noView},
+			checker: func(details []types.ProductDetails, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 2, len(details))
+				assert.Equal(t, []types.ZonePrice{{Zone: "eu-west-1a", Price: 0.04}}, details[0].SpotPrice)
+				assert.Empty(t, details[1].SpotPrice)
+			},
+		},
+		{
+			name:    "merged the vms and prices in place of a nil view",
+			ciStore: &DummyCloudInfoStore{TcId: nilView},
+			checker: func(details []types.ProductDetails, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 2, len(details))
+			},
+		},
+		{
+			name:    "failed to retrieve product details",
+			ciStore: &DummyCloudInfoStore{TcId: notCached},
+			checker: func(details []types.ProductDetails, err error) {
+				assert.Nil(t, details, "the details should be nil")
+				assert.EqualError(t, err, "VMs not yet cached")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, _ := NewCloudInfo([]string{}, &DummyCloudInfoStore{}, nil, cloudinfoLogger)
+			info.cloudInfoStore = test.ciStore
+			test.checker(info.GetProductDetails("dummyProvider", "dummyService", "dummyRegion"))
+		})
+	}
+}
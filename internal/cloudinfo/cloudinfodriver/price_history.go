@@ -0,0 +1,32 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfodriver
+
+import (
+	"context"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+const (
+	OperationPriceHistoryGetPriceHistory = "cloudinfo.PriceHistory.GetPriceHistory"
+)
+
+// PriceHistoryService provides access to the price history of instance types.
+type PriceHistoryService interface {
+	// GetPriceHistory returns the recorded prices of an instance type in a time range.
+	GetPriceHistory(ctx context.Context, provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfodriver
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-kit/kit/endpoint"
+	kitoc "github.com/go-kit/kit/tracing/opencensus"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// PriceHistoryEndpoints collects all of the endpoints that compose a price history service.
+// It's meant to be used as a helper struct, to collect all of the endpoints into a
+// single parameter.
+type PriceHistoryEndpoints struct {
+	GetPriceHistory endpoint.Endpoint
+}
+
+// MakePriceHistoryEndpoints returns an Endpoints struct where each endpoint invokes
+// the corresponding method on the provided service.
+func MakePriceHistoryEndpoints(s PriceHistoryService, logger cloudinfo.Logger) PriceHistoryEndpoints {
+	return PriceHistoryEndpoints{
+		GetPriceHistory: endpoint.Chain(
+			kitoc.TraceEndpoint(OperationPriceHistoryGetPriceHistory),
+			LogEndpoint(OperationPriceHistoryGetPriceHistory, logger),
+		)(MakeGetPriceHistoryEndpoint(s)),
+	}
+}
+
+type getPriceHistoryRequest struct {
+	Provider     string
+	Region       string
+	InstanceType string
+	From         time.Time
+	To           time.Time
+}
+
+type getPriceHistoryResponse struct {
+	Prices []types.PricePoint
+	Err    error
+}
+
+func (r getPriceHistoryResponse) Failed() error {
+	return r.Err
+}
+
+// MakeGetPriceHistoryEndpoint returns an endpoint for the matching method of the underlying service.
+func MakeGetPriceHistoryEndpoint(s PriceHistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getPriceHistoryRequest)
+
+		prices, err := s.GetPriceHistory(ctx, req.Provider, req.Region, req.InstanceType, req.From, req.To)
+
+		if err != nil {
+			if b, ok := errors.Cause(err).(businessError); ok && b.IsBusinessError() {
+				return getPriceHistoryResponse{
+					Err: err,
+				}, nil
+			}
+
+			return nil, err
+		}
+
+		resp := getPriceHistoryResponse{
+			Prices: prices,
+		}
+
+		return resp, nil
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfodriver
+
+import (
+	"context"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+const (
+	OperationScrapeRunGetScrapeRuns = "cloudinfo.ScrapeRun.GetScrapeRuns"
+)
+
+type ScrapeRunService interface {
+	// GetScrapeRuns returns the recorded scrape runs of the provider matching the filter.
+	GetScrapeRuns(ctx context.Context, provider string, filter types.ScrapeRunFilter) ([]types.ScrapeRun, error)
+}
@@ -0,0 +1,77 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfodriver
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	"github.com/go-kit/kit/endpoint"
+	kitoc "github.com/go-kit/kit/tracing/opencensus"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+type ScrapeRunEndpoints struct {
+	GetScrapeRuns endpoint.Endpoint
+}
+
+func MakeScrapeRunEndpoints(s ScrapeRunService, logger cloudinfo.Logger) ScrapeRunEndpoints {
+	return ScrapeRunEndpoints{
+		GetScrapeRuns: endpoint.Chain(
+			kitoc.TraceEndpoint(OperationScrapeRunGetScrapeRuns),
+			LogEndpoint(OperationScrapeRunGetScrapeRuns, logger),
+		)(MakeGetScrapeRunsEndpoint(s)),
+	}
+}
+
+type getScrapeRunsRequest struct {
+	Provider string
+	Filter   types.ScrapeRunFilter
+}
+
+type getScrapeRunsResponse struct {
+	Runs []types.ScrapeRun
+	Err  error
+}
+
+func (r getScrapeRunsResponse) Failed() error {
+	return r.Err
+}
+
+func MakeGetScrapeRunsEndpoint(s ScrapeRunService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getScrapeRunsRequest)
+
+		runs, err := s.GetScrapeRuns(ctx, req.Provider, req.Filter)
+
+		if err != nil {
+			if b, ok := errors.Cause(err).(businessError); ok && b.IsBusinessError() {
+				return getScrapeRunsResponse{
+					Err: err,
+				}, nil
+			}
+
+			return nil, err
+		}
+
+		resp := getScrapeRunsResponse{
+			Runs: runs,
+		}
+
+		return resp, nil
+	}
+}
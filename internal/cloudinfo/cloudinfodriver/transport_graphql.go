@@ -18,12 +18,14 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/99designs/gqlgen/handler"
 	"github.com/go-kit/kit/endpoint"
 
 	"github.com/banzaicloud/cloudinfo/.gen/api/graphql"
 	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 )
 
 // MakeGraphQLHandler mounts all of the service endpoints into a GraphQL handler.
@@ -32,26 +34,32 @@ func MakeGraphQLHandler(
 	providerEndpoints ProviderEndpoints,
 	serviceEndpoints ServiceEndpoints,
 	regionEndpoints RegionEndpoints,
+	priceHistoryEndpoints PriceHistoryEndpoints,
+	scrapeRunEndpoints ScrapeRunEndpoints,
 	errorHandler cloudinfo.ErrorHandler,
 ) http.Handler {
 	// nolint: staticcheck
 	return handler.GraphQL(graphql.NewExecutableSchema(graphql.Config{
 		Resolvers: &resolver{
-			endpoints:         endpoints,
-			providerEndpoints: providerEndpoints,
-			serviceEndpoints:  serviceEndpoints,
-			regionEndpoints:   regionEndpoints,
-			errorHandler:      errorHandler,
+			endpoints:             endpoints,
+			providerEndpoints:     providerEndpoints,
+			serviceEndpoints:      serviceEndpoints,
+			regionEndpoints:       regionEndpoints,
+			priceHistoryEndpoints: priceHistoryEndpoints,
+			scrapeRunEndpoints:    scrapeRunEndpoints,
+			errorHandler:          errorHandler,
 		},
 	}))
 }
 
 type resolver struct {
-	endpoints         Endpoints
-	providerEndpoints ProviderEndpoints
-	serviceEndpoints  ServiceEndpoints
-	regionEndpoints   RegionEndpoints
-	errorHandler      cloudinfo.ErrorHandler
+	endpoints             Endpoints
+	providerEndpoints     ProviderEndpoints
+	serviceEndpoints      ServiceEndpoints
+	regionEndpoints       RegionEndpoints
+	priceHistoryEndpoints PriceHistoryEndpoints
+	scrapeRunEndpoints    ScrapeRunEndpoints
+	errorHandler          cloudinfo.ErrorHandler
 }
 
 func (r *resolver) Query() graphql.QueryResolver {
@@ -98,6 +106,63 @@ func (r *queryResolver) InstanceTypes(ctx context.Context, provider string, serv
 	return resp.(instanceTypeQueryResponse).InstanceTypes, nil
 }
 
+func (r *queryResolver) PriceHistory(ctx context.Context, provider string, region string, instanceType string, from *time.Time, to *time.Time) ([]types.PricePoint, error) {
+	req := getPriceHistoryRequest{
+		Provider:     provider,
+		Region:       region,
+		InstanceType: instanceType,
+		To:           time.Now(),
+	}
+	if to != nil {
+		req.To = *to
+	}
+	req.From = req.To.Add(-24 * time.Hour)
+	if from != nil {
+		req.From = *from
+	}
+
+	resp, err := r.priceHistoryEndpoints.GetPriceHistory(ctx, req)
+	if err != nil {
+		r.errorHandler.Handle(err)
+
+		return nil, errors.New("internal server error")
+	}
+
+	if f, ok := resp.(endpoint.Failer); ok && f.Failed() != nil {
+		return nil, f.Failed()
+	}
+
+	return resp.(getPriceHistoryResponse).Prices, nil
+}
+
+func (r *queryResolver) ScrapeRuns(ctx context.Context, provider string, service *string, region *string, outcome *string) ([]types.ScrapeRun, error) {
+	req := getScrapeRunsRequest{
+		Provider: provider,
+	}
+	if service != nil {
+		req.Filter.Service = *service
+	}
+	if region != nil {
+		req.Filter.Region = *region
+	}
+	if outcome != nil {
+		req.Filter.Outcome = *outcome
+	}
+
+	resp, err := r.scrapeRunEndpoints.GetScrapeRuns(ctx, req)
+	if err != nil {
+		r.errorHandler.Handle(err)
+
+		return nil, errors.New("internal server error")
+	}
+
+	if f, ok := resp.(endpoint.Failer); ok && f.Failed() != nil {
+		return nil, f.Failed()
+	}
+
+	return resp.(getScrapeRunsResponse).Runs, nil
+}
+
 func (r *resolver) Provider() graphql.ProviderResolver {
 	return &providerResolver{r}
 }
@@ -14,14 +14,15 @@
 
 package distribution
 
-type Config struct {
-	Pke struct {
-		Amazon struct {
-			Enabled bool
-		}
+// Config enables distributions per provider, eg. distribution.pke.amazon.enabled
+type Config map[string]map[string]ProviderConfig
 
-		Azure struct {
-			Enabled bool
-		}
-	}
+// ProviderConfig holds the configuration of a distribution on a provider
+type ProviderConfig struct {
+	Enabled bool
+}
+
+// Enabled checks whether the distribution is enabled on the provider
+func (c Config) Enabled(distribution, provider string) bool {
+	return c[distribution][provider].Enabled
 }
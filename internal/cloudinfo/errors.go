@@ -0,0 +1,93 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"net/http"
+
+	"emperror.dev/errors"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/digitalocean/godo"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrUnsupportedRegion is returned by the providers for the regions they can't be queried in
+const ErrUnsupportedRegion = errors.Sentinel("the region is not supported")
+
+// isTerminal checks whether retrying the call can't change its outcome:
+// the region is not supported or the cloud provider API rejected the request with a client error (bad request, auth, not found)
+// the request timeouts and the throttling responses are not terminal
+func isTerminal(err error) bool {
+	if errors.Is(err, ErrUnsupportedRegion) {
+		return true
+	}
+
+	status, ok := httpStatus(err)
+	if !ok {
+		return false
+	}
+
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// httpStatus extracts the HTTP status code of the response from the errors of the cloud provider SDKs
+func httpStatus(err error) (int, bool) {
+	// aws
+	var statusCoder interface{ StatusCode() int }
+	if errors.As(err, &statusCoder) {
+		return statusCoder.StatusCode(), true
+	}
+
+	// oracle
+	var serviceError interface{ GetHTTPStatusCode() int }
+	if errors.As(err, &serviceError) {
+		return serviceError.GetHTTPStatusCode(), true
+	}
+
+	// alibaba
+	var serverError interface{ HttpStatus() int }
+	if errors.As(err, &serverError) {
+		return serverError.HttpStatus(), true
+	}
+
+	// google
+	var googleError *googleapi.Error
+	if errors.As(err, &googleError) {
+		return googleError.Code, true
+	}
+
+	// azure
+	var detailedError autorest.DetailedError
+	if errors.As(err, &detailedError) {
+		if status, ok := detailedError.StatusCode.(int); ok {
+			return status, true
+		}
+	}
+
+	// digitalocean
+	var errorResponse *godo.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Response != nil {
+		return errorResponse.Response.StatusCode, true
+	}
+
+	// token requests of the oauth2 clients
+	var retrieveError *oauth2.RetrieveError
+	if errors.As(err, &retrieveError) && retrieveError.Response != nil {
+		return retrieveError.Response.StatusCode, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,263 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"math"
+	"sort"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// publish emits an event of the provider, the event is dropped if the manager has no event bus
+func (sm *scrapingManager) publish(eventType messaging.EventType, service, region string, payload interface{}) {
+	if sm.eventBus == nil {
+		return
+	}
+
+	sm.eventBus.Publish(messaging.NewEvent(eventType, sm.provider, service, region, payload))
+}
+
+// publishFailure emits a "scrape failed" event for the service and region, either of them is empty if the failure concerns more of them
+func (sm *scrapingManager) publishFailure(service, region string, err error) {
+	sm.publish(messaging.ScrapeFailed, service, region, messaging.ScrapeFailedPayload{Error: err.Error()})
+}
+
+// regionChanges collects the change events of the scraped region data compared to the stored data
+// the events are to be published once the scraped data is stored, nothing is collected for the regions scraped the first time
+func (sm *scrapingManager) regionChanges(service, region string, data RegionData) []messaging.Event {
+	if sm.eventBus == nil {
+		return nil
+	}
+
+	var events []messaging.Event
+	events = append(events, sm.vmChanges(service, region, data.Vms)...)
+	events = append(events, sm.imageChanges(service, region, data.Images)...)
+	events = append(events, sm.versionChanges(service, region, data.Versions)...)
+
+	return events
+}
+
+// publishEvents emits the collected events
+func (sm *scrapingManager) publishEvents(events []messaging.Event) {
+	for _, event := range events {
+		sm.eventBus.Publish(event)
+	}
+}
+
+// vmChanges returns the instance type and on demand price changes of the scraped vms compared to the stored ones
+func (sm *scrapingManager) vmChanges(service, region string, vms []types.VMInfo) []messaging.Event {
+	stored, ok := sm.store.GetVm(sm.provider, service, region)
+	if !ok {
+		return nil
+	}
+
+	var events []messaging.Event
+	added, removed, priceChanges := diffVms(stored, vms)
+	for _, vm := range added {
+		events = append(events, messaging.NewEvent(messaging.InstanceTypeAdded, sm.provider, service, region, newInstanceTypePayload(vm)))
+	}
+	for _, vm := range removed {
+		events = append(events, messaging.NewEvent(messaging.InstanceTypeRemoved, sm.provider, service, region, newInstanceTypePayload(vm)))
+	}
+	for _, change := range priceChanges {
+		events = append(events, messaging.NewEvent(messaging.OnDemandPriceChanged, sm.provider, service, region, change))
+	}
+
+	return events
+}
+
+// imageChanges returns the scraped images missing from the stored ones
+func (sm *scrapingManager) imageChanges(service, region string, images []types.Image) []messaging.Event {
+	stored, ok := sm.store.GetImage(sm.provider, service, region)
+	if !ok {
+		return nil
+	}
+
+	var events []messaging.Event
+	for _, image := range newImages(stored, images) {
+		events = append(events, messaging.NewEvent(messaging.ImageAvailable, sm.provider, service, region, messaging.ImagePayload{
+			Name:         image.Name,
+			Version:      image.Version,
+			GpuAvailable: image.GpuAvailable,
+		}))
+	}
+
+	return events
+}
+
+// versionChanges returns the scraped versions missing from the stored ones
+func (sm *scrapingManager) versionChanges(service, region string, versions []types.LocationVersion) []messaging.Event {
+	stored, ok := sm.store.GetVersion(sm.provider, service, region)
+	if !ok {
+		return nil
+	}
+
+	var events []messaging.Event
+	for _, version := range newVersions(stored, versions) {
+		events = append(events, messaging.NewEvent(messaging.VersionAvailable, sm.provider, service, region, version))
+	}
+
+	return events
+}
+
+// publishPriceChanges emits the spot price changes of the region beyond the configured threshold compared to the stored prices
+func (sm *scrapingManager) publishPriceChanges(region string, prices map[string]types.Price) {
+	if sm.eventBus == nil {
+		return
+	}
+
+	var threshold float64
+	if sm.config.Events.SpotPriceThreshold != nil {
+		threshold = *sm.config.Events.SpotPriceThreshold
+	}
+
+	instTypes := make([]string, 0, len(prices))
+	for instType := range prices {
+		instTypes = append(instTypes, instType)
+	}
+	sort.Strings(instTypes)
+
+	for _, instType := range instTypes {
+		stored, ok := sm.store.GetPrice(sm.provider, region, instType)
+		if !ok {
+			continue
+		}
+
+		for _, change := range spotPriceChanges(instType, stored.SpotPrice, prices[instType].SpotPrice, threshold) {
+			sm.publish(messaging.SpotPriceChanged, "", region, change)
+		}
+	}
+}
+
+func newInstanceTypePayload(vm types.VMInfo) messaging.InstanceTypePayload {
+	return messaging.InstanceTypePayload{
+		InstanceType:  vm.Type,
+		Category:      vm.Category,
+		Cpus:          vm.Cpus,
+		Mem:           vm.Mem,
+		OnDemandPrice: vm.OnDemandPrice,
+	}
+}
+
+// diffVms compares the scraped instance types with the stored ones
+// it returns the added and removed instance types and the changed on demand prices, ordered by instance type
+func diffVms(stored, scraped []types.VMInfo) (added []types.VMInfo, removed []types.VMInfo, priceChanges []messaging.OnDemandPricePayload) {
+	storedVms := make(map[string]types.VMInfo, len(stored))
+	for _, vm := range stored {
+		storedVms[vm.Type] = vm
+	}
+
+	scrapedVms := make(map[string]types.VMInfo, len(scraped))
+	for _, vm := range scraped {
+		scrapedVms[vm.Type] = vm
+
+		previous, ok := storedVms[vm.Type]
+		if !ok {
+			added = append(added, vm)
+			continue
+		}
+
+		if previous.OnDemandPrice > 0 && vm.OnDemandPrice > 0 && previous.OnDemandPrice != vm.OnDemandPrice {
+			priceChanges = append(priceChanges, messaging.OnDemandPricePayload{
+				InstanceType: vm.Type,
+				OldPrice:     previous.OnDemandPrice,
+				NewPrice:     vm.OnDemandPrice,
+			})
+		}
+	}
+
+	for _, vm := range stored {
+		if _, ok := scrapedVms[vm.Type]; !ok {
+			removed = append(removed, vm)
+		}
+	}
+
+	sort.Slice(added, func(i, j int) bool { return added[i].Type < added[j].Type })
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Type < removed[j].Type })
+	sort.Slice(priceChanges, func(i, j int) bool { return priceChanges[i].InstanceType < priceChanges[j].InstanceType })
+
+	return added, removed, priceChanges
+}
+
+// newImages returns the scraped images not among the stored ones, the images are identified by their name
+func newImages(stored, scraped []types.Image) []types.Image {
+	storedImages := make(map[string]bool, len(stored))
+	for _, image := range stored {
+		storedImages[image.Name] = true
+	}
+
+	var images []types.Image
+	for _, image := range scraped {
+		if !storedImages[image.Name] {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
+
+// newVersions returns the scraped versions not among the stored ones of the same location
+func newVersions(stored, scraped []types.LocationVersion) []messaging.VersionPayload {
+	storedVersions := make(map[string]map[string]bool, len(stored))
+	for _, location := range stored {
+		versions := make(map[string]bool, len(location.Versions))
+		for _, version := range location.Versions {
+			versions[version] = true
+		}
+		storedVersions[location.Location] = versions
+	}
+
+	var versions []messaging.VersionPayload
+	for _, location := range scraped {
+		for _, version := range location.Versions {
+			if !storedVersions[location.Location][version] {
+				versions = append(versions, messaging.VersionPayload{Location: location.Location, Version: version})
+			}
+		}
+	}
+
+	return versions
+}
+
+// spotPriceChanges returns the spot price changes of the instance type reaching the threshold (in percent), ordered by zone
+// the zones without a previous or a current price are left out
+func spotPriceChanges(instType string, stored, scraped types.SpotPriceInfo, threshold float64) []messaging.SpotPricePayload {
+	var changes []messaging.SpotPricePayload
+	for zone, price := range scraped {
+		previous := stored[zone]
+		if previous <= 0 || price <= 0 || previous == price {
+			continue
+		}
+
+		change := (price - previous) / previous * 100
+		if math.Abs(change) < threshold {
+			continue
+		}
+
+		changes = append(changes, messaging.SpotPricePayload{
+			InstanceType: instType,
+			Zone:         zone,
+			OldPrice:     previous,
+			NewPrice:     price,
+			Change:       change,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Zone < changes[j].Zone })
+
+	return changes
+}
@@ -0,0 +1,197 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// publishedEvents records the published events
+type publishedEvents struct {
+	// implement the interface
+	messaging.EventBus
+	events []messaging.Event
+	mu     sync.Mutex
+}
+
+func (pe *publishedEvents) Publish(event messaging.Event) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.events = append(pe.events, event)
+}
+
+// payloads returns the payloads of the recorded events of the given type
+func (pe *publishedEvents) payloads(eventType messaging.EventType) []interface{} {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	var payloads []interface{}
+	for _, event := range pe.events {
+		if event.Type == eventType {
+			payloads = append(payloads, event.Payload)
+		}
+	}
+
+	return payloads
+}
+
+// versionStore serves stored versions and prices on top of the region data store
+type versionStore struct {
+	regionDataStore
+	versions []types.LocationVersion
+	price    types.Price
+}
+
+func (vs *versionStore) GetVersion(provider, service, region string) ([]types.LocationVersion, bool) {
+	return vs.versions, vs.versions != nil
+}
+
+func (vs *versionStore) GetPrice(provider, region, instanceType string) (types.Price, bool) {
+	return vs.price, instanceType == "m5.large"
+}
+
+func TestScrapingManager_scrapeServiceRegion_Events(t *testing.T) {
+	store := &versionStore{
+		regionDataStore: regionDataStore{
+			regions:  make(map[string]RegionData),
+			statuses: make(map[string]types.RegionStatus),
+			vms:      []types.VMInfo{{Type: "m5.large", OnDemandPrice: 0.2}, {Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.4}},
+		},
+		versions: []types.LocationVersion{{Location: "eu-west-1", Versions: []string{"1.20"}}},
+	}
+	eventBus := &publishedEvents{}
+	config := ScrapeConfig{
+		Concurrency: 2,
+		Sanity:      SanityConfig{MaxInstanceDrop: percent(-1), MaxPriceChange: percent(-1), AllowMissingCategories: true},
+	}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, config, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), eventBus, emperror.NoopHandler{})
+
+	_, err := sm.scrapeServiceRegion(context.Background(), "compute", "eu-west-1", 42)
+	assert.NoError(t, err)
+
+	assert.Empty(t, eventBus.payloads(messaging.InstanceTypeAdded))
+	assert.Equal(t, []interface{}{
+		messaging.InstanceTypePayload{InstanceType: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.4},
+	}, eventBus.payloads(messaging.InstanceTypeRemoved))
+	assert.Equal(t, []interface{}{
+		messaging.OnDemandPricePayload{InstanceType: "m5.large", OldPrice: 0.2, NewPrice: 0.1},
+	}, eventBus.payloads(messaging.OnDemandPriceChanged))
+	assert.Equal(t, []interface{}{
+		messaging.VersionPayload{Location: "eu-west-1", Version: "1.21"},
+	}, eventBus.payloads(messaging.VersionAvailable))
+
+	for _, event := range eventBus.events {
+		assert.Equal(t, "dummy", event.Provider)
+		assert.Equal(t, "compute", event.Service)
+		assert.Equal(t, "eu-west-1", event.Region)
+	}
+
+	_, err = sm.scrapeServiceRegion(context.Background(), "compute", "broken", 42)
+	assert.Error(t, err)
+	assert.Empty(t, eventBus.payloads(messaging.ScrapeFailed), "the failures are published by the callers")
+}
+
+func TestScrapingManager_scrapeServiceRegion_EventsNotStored(t *testing.T) {
+	store := &versionStore{
+		regionDataStore: regionDataStore{
+			regions:  make(map[string]RegionData),
+			statuses: make(map[string]types.RegionStatus),
+			vms:      []types.VMInfo{{Type: "m5.large", OnDemandPrice: 0.2}, {Type: "m5.xlarge", OnDemandPrice: 0.4}},
+			storeErr: errors.New("connection refused"),
+		},
+		versions: []types.LocationVersion{{Location: "eu-west-1", Versions: []string{"1.20"}}},
+	}
+	eventBus := &publishedEvents{}
+	config := ScrapeConfig{
+		Concurrency: 2,
+		Sanity:      SanityConfig{MaxInstanceDrop: percent(-1), MaxPriceChange: percent(-1), AllowMissingCategories: true},
+	}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, config, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), eventBus, emperror.NoopHandler{})
+
+	_, err := sm.scrapeServiceRegion(context.Background(), "compute", "eu-west-1", 42)
+	assert.EqualError(t, err, "failed to store region data: connection refused")
+
+	// the changes of the data that failed to be stored are not published
+	assert.Empty(t, eventBus.events)
+}
+
+func TestScrapingManager_publishPriceChanges(t *testing.T) {
+	store := &versionStore{price: types.Price{SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.5, "eu-west-1b": 0.5}}}
+	eventBus := &publishedEvents{}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, ScrapeConfig{Events: EventsConfig{SpotPriceThreshold: percent(10)}}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), eventBus, emperror.NoopHandler{})
+
+	sm.publishPriceChanges("eu-west-1", map[string]types.Price{
+		"m5.large":  {SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.25, "eu-west-1b": 0.52}},
+		"m5.xlarge": {SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.2}},
+	})
+
+	assert.Equal(t, []interface{}{
+		messaging.SpotPricePayload{InstanceType: "m5.large", Zone: "eu-west-1a", OldPrice: 0.5, NewPrice: 0.25, Change: -50},
+	}, eventBus.payloads(messaging.SpotPriceChanged))
+}
+
+func TestDiffVms(t *testing.T) {
+	added, removed, priceChanges := diffVms(
+		[]types.VMInfo{{Type: "c5.large", OnDemandPrice: 0.1}, {Type: "m5.large", OnDemandPrice: 0.1}, {Type: "m5.xlarge", OnDemandPrice: 0.2}},
+		[]types.VMInfo{{Type: "m5.xlarge", OnDemandPrice: 0.3}, {Type: "m5.large", OnDemandPrice: 0.1}, {Type: "r5.large", OnDemandPrice: 0.2}, {Type: "a1.large"}},
+	)
+
+	assert.Equal(t, []types.VMInfo{{Type: "a1.large"}, {Type: "r5.large", OnDemandPrice: 0.2}}, added)
+	assert.Equal(t, []types.VMInfo{{Type: "c5.large", OnDemandPrice: 0.1}}, removed)
+	assert.Equal(t, []messaging.OnDemandPricePayload{{InstanceType: "m5.xlarge", OldPrice: 0.2, NewPrice: 0.3}}, priceChanges)
+}
+
+func TestNewImages(t *testing.T) {
+	images := newImages(
+		[]types.Image{{Name: "ami-1"}},
+		[]types.Image{{Name: "ami-1"}, {Name: "ami-2", Version: "1.21"}},
+	)
+
+	assert.Equal(t, []types.Image{{Name: "ami-2", Version: "1.21"}}, images)
+}
+
+func TestNewVersions(t *testing.T) {
+	versions := newVersions(
+		[]types.LocationVersion{{Location: "eu-west-1", Versions: []string{"1.20", "1.21"}}},
+		[]types.LocationVersion{{Location: "eu-west-1", Versions: []string{"1.21", "1.22"}}, {Location: "eu-west-2", Versions: []string{"1.21"}}},
+	)
+
+	assert.Equal(t, []messaging.VersionPayload{{Location: "eu-west-1", Version: "1.22"}, {Location: "eu-west-2", Version: "1.21"}}, versions)
+}
+
+func TestSpotPriceChanges(t *testing.T) {
+	stored := types.SpotPriceInfo{"a": 0.1, "b": 0.1, "c": 0.1}
+	scraped := types.SpotPriceInfo{"a": 0.2, "b": 0.101, "d": 0.1}
+
+	assert.Equal(t, []messaging.SpotPricePayload{
+		{InstanceType: "m5.large", Zone: "a", OldPrice: 0.1, NewPrice: 0.2, Change: 100},
+	}, spotPriceChanges("m5.large", stored, scraped, 10))
+	assert.Len(t, spotPriceChanges("m5.large", stored, scraped, -1), 2, "every change is published with a negative threshold")
+}
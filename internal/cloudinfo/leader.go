@@ -0,0 +1,214 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// LeaderLockKeyTemplate format for generating the keys of the scrape locks of the providers
+const LeaderLockKeyTemplate = "/banzaicloud.com/cloudinfo-lock/providers/%s/scrape"
+
+// Lock is a distributed lock with a lease, the lock is lost if it's not renewed before the lease expires.
+type Lock interface {
+	// Acquire acquires the lock of the key for the lease or renews it if it's already held, false is returned if it's held by someone else
+	Acquire(ctx context.Context, key string, lease time.Duration) (bool, error)
+
+	// Release releases the lock of the key if it's held
+	Release(ctx context.Context, key string) error
+}
+
+// LeaderElectionConfig holds the settings of the leader election among the replicas scraping the same store.
+type LeaderElectionConfig struct {
+	// Enabled turns on the leader election, otherwise every replica scrapes every provider.
+	Enabled bool
+
+	// Lease is the time the leadership of a provider is held for without renewal.
+	Lease time.Duration
+
+	// RenewInterval is the time between the renewals of the leaderships, it should be well below the lease.
+	RenewInterval time.Duration
+}
+
+// Validate validates the leader election configuration.
+func (c LeaderElectionConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.RenewInterval <= 0 {
+		return errors.New("leader election renew interval must be positive")
+	}
+
+	if c.Lease <= c.RenewInterval {
+		return errors.New("leader election lease must be longer than the renew interval")
+	}
+
+	return nil
+}
+
+// LeaderElector elects the replica scraping a provider, the leaderships are renewed in the background.
+type LeaderElector struct {
+	lock   Lock
+	config LeaderElectionConfig
+	log    Logger
+
+	leading map[string]bool
+	// lost the channels closed when the leadership of the providers is lost, see LeaderContext
+	lost map[string]chan struct{}
+	mu   sync.RWMutex
+}
+
+// NewLeaderElector creates a new leader elector with the given lock.
+func NewLeaderElector(lock Lock, config LeaderElectionConfig, log Logger) *LeaderElector {
+	return &LeaderElector{
+		lock:    lock,
+		config:  config,
+		log:     log.WithFields(map[string]interface{}{"component": "leader-elector"}),
+		leading: make(map[string]bool),
+		lost:    make(map[string]chan struct{}),
+	}
+}
+
+// Start runs the election of the providers right away, then renews the leaderships periodically in a new goroutine
+// the leaderships are released when the context is done
+func (le *LeaderElector) Start(ctx context.Context, providers []string) {
+	le.elect(ctx, providers)
+
+	go func() {
+		ticker := time.NewTicker(le.config.RenewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				le.elect(ctx, providers)
+			case <-ctx.Done():
+				le.release(providers)
+				return
+			}
+		}
+	}()
+}
+
+// IsLeader returns true if the replica is the leader of the provider, ie. the one scraping it
+func (le *LeaderElector) IsLeader(provider string) bool {
+	le.mu.RLock()
+	defer le.mu.RUnlock()
+
+	return le.leading[provider]
+}
+
+// LeaderContext returns a context that is cancelled when the replica loses the leadership of the provider,
+// the scrapes run with it stop storing their results once another replica may have taken over the provider
+// the context is cancelled right away if the replica is not the leader
+func (le *LeaderElector) LeaderContext(ctx context.Context, provider string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	le.mu.Lock()
+	if !le.leading[provider] {
+		le.mu.Unlock()
+		cancel()
+
+		return ctx, cancel
+	}
+
+	lost, ok := le.lost[provider]
+	if !ok {
+		lost = make(chan struct{})
+		le.lost[provider] = lost
+	}
+	le.mu.Unlock()
+
+	go func() {
+		select {
+		case <-lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// setLeading records the leadership of the provider, the leader contexts are cancelled if it's lost
+// the caller must hold the lock
+func (le *LeaderElector) setLeading(provider string, leading bool) {
+	if le.leading[provider] && !leading {
+		if lost, ok := le.lost[provider]; ok {
+			close(lost)
+			delete(le.lost, provider)
+		}
+	}
+
+	le.leading[provider] = leading
+}
+
+// elect acquires or renews the leadership of the providers
+// the leadership is given up if the lock can't be renewed, as it may expire any time
+func (le *LeaderElector) elect(ctx context.Context, providers []string) {
+	for _, provider := range providers {
+		leading, err := le.lock.Acquire(ctx, fmt.Sprintf(LeaderLockKeyTemplate, provider), le.config.Lease)
+		if err != nil {
+			le.log.Error("failed to acquire scrape lock", map[string]interface{}{"provider": provider, "error": err.Error()})
+			leading = false
+		}
+
+		le.mu.Lock()
+		if le.leading[provider] != leading {
+			le.log.Info("scrape leadership changed", map[string]interface{}{"provider": provider, "leader": leading})
+		}
+		le.setLeading(provider, leading)
+		le.mu.Unlock()
+	}
+}
+
+// release releases the held leaderships
+func (le *LeaderElector) release(providers []string) {
+	for _, provider := range providers {
+		if !le.IsLeader(provider) {
+			continue
+		}
+
+		le.mu.Lock()
+		le.setLeading(provider, false)
+		le.mu.Unlock()
+
+		if err := le.lock.Release(context.Background(), fmt.Sprintf(LeaderLockKeyTemplate, provider)); err != nil {
+			le.log.Error("failed to release scrape lock", map[string]interface{}{"provider": provider, "error": err.Error()})
+		}
+	}
+}
+
+// localLock is a lock that is always acquired, used if there's a single replica scraping the providers
+type localLock struct{}
+
+// NewLocalLock creates a lock that is always acquired, with it every replica scrapes every provider.
+func NewLocalLock() Lock {
+	return localLock{}
+}
+
+func (localLock) Acquire(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (localLock) Release(ctx context.Context, key string) error {
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// sharedLock is an in-memory lock shared by the electors of the tests, the leases never expire
+type sharedLock struct {
+	owners map[string]string
+	mu     sync.Mutex
+}
+
+// ownedBy returns a view of the lock owned by the owner
+func (sl *sharedLock) ownedBy(owner string) Lock {
+	return &ownedLock{lock: sl, owner: owner}
+}
+
+type ownedLock struct {
+	lock  *sharedLock
+	owner string
+}
+
+func (ol *ownedLock) Acquire(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	ol.lock.mu.Lock()
+	defer ol.lock.mu.Unlock()
+
+	if owner, ok := ol.lock.owners[key]; ok && owner != ol.owner {
+		return false, nil
+	}
+
+	ol.lock.owners[key] = ol.owner
+	return true, nil
+}
+
+func (ol *ownedLock) Release(ctx context.Context, key string) error {
+	ol.lock.mu.Lock()
+	defer ol.lock.mu.Unlock()
+
+	if ol.lock.owners[key] == ol.owner {
+		delete(ol.lock.owners, key)
+	}
+
+	return nil
+}
+
+// failingLock can't be acquired
+type failingLock struct {
+	Lock
+}
+
+func (failingLock) Acquire(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestLeaderElector(t *testing.T) {
+	lock := &sharedLock{owners: make(map[string]string)}
+	config := LeaderElectionConfig{Enabled: true, Lease: time.Minute, RenewInterval: time.Hour}
+	providers := []string{"amazon", "google"}
+
+	// the google lock is held by a third replica
+	lock.owners["/banzaicloud.com/cloudinfo-lock/providers/google/scrape"] = "replica-3"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := NewLeaderElector(lock.ownedBy("replica-1"), config, cloudinfoLogger)
+	first.Start(ctx, providers)
+
+	second := NewLeaderElector(lock.ownedBy("replica-2"), config, cloudinfoLogger)
+	second.Start(context.Background(), providers)
+
+	assert.True(t, first.IsLeader("amazon"))
+	assert.False(t, first.IsLeader("google"))
+	assert.False(t, second.IsLeader("amazon"))
+
+	// the leadership is released when the first replica stops
+	cancel()
+	assert.Eventually(t, func() bool {
+		lock.mu.Lock()
+		defer lock.mu.Unlock()
+
+		_, held := lock.owners["/banzaicloud.com/cloudinfo-lock/providers/amazon/scrape"]
+		return !held
+	}, time.Second, 10*time.Millisecond)
+
+	second.elect(context.Background(), providers)
+	assert.True(t, second.IsLeader("amazon"))
+	assert.False(t, first.IsLeader("amazon"))
+}
+
+func TestLeaderElector_failingLock(t *testing.T) {
+	elector := NewLeaderElector(failingLock{}, LeaderElectionConfig{Lease: time.Minute, RenewInterval: time.Second}, cloudinfoLogger)
+	elector.leading["amazon"] = true
+
+	elector.elect(context.Background(), []string{"amazon"})
+
+	// the leadership is given up, the lock may expire any time
+	assert.False(t, elector.IsLeader("amazon"))
+}
+
+func TestLeaderElector_LeaderContext(t *testing.T) {
+	lock := &sharedLock{owners: make(map[string]string)}
+	elector := NewLeaderElector(lock.ownedBy("replica-1"), LeaderElectionConfig{Lease: time.Minute, RenewInterval: time.Second}, cloudinfoLogger)
+	elector.elect(context.Background(), []string{"amazon"})
+
+	ctx, cancel := elector.LeaderContext(context.Background(), "amazon")
+	defer cancel()
+	assert.NoError(t, ctx.Err())
+
+	// the lock is taken over by another replica, eg. the lease expired
+	lock.owners["/banzaicloud.com/cloudinfo-lock/providers/amazon/scrape"] = "replica-2"
+	elector.elect(context.Background(), []string{"amazon"})
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context should be cancelled when the leadership is lost")
+	}
+
+	// the context of a replica not leading is cancelled right away
+	ctx, cancel = elector.LeaderContext(context.Background(), "amazon")
+	defer cancel()
+	assert.Error(t, ctx.Err())
+}
+
+func TestLeaderElectionConfig_Validate(t *testing.T) {
+	assert.NoError(t, LeaderElectionConfig{}.Validate())
+	assert.NoError(t, LeaderElectionConfig{Enabled: true, Lease: 30 * time.Second, RenewInterval: 10 * time.Second}.Validate())
+	assert.Error(t, LeaderElectionConfig{Enabled: true, Lease: 10 * time.Second, RenewInterval: 10 * time.Second}.Validate())
+	assert.Error(t, LeaderElectionConfig{Enabled: true, Lease: 10 * time.Second}.Validate())
+}
@@ -69,6 +69,54 @@ var (
 	},
 		[]string{"provider", "region"},
 	)
+	// scrapeRetriesTotalCounter collects metrics for the prometheus
+	scrapeRetriesTotalCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "scrape",
+		Name:      "retries_total",
+		Help:      "Total number of retried cloud provider calls, partitioned by provider and operation",
+	},
+		[]string{"provider", "operation"},
+	)
+	// scrapeThrottledTotalCounter collects metrics for the prometheus
+	scrapeThrottledTotalCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "scrape",
+		Name:      "throttled_total",
+		Help:      "Total number of cloud provider calls delayed by the rate limits, partitioned by provider and operation",
+	},
+		[]string{"provider", "operation"},
+	)
+	// scrapeThrottledSecondsCounter collects metrics for the prometheus
+	scrapeThrottledSecondsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "scrape",
+		Name:      "throttled_seconds_total",
+		Help:      "Total time cloud provider calls waited for the rate limits in seconds, partitioned by provider and operation",
+	},
+		[]string{"provider", "operation"},
+	)
+	// scrapeRejectedTotalCounter collects metrics for the prometheus
+	scrapeRejectedTotalCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "scrape",
+		Name:      "rejected_total",
+		Help:      "Total number of scrape results rejected or quarantined by the sanity checks, partitioned by provider, service, region and reason",
+	},
+		[]string{"provider", "service", "region", "reason"},
+	)
+	// scrapeWarnedTotalCounter collects metrics for the prometheus
+	scrapeWarnedTotalCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "scrape",
+		Name:      "warned_total",
+		Help:      "Total number of scrape results failing the sanity checks but stored anyway (warn action or accepted by the operator), partitioned by provider, service, region and reason",
+	},
+		[]string{"provider", "service", "region", "reason"},
+	)
+	// scrapeCircuitBreakerStateGauge collects metrics for the prometheus
+	scrapeCircuitBreakerStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "scrape",
+		Name:      "circuit_breaker_state",
+		Help:      "Worst state of the circuit breakers of the cloud provider regions (0: closed, 1: half-open, 2: open)",
+	},
+		[]string{"provider"},
+	)
 	// OnDemandPriceGauge collects metrics for the prometheus
 	OnDemandPriceGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "cloudinfo",
@@ -98,6 +146,21 @@ type Reporter interface {
 
 	// ReportScrapeShortLivedFailure reports the failure of scraping short lived information
 	ReportScrapeShortLivedFailure(provider, region string)
+
+	// ReportScrapeRetry reports a retried cloud provider call
+	ReportScrapeRetry(provider, operation string)
+
+	// ReportScrapeThrottled reports a cloud provider call delayed by the rate limits
+	ReportScrapeThrottled(provider, operation string, wait time.Duration)
+
+	// ReportScrapeRejected reports a scrape result rejected or quarantined for failing a sanity check
+	ReportScrapeRejected(provider, service, region, reason string)
+
+	// ReportScrapeWarned reports a scrape result failing a sanity check that is stored anyway
+	ReportScrapeWarned(provider, service, region, reason string)
+
+	// ReportCircuitBreakerState reports the worst state of the circuit breakers of the provider (0: closed, 1: half-open, 2: open)
+	ReportCircuitBreakerState(provider string, state int)
 }
 
 // DefaultMetricsReporter default metrics source for the application
@@ -144,6 +207,27 @@ func (ms *DefaultMetricsReporter) ReportScrapeShortLivedFailure(provider, region
 	scrapeShortLivedFailuresTotalCounter.WithLabelValues(provider, region).Inc()
 }
 
+func (ms *DefaultMetricsReporter) ReportScrapeRetry(provider, operation string) {
+	scrapeRetriesTotalCounter.WithLabelValues(provider, operation).Inc()
+}
+
+func (ms *DefaultMetricsReporter) ReportScrapeThrottled(provider, operation string, wait time.Duration) {
+	scrapeThrottledTotalCounter.WithLabelValues(provider, operation).Inc()
+	scrapeThrottledSecondsCounter.WithLabelValues(provider, operation).Add(wait.Seconds())
+}
+
+func (ms *DefaultMetricsReporter) ReportScrapeRejected(provider, service, region, reason string) {
+	scrapeRejectedTotalCounter.WithLabelValues(provider, service, region, reason).Inc()
+}
+
+func (ms *DefaultMetricsReporter) ReportScrapeWarned(provider, service, region, reason string) {
+	scrapeWarnedTotalCounter.WithLabelValues(provider, service, region, reason).Inc()
+}
+
+func (ms *DefaultMetricsReporter) ReportCircuitBreakerState(provider string, state int) {
+	scrapeCircuitBreakerStateGauge.WithLabelValues(provider).Set(float64(state))
+}
+
 // NewMetricsSource assembles a Reporter with custom collectors
 func NewDefaultMetricsReporter() Reporter {
 	dms := &DefaultMetricsReporter{}
@@ -153,6 +237,12 @@ func NewDefaultMetricsReporter() Reporter {
 	dms.addCollector(scrapeShortLivedCompleteDurationGauge)
 	dms.addCollector(scrapeShortLivedRegionDurationGauge)
 	dms.addCollector(scrapeShortLivedFailuresTotalCounter)
+	dms.addCollector(scrapeRetriesTotalCounter)
+	dms.addCollector(scrapeThrottledTotalCounter)
+	dms.addCollector(scrapeThrottledSecondsCounter)
+	dms.addCollector(scrapeCircuitBreakerStateGauge)
+	dms.addCollector(scrapeRejectedTotalCounter)
+	dms.addCollector(scrapeWarnedTotalCounter)
 
 	dms.registerCollectors()
 
@@ -241,6 +331,16 @@ func (nor *noOpReporter) ReportScrapeRegionShortLivedCompleted(provider, region
 
 func (nor *noOpReporter) ReportScrapeShortLivedFailure(provider, region string) {}
 
+func (nor *noOpReporter) ReportScrapeRetry(provider, operation string) {}
+
+func (nor *noOpReporter) ReportScrapeRejected(provider, service, region, reason string) {}
+
+func (nor *noOpReporter) ReportScrapeWarned(provider, service, region, reason string) {}
+
+func (nor *noOpReporter) ReportScrapeThrottled(provider, operation string, wait time.Duration) {}
+
+func (nor *noOpReporter) ReportCircuitBreakerState(provider string, state int) {}
+
 func NewNoOpMetricsReporter() Reporter {
 	return &noOpReporter{}
 }
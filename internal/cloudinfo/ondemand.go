@@ -0,0 +1,165 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// RegionScraper scrapes the data of a single region on demand.
+type RegionScraper interface {
+	// ScrapeRegion scrapes the data of the service in the region, waiting for it until the context is done
+	ScrapeRegion(ctx context.Context, provider, service, region string) error
+}
+
+// onDemandCloudInfo scrapes the regions requested before being scraped, instead of failing till the next scrape
+type onDemandCloudInfo struct {
+	types.CloudInfo
+
+	scraper  RegionScraper
+	timeout  time.Duration
+	cooldown time.Duration
+	log      Logger
+
+	// failures the times of the last failed scrapes of the regions
+	failures map[string]time.Time
+	mu       sync.Mutex
+}
+
+// NewOnDemandCloudInfo wraps the cloud info, so that the region data missing because the region hasn't been scraped yet
+// is scraped on demand, the requests wait for the scrape up to the timeout.
+// A region failed to be scraped is not scraped on demand again before the cooldown passes.
+func NewOnDemandCloudInfo(ci types.CloudInfo, scraper RegionScraper, timeout, cooldown time.Duration, log Logger) types.CloudInfo {
+	return &onDemandCloudInfo{
+		CloudInfo: ci,
+		scraper:   scraper,
+		timeout:   timeout,
+		cooldown:  cooldown,
+		log:       log.WithFields(map[string]interface{}{"component": "on-demand-scraper"}),
+		failures:  make(map[string]time.Time),
+	}
+}
+
+func (ci *onDemandCloudInfo) GetProductDetails(provider, service, region string) ([]types.ProductDetails, error) {
+	details, err := ci.CloudInfo.GetProductDetails(provider, service, region)
+	if err != nil && ci.scrapeMissingRegion(provider, service, region) {
+		return ci.CloudInfo.GetProductDetails(provider, service, region)
+	}
+
+	return details, err
+}
+
+func (ci *onDemandCloudInfo) GetZones(provider, service, region string) ([]string, error) {
+	zones, err := ci.CloudInfo.GetZones(provider, service, region)
+	if err != nil && ci.scrapeMissingRegion(provider, service, region) {
+		return ci.CloudInfo.GetZones(provider, service, region)
+	}
+
+	return zones, err
+}
+
+func (ci *onDemandCloudInfo) GetServiceImages(provider, service, region string) ([]types.Image, error) {
+	images, err := ci.CloudInfo.GetServiceImages(provider, service, region)
+	if err != nil && ci.scrapeMissingRegion(provider, service, region) {
+		return ci.CloudInfo.GetServiceImages(provider, service, region)
+	}
+
+	return images, err
+}
+
+func (ci *onDemandCloudInfo) GetVersions(provider, service, region string) ([]types.LocationVersion, error) {
+	versions, err := ci.CloudInfo.GetVersions(provider, service, region)
+	if err != nil && ci.scrapeMissingRegion(provider, service, region) {
+		return ci.CloudInfo.GetVersions(provider, service, region)
+	}
+
+	return versions, err
+}
+
+// scrapeMissingRegion scrapes the region if it's a known region of the service that hasn't been scraped yet
+// it returns true if the region got scraped, the data still missing after a successful scrape (eg. images) is not scraped again
+// neither is a region failed to be scraped within the cooldown
+func (ci *onDemandCloudInfo) scrapeMissingRegion(provider, service, region string) bool {
+	if _, scraped := ci.GetRegionStatus(provider, service, region); scraped {
+		return false
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", provider, service, region)
+	if ci.coolingDown(key) {
+		return false
+	}
+
+	regions, err := ci.GetRegions(provider, service)
+	if err != nil {
+		return false
+	}
+
+	if _, ok := regions[region]; !ok {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ci.timeout)
+	defer cancel()
+
+	fields := map[string]interface{}{"provider": provider, "service": service, "region": region}
+
+	ci.log.Info("scraping region on demand", fields)
+	if err := ci.scraper.ScrapeRegion(ctx, provider, service, region); err != nil {
+		fields["error"] = err.Error()
+		if errors.Is(err, ErrNotLeader) {
+			ci.log.Debug("region is scraped by the leader replica", fields)
+			return false
+		}
+
+		ci.log.Warn("failed to scrape region on demand", fields)
+		ci.recordFailure(key)
+		return false
+	}
+
+	return true
+}
+
+// coolingDown returns true if the last scrape of the region failed within the cooldown
+func (ci *onDemandCloudInfo) coolingDown(key string) bool {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	failed, ok := ci.failures[key]
+	if !ok {
+		return false
+	}
+
+	if time.Since(failed) >= ci.cooldown {
+		delete(ci.failures, key)
+		return false
+	}
+
+	return true
+}
+
+// recordFailure records the time of the failed scrape of the region
+func (ci *onDemandCloudInfo) recordFailure(key string) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	ci.failures[key] = time.Now()
+}
@@ -0,0 +1,246 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// regionCloudInfo has the zones of the scraped regions
+type regionCloudInfo struct {
+	// implement the interface
+	types.CloudInfo
+	zones    map[string][]string
+	statuses map[string]types.RegionStatus
+}
+
+func (ci *regionCloudInfo) GetRegions(provider, service string) (map[string]string, error) {
+	return map[string]string{"eu-west-1": "EU (Ireland)", "eu-west-2": "EU (London)"}, nil
+}
+
+func (ci *regionCloudInfo) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	status, ok := ci.statuses[region]
+	return status, ok
+}
+
+func (ci *regionCloudInfo) GetZones(provider, service, region string) ([]string, error) {
+	if zones, ok := ci.zones[region]; ok {
+		return zones, nil
+	}
+
+	return nil, errors.New("zones not yet cached")
+}
+
+// regionScraper stores the zones of the scraped regions
+type regionScraper struct {
+	ci      *regionCloudInfo
+	err     error
+	scraped []string
+}
+
+func (rs *regionScraper) ScrapeRegion(ctx context.Context, provider, service, region string) error {
+	rs.scraped = append(rs.scraped, region)
+	if rs.err != nil {
+		return rs.err
+	}
+
+	rs.ci.zones[region] = []string{region + "a"}
+	rs.ci.statuses[region] = types.RegionStatus{LastSuccess: time.Now()}
+	return nil
+}
+
+func TestOnDemandCloudInfo_GetZones(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    string
+		scrapeErr error
+		checker   func(zones []string, err error, scraped []string)
+	}{
+		{
+			name:   "scraped region",
+			region: "eu-west-1",
+			checker: func(zones []string, err error, scraped []string) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"eu-west-1a"}, zones)
+				assert.Empty(t, scraped)
+			},
+		},
+		{
+			name:   "missing region scraped on demand",
+			region: "eu-west-2",
+			checker: func(zones []string, err error, scraped []string) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"eu-west-2a"}, zones)
+				assert.Equal(t, []string{"eu-west-2"}, scraped)
+			},
+		},
+		{
+			name:      "failed on demand scrape",
+			region:    "eu-west-2",
+			scrapeErr: errors.New("context deadline exceeded"),
+			checker: func(zones []string, err error, scraped []string) {
+				assert.EqualError(t, err, "zones not yet cached")
+				assert.Equal(t, []string{"eu-west-2"}, scraped)
+			},
+		},
+		{
+			name:   "unknown region",
+			region: "mars-1",
+			checker: func(zones []string, err error, scraped []string) {
+				assert.Error(t, err)
+				assert.Empty(t, scraped)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ci := &regionCloudInfo{
+				zones:    map[string][]string{"eu-west-1": {"eu-west-1a"}},
+				statuses: map[string]types.RegionStatus{"eu-west-1": {LastSuccess: time.Now()}},
+			}
+			scraper := &regionScraper{ci: ci, err: test.scrapeErr}
+
+			zones, err := NewOnDemandCloudInfo(ci, scraper, time.Second, time.Minute, cloudinfoLogger).GetZones("amazon", "compute", test.region)
+			test.checker(zones, err, scraper.scraped)
+		})
+	}
+}
+
+func TestOnDemandCloudInfo_FailureCooldown(t *testing.T) {
+	tests := []struct {
+		name      string
+		scrapeErr error
+		cooldown  time.Duration
+		scraped   []string
+	}{
+		{
+			name:      "failed region is not scraped within the cooldown",
+			scrapeErr: errors.New("context deadline exceeded"),
+			cooldown:  time.Minute,
+			scraped:   []string{"eu-west-2"},
+		},
+		{
+			name:      "failed region is scraped again after the cooldown",
+			scrapeErr: errors.New("context deadline exceeded"),
+			cooldown:  0,
+			scraped:   []string{"eu-west-2", "eu-west-2"},
+		},
+		{
+			name:      "region scraped by another replica is retried",
+			scrapeErr: errors.WithDetails(ErrNotLeader, "provider", "amazon"),
+			cooldown:  time.Minute,
+			scraped:   []string{"eu-west-2", "eu-west-2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ci := &regionCloudInfo{zones: make(map[string][]string), statuses: make(map[string]types.RegionStatus)}
+			scraper := &regionScraper{ci: ci, err: test.scrapeErr}
+			onDemand := NewOnDemandCloudInfo(ci, scraper, time.Second, test.cooldown, cloudinfoLogger)
+
+			for i := 0; i < 2; i++ {
+				_, err := onDemand.GetZones("amazon", "compute", "eu-west-2")
+				assert.EqualError(t, err, "zones not yet cached")
+			}
+			assert.Equal(t, test.scraped, scraper.scraped)
+		})
+	}
+}
+
+// blockingCloudInfoer counts the zone retrievals, blocking them till released
+type blockingCloudInfoer struct {
+	dummyCloudInfoer
+	release chan struct{}
+	calls   int
+	mu      sync.Mutex
+}
+
+func (bci *blockingCloudInfoer) GetZones(region string) ([]string, error) {
+	bci.mu.Lock()
+	bci.calls++
+	bci.mu.Unlock()
+
+	<-bci.release
+	return bci.dummyCloudInfoer.GetZones(region)
+}
+
+// serviceRegionDataStore has the compute service
+type serviceRegionDataStore struct {
+	regionDataStore
+}
+
+func (srds *serviceRegionDataStore) GetServices(provider string) ([]types.Service, bool) {
+	return []types.Service{{Service: "compute"}}, true
+}
+
+func TestScrapingDriver_ScrapeRegion(t *testing.T) {
+	infoer := &blockingCloudInfoer{release: make(chan struct{})}
+	store := &serviceRegionDataStore{regionDataStore{regions: make(map[string]RegionData), statuses: make(map[string]types.RegionStatus)}}
+	driver := NewScrapingDriver(map[string]CloudInfoer{"dummy": infoer}, store, nil,
+		map[string]ScrapeConfig{"dummy": {Concurrency: 1, RunHistory: 10, Retry: RetryConfig{MaxRetries: -1}, CircuitBreaker: CircuitBreakerConfig{Threshold: -1}}},
+		NewLeaderElector(NewLocalLock(), LeaderElectionConfig{}, cloudinfoLogger), nil,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), emperror.NoopHandler{}, cloudinfoLogger)
+	driver.leader.elect(context.Background(), []string{"dummy"})
+
+	// the request gives up waiting, the scrape goes on
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, driver.ScrapeRegion(ctx, "dummy", "compute", "eu-west-1"))
+
+	// the concurrent requests share the scrape in progress
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, driver.ScrapeRegion(context.Background(), "dummy", "compute", "eu-west-1"))
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(infoer.release)
+	wg.Wait()
+
+	assert.Equal(t, 1, infoer.calls)
+	assert.Contains(t, store.regions, "eu-west-1")
+
+	assert.Error(t, driver.ScrapeRegion(context.Background(), "other", "compute", "eu-west-1"))
+}
+
+func TestScrapingDriver_ScrapeRegion_NotLeader(t *testing.T) {
+	infoer := &blockingCloudInfoer{release: make(chan struct{})}
+	store := &serviceRegionDataStore{regionDataStore{regions: make(map[string]RegionData), statuses: make(map[string]types.RegionStatus)}}
+	driver := NewScrapingDriver(map[string]CloudInfoer{"dummy": infoer}, store, nil,
+		map[string]ScrapeConfig{"dummy": {Concurrency: 1, RunHistory: 10, Retry: RetryConfig{MaxRetries: -1}, CircuitBreaker: CircuitBreakerConfig{Threshold: -1}}},
+		NewLeaderElector(NewLocalLock(), LeaderElectionConfig{}, cloudinfoLogger), nil,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), emperror.NoopHandler{}, cloudinfoLogger)
+
+	// only the leader of the provider scrapes on demand
+	err := driver.ScrapeRegion(context.Background(), "dummy", "compute", "eu-west-1")
+	assert.True(t, errors.Is(err, ErrNotLeader))
+	assert.Equal(t, 0, infoer.calls)
+	assert.NotContains(t, store.regions, "eu-west-1")
+}
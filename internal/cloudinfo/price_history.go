@@ -0,0 +1,106 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// PriceHistoryStore keeps the history of the instance type prices
+// only the changes are expected to be appended, a point is valid till the next one
+type PriceHistoryStore interface {
+	// AppendPrices records the prices of the instance types in a region at the given time
+	AppendPrices(provider, region string, at time.Time, prices map[string]types.Price) error
+
+	// GetPriceHistory returns the points of an instance type in the time range in chronological order
+	// the last point before the range is included too, as it holds the price at the start of the range
+	GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error)
+
+	// Prune removes the points older than the given time, the last of them is kept for every instance type
+	Prune(before time.Time) error
+}
+
+// PriceHistorySource retrieves price histories.
+type PriceHistorySource interface {
+	// GetPriceHistory returns the recorded prices of an instance type in a time range
+	GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error)
+}
+
+// PriceHistoryService returns the price history of instance types.
+type PriceHistoryService struct {
+	source PriceHistorySource
+}
+
+// NewPriceHistoryService returns a new PriceHistoryService.
+func NewPriceHistoryService(source PriceHistorySource) *PriceHistoryService {
+	return &PriceHistoryService{
+		source: source,
+	}
+}
+
+// PriceHistoryQueryValidationError is returned if a price history query is invalid.
+type PriceHistoryQueryValidationError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e PriceHistoryQueryValidationError) Error() string {
+	return e.Message
+}
+
+// IsBusinessError tells the transport layer whether this error should be translated into the transport format
+// or an internal error should be returned instead.
+func (PriceHistoryQueryValidationError) IsBusinessError() bool {
+	return true
+}
+
+// GetPriceHistory returns the recorded prices of an instance type in a time range.
+func (s *PriceHistoryService) GetPriceHistory(ctx context.Context, provider, region, instanceType string, from, to time.Time) ([]types.PricePoint, error) {
+	if provider == "" || region == "" || instanceType == "" {
+		return nil, errors.WithStack(PriceHistoryQueryValidationError{
+			Message: "provider, region and instance type fields must not be empty",
+		})
+	}
+
+	if from.After(to) {
+		return nil, errors.WithStack(PriceHistoryQueryValidationError{
+			Message: "the start of the time range must not be after its end",
+		})
+	}
+
+	points, err := s.source.GetPriceHistory(provider, region, instanceType, from, to)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to retrieve price history")
+	}
+
+	return points, nil
+}
+
+// PrunePriceHistory returns a task that removes the price points older than the retention period
+func PrunePriceHistory(store PriceHistoryStore, retention time.Duration, log Logger, errorHandler ErrorHandler) TaskFn {
+	return func(ctx context.Context) {
+		before := time.Now().Add(-retention)
+
+		log.Debug("pruning price history", map[string]interface{}{"before": before})
+		if err := store.Prune(before); err != nil {
+			errorHandler.Handle(errors.WrapIf(err, "failed to prune price history"))
+		}
+	}
+}
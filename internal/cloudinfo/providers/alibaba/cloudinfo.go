@@ -17,6 +17,7 @@ package alibaba
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"emperror.dev/emperror"
@@ -40,7 +41,8 @@ type AlibabaInfoer struct {
 const svcAck = "ack"
 
 // NewAlibabaInfoer creates a new instance of the Alibaba infoer.
-func NewAlibabaInfoer(config Config, logger cloudinfo.Logger) (*AlibabaInfoer, error) {
+// The API calls are sent with the transport of the HTTP client if it's set.
+func NewAlibabaInfoer(config Config, httpClient *http.Client, logger cloudinfo.Logger) (*AlibabaInfoer, error) {
 	client, err := sdk.NewClientWithAccessKey(
 		config.Region,
 		config.AccessKey,
@@ -56,6 +58,10 @@ func NewAlibabaInfoer(config Config, logger cloudinfo.Logger) (*AlibabaInfoer, e
 	client.GetConfig().WithDebug(true)
 	client.GetConfig().WithMaxRetryTime(10)
 
+	if httpClient != nil {
+		client.SetTransport(httpClient.Transport)
+	}
+
 	return &AlibabaInfoer{
 		client: client,
 		log:    logger,
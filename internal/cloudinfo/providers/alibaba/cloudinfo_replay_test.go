@@ -0,0 +1,66 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alibaba
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestAlibabaInfoer_Replay runs the infoer against the API responses recorded in testdata/alibaba.json
+func TestAlibabaInfoer_Replay(t *testing.T) {
+	transport, err := httprecord.Load("testdata/alibaba.json")
+	require.NoError(t, err)
+
+	config := Config{
+		Region:    "eu-central-1",
+		AccessKey: "access-key",
+		SecretKey: "secret-key",
+	}
+
+	infoer, err := NewAlibabaInfoer(config, transport.Client(), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	require.NoError(t, err)
+
+	regions, err := infoer.GetRegions("compute")
+	require.NoError(t, err)
+
+	zones, err := infoer.GetZones("eu-central-1")
+	require.NoError(t, err)
+
+	vms, err := infoer.GetVirtualMachines("eu-central-1")
+	require.NoError(t, err)
+
+	prices, err := infoer.GetCurrentPrices("eu-central-1")
+	require.NoError(t, err)
+
+	images, err := infoer.GetServiceImages(svcAck, "eu-central-1")
+	require.NoError(t, err)
+
+	httprecord.AssertGolden(t, "testdata/alibaba.golden.json", map[string]interface{}{
+		"regions": regions,
+		"zones":   zones,
+		"vms":     vms,
+		"prices":  prices,
+		"images":  images,
+	}, *update)
+}
@@ -17,6 +17,7 @@ package amazon
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -61,12 +62,17 @@ type Ec2Describer interface {
 }
 
 // NewAmazonInfoer builds an infoer instance based on the provided configuration
-func NewAmazonInfoer(config Config, logger cloudinfo.Logger) (*Ec2Infoer, error) {
+// the AWS API calls are sent with the HTTP client if it's set
+func NewAmazonInfoer(config Config, httpClient *http.Client, logger cloudinfo.Logger) (*Ec2Infoer, error) {
 	pconfig, err := configFromCredentials(config.GetPricingCredentials())
 	if err != nil {
 		return nil, errors.Wrap(err, "creating pricing aws config")
 	}
 
+	if httpClient != nil {
+		pconfig.WithHTTPClient(httpClient)
+	}
+
 	psess, err := session.NewSession(pconfig.WithRegion(config.Pricing.Region))
 	if err != nil {
 		return nil, errors.Wrap(err, "creating pricing aws session")
@@ -77,6 +83,10 @@ func NewAmazonInfoer(config Config, logger cloudinfo.Logger) (*Ec2Infoer, error)
 		return nil, errors.Wrap(err, "creating ec2 aws config")
 	}
 
+	if httpClient != nil {
+		econfig.WithHTTPClient(httpClient)
+	}
+
 	esess, err := session.NewSession(econfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating ec2 aws session")
@@ -0,0 +1,69 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amazon
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestEc2Infoer_Replay runs the infoer against the API responses recorded in testdata/amazon.json
+func TestEc2Infoer_Replay(t *testing.T) {
+	// the SDK can't load a custom CA bundle into a replaying transport
+	if caBundle, ok := os.LookupEnv("AWS_CA_BUNDLE"); ok {
+		_ = os.Unsetenv("AWS_CA_BUNDLE")
+		defer os.Setenv("AWS_CA_BUNDLE", caBundle)
+	}
+
+	transport, err := httprecord.Load("testdata/amazon.json")
+	require.NoError(t, err)
+
+	config := Config{
+		Credentials: Credentials{AccessKey: "access-key", SecretKey: "secret-key"},
+		Region:      "us-east-1",
+		Pricing:     PricingConfig{Region: "us-east-1"},
+	}
+
+	infoer, err := NewAmazonInfoer(config, transport.Client(), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	require.NoError(t, err)
+
+	zones, err := infoer.GetZones("eu-central-1")
+	require.NoError(t, err)
+
+	vms, err := infoer.GetVirtualMachines("eu-central-1")
+	require.NoError(t, err)
+
+	prices, err := infoer.GetCurrentPrices("eu-central-1")
+	require.NoError(t, err)
+
+	images, err := infoer.GetServiceImages(svcPKE, "eu-central-1")
+	require.NoError(t, err)
+
+	httprecord.AssertGolden(t, "testdata/amazon.golden.json", map[string]interface{}{
+		"zones":  zones,
+		"vms":    vms,
+		"prices": prices,
+		"images": images,
+	}, *update)
+}
@@ -166,7 +166,7 @@ func TestNewEc2Infoer(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			test.check(NewAmazonInfoer(test.config, cloudinfoadapter.NewLogger(&logur.TestLogger{})))
+			test.check(NewAmazonInfoer(test.config, nil, cloudinfoadapter.NewLogger(&logur.TestLogger{})))
 		})
 	}
 }
@@ -198,7 +198,7 @@ func TestEc2Infoer_GetRegion(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			cloudInfoer, err := NewAmazonInfoer(Config{Region: "us-east-1"}, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+			cloudInfoer, err := NewAmazonInfoer(Config{Region: "us-east-1"}, nil, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
 			if err != nil {
 				t.Fatalf("failed to create cloudinfoer; [%s]", err.Error())
 			}
@@ -240,7 +240,7 @@ func TestEc2Infoer_getCurrentSpotPrices(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			cloudInfoer, err := NewAmazonInfoer(Config{Region: "us-east-1"}, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+			cloudInfoer, err := NewAmazonInfoer(Config{Region: "us-east-1"}, nil, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
 			if err != nil {
 				t.Fatalf("failed to create cloudinfoer; [%s]", err.Error())
 			}
@@ -285,7 +285,7 @@ func TestEc2Infoer_GetCurrentPrices(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			c := Config{PrometheusAddress: "PromAPIAddress", Region: "us-east-1"}
-			cloudInfoer, err := NewAmazonInfoer(c, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+			cloudInfoer, err := NewAmazonInfoer(c, nil, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
 			if err != nil {
 				t.Fatalf("failed to create cloudinfoer; [%s]", err.Error())
 			}
@@ -331,7 +331,7 @@ func TestEc2Infoer_GetZones(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			c := Config{PrometheusAddress: "PromAPIAddress", Region: "us-east-1"}
-			cloudInfoer, err := NewAmazonInfoer(c, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+			cloudInfoer, err := NewAmazonInfoer(c, nil, cloudinfoadapter.NewLogger(&logur.TestLogger{}))
 			if err != nil {
 				t.Fatalf("failed to create cloudinfoer; [%s]", err.Error())
 			}
@@ -17,6 +17,7 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -104,16 +105,21 @@ type ResourceSkuRetriever interface {
 }
 
 // NewAzureInfoer creates a new instance of the Azure infoer.
-func NewAzureInfoer(config Config, logger cloudinfo.Logger) (*AzureInfoer, error) {
+// The API calls are sent with the HTTP client if it's set, so are the token requests of the client credentials.
+func NewAzureInfoer(config Config, httpClient *http.Client, logger cloudinfo.Logger) (*AzureInfoer, error) {
 	var authorizer autorest.Authorizer
 	if config.ClientID != "" && config.ClientSecret != "" && config.TenantID != "" {
 		credentialsConfig := auth.NewClientCredentialsConfig(config.ClientID, config.ClientSecret, config.TenantID)
-		a, err := credentialsConfig.Authorizer()
+		token, err := credentialsConfig.ServicePrincipalToken()
 		if err != nil {
 			return nil, emperror.Wrap(err, "failed to build authorizer")
 		}
 
-		authorizer = a
+		if httpClient != nil {
+			token.SetSender(httpClient)
+		}
+
+		authorizer = autorest.NewBearerAuthorizer(token)
 	}
 
 	if authorizer == nil {
@@ -144,6 +150,14 @@ func NewAzureInfoer(config Config, logger cloudinfo.Logger) (*AzureInfoer, error
 	containerServiceClient := containerservice.NewContainerServicesClient(config.SubscriptionID)
 	containerServiceClient.Authorizer = authorizer
 
+	if httpClient != nil {
+		sClient.Sender = httpClient
+		rcClient.Sender = httpClient
+		skusClient.Sender = httpClient
+		providersClient.Sender = httpClient
+		containerServiceClient.Sender = httpClient
+	}
+
 	return &AzureInfoer{
 		subscriptionId:      config.SubscriptionID,
 		subscriptionsClient: sClient,
@@ -0,0 +1,77 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"flag"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestAzureInfoer_Replay runs the infoer against the API responses recorded in testdata/azure.json
+func TestAzureInfoer_Replay(t *testing.T) {
+	transport, err := httprecord.Load("testdata/azure.json")
+	require.NoError(t, err)
+
+	config := Config{
+		SubscriptionID: "subscription-id",
+		ClientID:       "client-id",
+		ClientSecret:   "client-secret",
+		TenantID:       "tenant-id",
+	}
+
+	infoer, err := NewAzureInfoer(config, transport.Client(), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	require.NoError(t, err)
+
+	prices, err := infoer.Initialize()
+	require.NoError(t, err)
+
+	regions, err := infoer.GetRegions("compute")
+	require.NoError(t, err)
+
+	aksRegions, err := infoer.GetRegions(svcAks)
+	require.NoError(t, err)
+
+	zones, err := infoer.GetZones("westeurope")
+	require.NoError(t, err)
+	sort.Strings(zones)
+
+	vms, err := infoer.GetVirtualMachines("westeurope")
+	require.NoError(t, err)
+
+	products, err := infoer.GetProducts(vms, svcAks, "westeurope")
+	require.NoError(t, err)
+
+	versions, err := infoer.GetVersions(svcAks, "westeurope")
+	require.NoError(t, err)
+
+	httprecord.AssertGolden(t, "testdata/azure.golden.json", map[string]interface{}{
+		"prices":     prices,
+		"regions":    regions,
+		"aksRegions": aksRegions,
+		"zones":      zones,
+		"vms":        vms,
+		"aksVms":     products,
+		"versions":   versions,
+	}, *update)
+}
@@ -17,6 +17,7 @@ package digitalocean
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"emperror.dev/emperror"
@@ -37,11 +38,17 @@ type DigitaloceanInfoer struct {
 }
 
 // NewDigitaloceanInfoer creates a new instance of the Digitalocean infoer.
-func NewDigitaloceanInfoer(config Config, logger cloudinfo.Logger) (*DigitaloceanInfoer, error) {
+// The API calls are sent with the HTTP client if it's set.
+func NewDigitaloceanInfoer(config Config, httpClient *http.Client, logger cloudinfo.Logger) (*DigitaloceanInfoer, error) {
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
 	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{
 		AccessToken: config.AccessToken,
 	})
-	oauthClient := oauth2.NewClient(context.Background(), tokenSource)
+	oauthClient := oauth2.NewClient(ctx, tokenSource)
 	client := godo.NewClient(oauthClient)
 
 	return &DigitaloceanInfoer{
@@ -0,0 +1,64 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package digitalocean
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestDigitaloceanInfoer_Replay runs the infoer against the API responses recorded in testdata/digitalocean.json
+func TestDigitaloceanInfoer_Replay(t *testing.T) {
+	transport, err := httprecord.Load("testdata/digitalocean.json")
+	require.NoError(t, err)
+
+	infoer, err := NewDigitaloceanInfoer(Config{AccessToken: "token"}, transport.Client(), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	require.NoError(t, err)
+
+	prices, err := infoer.Initialize()
+	require.NoError(t, err)
+
+	regions, err := infoer.GetRegions("compute")
+	require.NoError(t, err)
+
+	dokRegions, err := infoer.GetRegions("dok")
+	require.NoError(t, err)
+
+	vms, err := infoer.GetVirtualMachines("fra1")
+	require.NoError(t, err)
+
+	products, err := infoer.GetProducts(vms, "dok", "fra1")
+	require.NoError(t, err)
+
+	versions, err := infoer.GetVersions("dok", "fra1")
+	require.NoError(t, err)
+
+	httprecord.AssertGolden(t, "testdata/digitalocean.golden.json", map[string]interface{}{
+		"prices":     prices,
+		"regions":    regions,
+		"dokRegions": dokRegions,
+		"vms":        vms,
+		"dokVms":     products,
+		"versions":   versions,
+	}, *update)
+}
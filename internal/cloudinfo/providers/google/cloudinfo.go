@@ -19,10 +19,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strings"
 
 	"emperror.dev/emperror"
 	"emperror.dev/errors"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/cloudbilling/v1"
 	"google.golang.org/api/compute/v1"
@@ -37,6 +39,9 @@ import (
 
 const svcGke = "gke"
 
+// scopes of the API clients
+var scopes = []string{compute.ComputeReadonlyScope, container.CloudPlatformScope}
+
 var regionNames = map[string]string{
 	"asia-east1":              "Asia Pacific (Taiwan)",
 	"asia-east2":              "Asia Pacific (Hong Kong)",
@@ -68,10 +73,11 @@ type GceInfoer struct {
 }
 
 // NewGoogleInfoer creates a new instance of the Google infoer.
-func NewGoogleInfoer(config Config, logger cloudinfo.Logger) (*GceInfoer, error) {
+// The API calls are sent with the HTTP client if it's set, authenticated with the configured credentials.
+func NewGoogleInfoer(config Config, httpClient *http.Client, logger cloudinfo.Logger) (*GceInfoer, error) {
 	clientOpts := []option.ClientOption{
 		option.WithCredentialsFile(config.CredentialsFile),
-		option.WithScopes(compute.ComputeReadonlyScope, container.CloudPlatformScope),
+		option.WithScopes(scopes...),
 	}
 
 	if config.Credentials != "" {
@@ -83,6 +89,15 @@ func NewGoogleInfoer(config Config, logger cloudinfo.Logger) (*GceInfoer, error)
 		clientOpts = append(clientOpts, option.WithCredentialsJSON(decoded))
 	}
 
+	if httpClient != nil {
+		authClient, err := newAuthenticatedClient(config, httpClient)
+		if err != nil {
+			return nil, err
+		}
+
+		clientOpts = []option.ClientOption{option.WithHTTPClient(authClient)}
+	}
+
 	computeSvc, err := compute.NewService(context.Background(), clientOpts...)
 	if err != nil {
 		return nil, emperror.Wrap(err, "failed to create the compute service client")
@@ -112,6 +127,40 @@ func NewGoogleInfoer(config Config, logger cloudinfo.Logger) (*GceInfoer, error)
 	}, nil
 }
 
+// newAuthenticatedClient returns a client authenticating the requests sent with the HTTP client
+// the API clients don't authenticate the requests of an HTTP client passed to them
+func newAuthenticatedClient(config Config, httpClient *http.Client) (*http.Client, error) {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case config.Credentials != "":
+		if data, err = base64.StdEncoding.DecodeString(config.Credentials); err != nil {
+			return nil, emperror.Wrap(err, "failed to decode credentials")
+		}
+
+	case config.CredentialsFile != "":
+		if data, err = ioutil.ReadFile(config.CredentialsFile); err != nil {
+			return nil, emperror.Wrap(err, "failed to read credentials file")
+		}
+	}
+
+	var credentials *google.Credentials
+	if data != nil {
+		credentials, err = google.CredentialsFromJSON(ctx, data, scopes...)
+	} else {
+		credentials, err = google.FindDefaultCredentials(ctx, scopes...)
+	}
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to load credentials")
+	}
+
+	return oauth2.NewClient(ctx, credentials.TokenSource), nil
+}
+
 func getProject(config Config) (string, error) {
 	if config.Project != "" {
 		return config.Project, nil
@@ -180,13 +229,17 @@ func (g *GceInfoer) Initialize() (map[string]map[string]types.Price, error) {
 	if err != nil {
 		return nil, err
 	}
+	// the spot prices are set for the zones of every region, so they are retrieved before the machine types
 	for r := range regions {
 		zones, err := g.GetZones(r)
 		if err != nil {
 			return nil, err
 		}
 		zonesInRegions[r] = zones
-		err = g.computeSvc.MachineTypes.List(g.projectId, zones[0]).Pages(context.TODO(), func(allMts *compute.MachineTypeList) error {
+	}
+
+	for r := range regions {
+		err = g.computeSvc.MachineTypes.List(g.projectId, zonesInRegions[r][0]).Pages(context.TODO(), func(allMts *compute.MachineTypeList) error {
 			for region, price := range pricePerRegion {
 				for _, mt := range allMts.Items {
 					if !cloudinfo.Contains(unsupportedInstanceTypes, mt.Name) {
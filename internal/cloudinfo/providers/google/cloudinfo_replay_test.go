@@ -0,0 +1,71 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package google
+
+import (
+	"encoding/base64"
+	"flag"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestGceInfoer_Replay runs the infoer against the API responses recorded in testdata/google.json
+func TestGceInfoer_Replay(t *testing.T) {
+	transport, err := httprecord.Load("testdata/google.json")
+	require.NoError(t, err)
+
+	// the token of the credentials is refreshed with a placeholder by the replaying transport
+	credentials := `{"type":"authorized_user","client_id":"client-id","client_secret":"client-secret","refresh_token":"refresh-token"}`
+
+	config := Config{
+		Credentials: base64.StdEncoding.EncodeToString([]byte(credentials)),
+		Project:     "project",
+	}
+
+	infoer, err := NewGoogleInfoer(config, transport.Client(), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	require.NoError(t, err)
+
+	prices, err := infoer.Initialize()
+	require.NoError(t, err)
+
+	regions, err := infoer.GetRegions("compute")
+	require.NoError(t, err)
+
+	zones, err := infoer.GetZones("europe-west1")
+	require.NoError(t, err)
+
+	vms, err := infoer.GetVirtualMachines("europe-west1")
+	require.NoError(t, err)
+	sort.Slice(vms, func(i, j int) bool { return vms[i].Type < vms[j].Type })
+
+	versions, err := infoer.GetVersions(svcGke, "europe-west1")
+	require.NoError(t, err)
+
+	httprecord.AssertGolden(t, "testdata/google.golden.json", map[string]interface{}{
+		"prices":   prices,
+		"regions":  regions,
+		"zones":    zones,
+		"vms":      vms,
+		"versions": versions,
+	}, *update)
+}
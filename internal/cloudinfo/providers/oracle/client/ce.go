@@ -38,6 +38,8 @@ func (oci *OCI) NewContainerEngineClient() (client *ContainerEngine, err error)
 		return client, err
 	}
 
+	oci.setHTTPClient(&oClient.BaseClient)
+
 	client.client = &oClient
 	client.oci = oci
 	client.CompartmentOCID = *oci.Tenancy.Id
@@ -17,6 +17,7 @@ package client
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"net/http"
 
 	"github.com/oracle/oci-go-sdk/common"
 	"github.com/oracle/oci-go-sdk/identity"
@@ -25,21 +26,24 @@ import (
 
 // OCI is for managing OCI API calls
 type OCI struct {
-	config  common.ConfigurationProvider
-	logger  *logrus.Logger
-	Tenancy identity.Tenancy
+	config     common.ConfigurationProvider
+	httpClient *http.Client
+	logger     *logrus.Logger
+	Tenancy    identity.Tenancy
 }
 
 // NewOCI creates a new OCI and gets and caches tenancy info
-func NewOCI(cfgProvider common.ConfigurationProvider) (oci *OCI, err error) {
+// the API calls are sent with the HTTP client if it's set
+func NewOCI(cfgProvider common.ConfigurationProvider, httpClient *http.Client) (oci *OCI, err error) {
 
 	if err != nil {
 		return
 	}
 
 	oci = &OCI{
-		config: cfgProvider,
-		logger: logrus.New(),
+		config:     cfgProvider,
+		httpClient: httpClient,
+		logger:     logrus.New(),
 	}
 
 	_, err = oci.GetTenancy()
@@ -78,6 +82,13 @@ func (oci *OCI) ChangeRegion(regionName string) (err error) {
 	return
 }
 
+// setHTTPClient sets the HTTP client of the OCI API client if there is one
+func (oci *OCI) setHTTPClient(client *common.BaseClient) {
+	if oci.httpClient != nil {
+		client.HTTPClient = oci.httpClient
+	}
+}
+
 // SetLogger sets a logrus logger
 func (oci *OCI) SetLogger(logger *logrus.Logger) {
 
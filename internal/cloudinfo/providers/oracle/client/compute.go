@@ -37,6 +37,8 @@ func (oci *OCI) NewComputeClient() (client *Compute, err error) {
 		return client, err
 	}
 
+	oci.setHTTPClient(&oClient.BaseClient)
+
 	client.client = &oClient
 	client.oci = oci
 
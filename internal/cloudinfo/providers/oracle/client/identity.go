@@ -20,6 +20,8 @@ import (
 	"emperror.dev/errors"
 	"github.com/oracle/oci-go-sdk/common"
 	"github.com/oracle/oci-go-sdk/identity"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo"
 )
 
 // Identity is for managing Identity related calls of OCI
@@ -38,6 +40,8 @@ func (oci *OCI) NewIdentityClient() (client *Identity, err error) {
 		return client, err
 	}
 
+	oci.setHTTPClient(&oClient.BaseClient)
+
 	client.client = &oClient
 	client.oci = oci
 
@@ -79,7 +83,7 @@ func (i *Identity) IsRegionAvailable(name string) error {
 		return nil
 	}
 
-	return errors.Wrap(errors.New(name), "region is not available")
+	return errors.WithDetails(cloudinfo.ErrUnsupportedRegion, "region", name)
 }
 
 // GetSubscribedRegionNames gives back an array of subscribed regions' names
@@ -16,6 +16,7 @@ package oracle
 
 import (
 	"fmt"
+	"net/http"
 
 	"emperror.dev/emperror"
 	"emperror.dev/errors"
@@ -32,6 +33,7 @@ const svcOke = "oke"
 // Infoer encapsulates the data and operations needed to access external resources
 type Infoer struct {
 	client         *client.OCI
+	httpClient     *http.Client
 	shapeSpecs     map[string]ShapeSpecs
 	cloudInfoCache map[string]ITRACloudInfo
 	log            cloudinfo.Logger
@@ -75,7 +77,8 @@ var shapeSpecs = map[string]ShapeSpecs{
 }
 
 // NewOracleInfoer creates a new instance of the Oracle infoer.
-func NewOracleInfoer(config Config, logger cloudinfo.Logger) (*Infoer, error) {
+// The API calls are sent with the HTTP client if it's set.
+func NewOracleInfoer(config Config, httpClient *http.Client, logger cloudinfo.Logger) (*Infoer, error) {
 	var privateKeyPassphrase string
 	if config.PrivateKeyPassphrase != nil {
 		privateKeyPassphrase = *config.PrivateKeyPassphrase
@@ -110,13 +113,18 @@ func NewOracleInfoer(config Config, logger cloudinfo.Logger) (*Infoer, error) {
 
 	provider, _ := common.ComposingConfigurationProvider(providers)
 
-	oci, err := client.NewOCI(provider)
+	oci, err := client.NewOCI(provider, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
 	return &Infoer{
 		client:     oci,
+		httpClient: httpClient,
 		shapeSpecs: shapeSpecs,
 		log:        logger,
 	}, nil
@@ -0,0 +1,80 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oracle
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"logur.dev/logur"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/cloudinfoadapter"
+	"github.com/banzaicloud/cloudinfo/internal/platform/httprecord"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestInfoer_Replay runs the infoer against the API responses recorded in testdata/oracle.json
+func TestInfoer_Replay(t *testing.T) {
+	transport, err := httprecord.Load("testdata/oracle.json")
+	require.NoError(t, err)
+
+	// the requests are signed with the key, the signatures are not recorded though
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	config := Config{
+		Tenancy:     "ocid1.tenancy.oc1..tenancy",
+		User:        "ocid1.user.oc1..user",
+		Region:      "eu-frankfurt-1",
+		Fingerprint: "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+	}
+
+	infoer, err := NewOracleInfoer(config, transport.Client(), cloudinfoadapter.NewLogger(&logur.TestLogger{}))
+	require.NoError(t, err)
+
+	regions, err := infoer.GetRegions("compute")
+	require.NoError(t, err)
+
+	zones, err := infoer.GetZones("eu-frankfurt-1")
+	require.NoError(t, err)
+
+	vms, err := infoer.GetVirtualMachines("eu-frankfurt-1")
+	require.NoError(t, err)
+
+	products, err := infoer.GetProducts(nil, svcOke, "eu-frankfurt-1")
+	require.NoError(t, err)
+
+	versions, err := infoer.GetVersions(svcOke, "eu-frankfurt-1")
+	require.NoError(t, err)
+
+	images, err := infoer.GetServiceImages("compute", "eu-frankfurt-1")
+	require.NoError(t, err)
+
+	httprecord.AssertGolden(t, "testdata/oracle.golden.json", map[string]interface{}{
+		"regions":  regions,
+		"zones":    zones,
+		"vms":      vms,
+		"okeVms":   products,
+		"versions": versions,
+		"images":   images,
+	}, *update)
+}
@@ -18,7 +18,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
 
 	"emperror.dev/emperror"
 	"emperror.dev/errors"
@@ -59,7 +58,7 @@ func (i *Infoer) GetCloudInfoFromITRA(partNumber string) (info ITRACloudInfo, er
 	i.log.Debug("getting product info", map[string]interface{}{"PN": partNumber})
 
 	url := fmt.Sprintf("https://itra.oraclecloud.com/itas/.anon/myservices/api/v1/products?partNumber=%s", partNumber)
-	resp, err := http.Get(url)
+	resp, err := i.httpClient.Get(url)
 	if err != nil {
 		return
 	}
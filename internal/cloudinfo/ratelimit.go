@@ -0,0 +1,186 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+)
+
+// cloudInfoerOperations lists the cloud infoer operations calling the cloud provider
+var cloudInfoerOperations = []string{
+	"Initialize",
+	"GetVirtualMachines",
+	"GetProducts",
+	"GetZones",
+	"GetRegions",
+	"GetCurrentPrices",
+	"GetServiceImages",
+	"GetVersions",
+	"GetServiceProducts",
+}
+
+// isCloudInfoerOperation checks whether the name is one of the cloud infoer operations, ignoring the case
+// (the keys of the configuration are lower cased)
+func isCloudInfoerOperation(name string) bool {
+	for _, operation := range cloudInfoerOperations {
+		if strings.EqualFold(operation, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callLimiter limits the rate of the cloud infoer calls with the token buckets of their operations
+// every call takes a single token regardless of the API requests it makes, so it's only an outer bound of the requests
+// limited by the rate limited transport
+type callLimiter struct {
+	operations map[string]*rate.Limiter
+}
+
+// newCallLimiter creates a limiter as configured, nil is returned if no operation is limited
+func newCallLimiter(config RateLimitConfig) *callLimiter {
+	cl := &callLimiter{
+		operations: make(map[string]*rate.Limiter, len(config.Operations)),
+	}
+
+	for operation, limit := range config.Operations {
+		if bucket := newTokenBucket(limit); bucket != nil {
+			cl.operations[strings.ToLower(operation)] = bucket
+		}
+	}
+
+	if len(cl.operations) == 0 {
+		return nil
+	}
+
+	return cl
+}
+
+func newTokenBucket(limit RateLimit) *rate.Limiter {
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(limit.Rate), burst)
+}
+
+// reserve takes the token of the call and returns the time to wait before making it
+func (cl *callLimiter) reserve(operation string, now time.Time) time.Duration {
+	return reserveTokens(now, cl.operations[strings.ToLower(operation)])
+}
+
+// reserveTokens takes a token from each bucket and returns the time to wait till all of them allow the call
+func reserveTokens(now time.Time, buckets ...*rate.Limiter) time.Duration {
+	var wait time.Duration
+	for _, bucket := range buckets {
+		if bucket == nil {
+			continue
+		}
+
+		if delay := bucket.ReserveN(now, 1).DelayFrom(now); delay > wait {
+			wait = delay
+		}
+	}
+
+	return wait
+}
+
+// apiOperationLimiter the token bucket of the API requests matching the host and the path prefix
+type apiOperationLimiter struct {
+	APIOperationRateLimit
+	bucket *rate.Limiter
+}
+
+// matches checks whether the request is a call of the API operation
+func (l apiOperationLimiter) matches(req *http.Request) bool {
+	host := req.URL.Hostname()
+	if host != l.Host && !(strings.HasPrefix(l.Host, ".") && strings.HasSuffix(host, l.Host)) {
+		return false
+	}
+
+	return strings.HasPrefix(req.URL.Path, l.Path)
+}
+
+// rateLimitedTransport limits the rate of the API requests sent to a cloud provider with token buckets
+// every request (eg. every page of a paginated listing) takes a token from the bucket of the provider
+// and from the bucket of each API operation it matches
+type rateLimitedTransport struct {
+	next       http.RoundTripper
+	provider   string
+	bucket     *rate.Limiter
+	operations []apiOperationLimiter
+	metrics    metrics.Reporter
+	log        Logger
+}
+
+// NewRateLimitedTransport wraps the transport (the default transport if it's nil) with the rate limits of the provider's API requests
+func NewRateLimitedTransport(provider string, next http.RoundTripper, config RateLimitConfig, reporter metrics.Reporter, log Logger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &rateLimitedTransport{
+		next:     next,
+		provider: provider,
+		bucket:   newTokenBucket(config.RateLimit),
+		metrics:  reporter,
+		log:      log.WithFields(map[string]interface{}{"component": "rate-limited-transport", "provider": provider}),
+	}
+
+	for _, operation := range config.APIOperations {
+		if bucket := newTokenBucket(operation.RateLimit); bucket != nil {
+			t.operations = append(t.operations, apiOperationLimiter{APIOperationRateLimit: operation, bucket: bucket})
+		}
+	}
+
+	return t
+}
+
+// RoundTrip waits till the request is allowed by the rate limits, or till its context is done
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	buckets := []*rate.Limiter{t.bucket}
+	for _, operation := range t.operations {
+		if operation.matches(req) {
+			buckets = append(buckets, operation.bucket)
+		}
+	}
+
+	if wait := reserveTokens(time.Now(), buckets...); wait > 0 {
+		t.log.Debug("throttling request", map[string]interface{}{"host": req.URL.Host, "path": req.URL.Path, "wait": wait.String()})
+		t.metrics.ReportScrapeThrottled(t.provider, req.URL.Hostname(), wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
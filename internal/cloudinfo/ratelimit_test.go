@@ -0,0 +1,94 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitedTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    RateLimitConfig
+		paths     []string
+		throttled int
+	}{
+		{
+			name:      "every request is limited, pages included",
+			config:    RateLimitConfig{RateLimit: RateLimit{Rate: 10, Burst: 2}},
+			paths:     []string{"/products?page=1", "/products?page=2", "/products?page=3", "/products?page=4"},
+			throttled: 2,
+		},
+		{
+			name: "API operation limit",
+			config: RateLimitConfig{APIOperations: []APIOperationRateLimit{
+				{Host: "127.0.0.1", Path: "/prices", RateLimit: RateLimit{Rate: 10, Burst: 1}},
+			}},
+			paths:     []string{"/products", "/products", "/prices", "/prices", "/prices"},
+			throttled: 2,
+		},
+		{
+			name: "other API operation limited",
+			config: RateLimitConfig{APIOperations: []APIOperationRateLimit{
+				{Host: ".amazonaws.com", RateLimit: RateLimit{Rate: 10, Burst: 1}},
+			}},
+			paths:     []string{"/products", "/products", "/products"},
+			throttled: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			reporter := &breakerStateReporter{}
+			client := &http.Client{Transport: NewRateLimitedTransport("dummy", nil, test.config, reporter, cloudinfoLogger)}
+
+			for _, path := range test.paths {
+				resp, err := client.Get(server.URL + path)
+				assert.NoError(t, err)
+				_ = resp.Body.Close()
+			}
+
+			assert.Len(t, reporter.throttled, test.throttled)
+		})
+	}
+}
+
+func TestRateLimitedTransport_ContextDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRateLimitedTransport("dummy", nil, RateLimitConfig{RateLimit: RateLimit{Rate: 0.1}},
+		&breakerStateReporter{}, cloudinfoLogger)}
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// the next token is 10s away, the request gives up waiting with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+}
@@ -16,8 +16,11 @@ package cloudinfo
 
 import (
 	"context"
+	"time"
 
 	"emperror.dev/emperror"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 )
 
 // RegionStore retrieves regions.
@@ -27,6 +30,9 @@ type RegionStore interface {
 
 	// GetZones returns the supported zones within a region.
 	GetZones(provider string, service string, region string) ([]string, error)
+
+	// GetRegionStatus returns the scrape status of a region, false if the region hasn't been scraped yet.
+	GetRegionStatus(provider string, service string, region string) (types.RegionStatus, bool)
 }
 
 // RegionService provides access to regions supported by a service.
@@ -46,6 +52,13 @@ type Region struct {
 	Code string
 	Name string
 
+	// Stale is set if the last scrape of the region failed, the data of the region is from the last successful scrape.
+	Stale bool
+	// LastSuccess is the time of the last successful scrape of the region.
+	LastSuccess *time.Time
+	// SnapshotTime is the time of the snapshot the data of the region was restored from, unset once it's scraped again.
+	SnapshotTime *time.Time
+
 	providerName string
 	serviceName  string
 }
@@ -78,9 +91,14 @@ func (s *RegionService) ListRegions(ctx context.Context, provider string, servic
 	i := 0
 
 	for code, name := range cloudRegions {
+		status, _ := s.store.GetRegionStatus(provider, service, code)
+
 		regions[i] = Region{
 			Code:         code,
 			Name:         name,
+			Stale:        status.Stale,
+			LastSuccess:  status.LastSuccessTime(),
+			SnapshotTime: status.SnapshotTime,
 			providerName: provider,
 			serviceName:  service,
 		}
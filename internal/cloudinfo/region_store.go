@@ -14,18 +14,24 @@
 
 package cloudinfo
 
+import (
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
 // InMemoryRegionStore keeps regions in the memory.
 // Use it in tests or for development/demo purposes.
 type InMemoryRegionStore struct {
-	regions map[string]map[string]map[string]string
-	zones   map[string]map[string]map[string][]string
+	regions  map[string]map[string]map[string]string
+	zones    map[string]map[string]map[string][]string
+	statuses map[string]map[string]map[string]types.RegionStatus
 }
 
 // NewInMemoryRegionStore returns a new InMemoryRegionStore.
 func NewInMemoryRegionStore() *InMemoryRegionStore {
 	return &InMemoryRegionStore{
-		regions: make(map[string]map[string]map[string]string),
-		zones:   make(map[string]map[string]map[string][]string),
+		regions:  make(map[string]map[string]map[string]string),
+		zones:    make(map[string]map[string]map[string][]string),
+		statuses: make(map[string]map[string]map[string]types.RegionStatus),
 	}
 }
 
@@ -36,3 +42,9 @@ func (s *InMemoryRegionStore) GetRegions(provider string, service string) (map[s
 func (s *InMemoryRegionStore) GetZones(provider string, service string, region string) ([]string, error) {
 	return s.zones[provider][service][region], nil
 }
+
+func (s *InMemoryRegionStore) GetRegionStatus(provider string, service string, region string) (types.RegionStatus, bool) {
+	status, ok := s.statuses[provider][service][region]
+
+	return status, ok
+}
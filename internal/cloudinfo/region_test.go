@@ -17,9 +17,12 @@ package cloudinfo
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
 )
 
 func TestRegionService_ListRegions(t *testing.T) {
@@ -28,6 +31,15 @@ func TestRegionService_ListRegions(t *testing.T) {
 		"amazon": {
 			"compute": {
 				"eu-west-1": "EU (Ireland)",
+				"eu-west-2": "EU (London)",
+			},
+		},
+	}
+	lastSuccess := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	store.statuses = map[string]map[string]map[string]types.RegionStatus{
+		"amazon": {
+			"compute": {
+				"eu-west-2": {LastSuccess: lastSuccess, Stale: true},
 			},
 		},
 	}
@@ -37,10 +49,11 @@ func TestRegionService_ListRegions(t *testing.T) {
 	regions, err := serviceService.ListRegions(context.Background(), "amazon", "compute")
 	require.NoError(t, err)
 
-	assert.Equal(
+	assert.ElementsMatch(
 		t,
 		[]Region{
 			{Code: "eu-west-1", Name: "EU (Ireland)", providerName: "amazon", serviceName: "compute"},
+			{Code: "eu-west-2", Name: "EU (London)", Stale: true, LastSuccess: &lastSuccess, providerName: "amazon", serviceName: "compute"},
 		},
 		regions,
 	)
@@ -0,0 +1,400 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/sony/gobreaker"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// resilientCloudInfoer retries the failed calls of the wrapped cloud infoer with exponential backoff
+// the retries of a provider are limited by a budget, so an outage doesn't multiply the load on the cloud provider API
+// and the calls of a region are rejected right away by the circuit breaker of the region after too many consecutive failures
+// the terminal errors (see isTerminal) are neither retried nor counted as failures by the circuit breakers
+// every call, retries included, waits for the rate limits of its operation before reaching it
+// (the API requests sent by the calls are limited by the rate limited transport of the provider)
+// the waits end when the context of the calls is done, see withContext
+type resilientCloudInfoer struct {
+	*resilience
+
+	// ctx the context of the calls, the calls aren't attempted anymore once it's done
+	ctx context.Context
+}
+
+// resilience the retry budget, the rate limits and the circuit breakers of a provider,
+// shared by the resilient cloud infoers bound to different contexts
+type resilience struct {
+	CloudInfoer
+
+	provider string
+	config   RetryConfig
+	budget   *retryBudget
+	limiter  *callLimiter
+	metrics  metrics.Reporter
+	log      Logger
+
+	// breakers the circuit breakers of the regions, the calls not bound to a region (eg. GetRegions) have their own one
+	breakers       map[string]*gobreaker.CircuitBreaker
+	breakerConfig  CircuitBreakerConfig
+	breakerStates  map[string]gobreaker.State
+	breakersMu     sync.Mutex
+	breakerStateMu sync.Mutex
+
+	// sleep waits between the retries and for the rate limits till the context is done, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewResilientCloudInfoer wraps the cloud infoer with retries, a circuit breaker and operation rate limits as configured
+// the cloud infoer is returned as it is if all of them are disabled
+func NewResilientCloudInfoer(provider string, infoer CloudInfoer, config ScrapeConfig, reporter metrics.Reporter, log Logger) CloudInfoer {
+	limiter := newCallLimiter(config.RateLimit)
+	if config.Retry.MaxRetries <= 0 && config.CircuitBreaker.Threshold <= 0 && limiter == nil {
+		return infoer
+	}
+
+	r := &resilience{
+		CloudInfoer: infoer,
+		provider:    provider,
+		config:      config.Retry,
+		budget:      newRetryBudget(config.Retry.BudgetRatio, config.Retry.BudgetBurst),
+		limiter:     limiter,
+		metrics:     reporter,
+		log:         log.WithFields(map[string]interface{}{"component": "resilient-cloud-infoer", "provider": provider}),
+		sleep:       sleepContext,
+	}
+
+	if config.CircuitBreaker.Threshold > 0 {
+		r.breakers = make(map[string]*gobreaker.CircuitBreaker)
+		r.breakerConfig = config.CircuitBreaker
+		r.breakerStates = make(map[string]gobreaker.State)
+		reporter.ReportCircuitBreakerState(provider, circuitBreakerState(gobreaker.StateClosed))
+	}
+
+	return &resilientCloudInfoer{resilience: r, ctx: context.Background()}
+}
+
+// withContext returns the cloud infoer with its calls bound to the context,
+// it shares the retry budget, the rate limits and the circuit breakers with the other contexts
+func (rci *resilientCloudInfoer) withContext(ctx context.Context) CloudInfoer {
+	return &resilientCloudInfoer{resilience: rci.resilience, ctx: ctx}
+}
+
+// sleepContext waits for the duration or till the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// breaker returns the circuit breaker of the region, it's created on the first call of the region
+func (r *resilience) breaker(region string) *gobreaker.CircuitBreaker {
+	r.breakersMu.Lock()
+	defer r.breakersMu.Unlock()
+
+	if breaker, ok := r.breakers[region]; ok {
+		return breaker
+	}
+
+	threshold := uint32(r.breakerConfig.Threshold)
+	breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        region,
+		MaxRequests: 1,
+		Timeout:     r.breakerConfig.Timeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= threshold
+		},
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			r.log.Warn("circuit breaker state changed", map[string]interface{}{"region": name, "from": from.String(), "to": to.String()})
+			r.reportBreakerState(name, to)
+		},
+	})
+	r.breakers[region] = breaker
+
+	return breaker
+}
+
+// reportBreakerState reports the worst state of the circuit breakers of the provider, so a single open region shows up
+func (r *resilience) reportBreakerState(region string, state gobreaker.State) {
+	r.breakerStateMu.Lock()
+	defer r.breakerStateMu.Unlock()
+
+	r.breakerStates[region] = state
+
+	worst := gobreaker.StateClosed
+	for _, state := range r.breakerStates {
+		if circuitBreakerState(state) > circuitBreakerState(worst) {
+			worst = state
+		}
+	}
+
+	r.metrics.ReportCircuitBreakerState(r.provider, circuitBreakerState(worst))
+}
+
+// circuitBreakerState maps the circuit breaker state to its metric value
+func circuitBreakerState(state gobreaker.State) int {
+	switch state {
+	case gobreaker.StateHalfOpen:
+		return 1
+	case gobreaker.StateOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// call executes the operation in the region (empty if the operation is not bound to a region),
+// the failed attempts are retried while the retry budget allows it unless the error is terminal,
+// no attempt is made once the context is done
+func (rci *resilientCloudInfoer) call(operation, region string, fn func() (interface{}, error)) (interface{}, error) {
+	rci.budget.deposit()
+
+	backoff := rci.config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		if err := rci.throttle(operation); err != nil {
+			return nil, errors.WrapIfWithDetails(err, "cloud provider call cancelled", "provider", rci.provider, "operation", operation, "region", region)
+		}
+
+		res, err := rci.execute(region, fn)
+		if err == nil {
+			return res, nil
+		}
+
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return nil, errors.WrapIfWithDetails(err, "cloud provider calls are suspended", "provider", rci.provider, "operation", operation, "region", region)
+		}
+
+		if isTerminal(err) || attempt >= rci.config.MaxRetries || !rci.budget.withdraw() {
+			return nil, err
+		}
+
+		rci.log.Debug("retrying failed call", map[string]interface{}{"operation": operation, "attempt": attempt + 1, "error": err.Error()})
+		rci.metrics.ReportScrapeRetry(rci.provider, operation)
+
+		if err := rci.sleep(rci.ctx, jitter(backoff)); err != nil {
+			return nil, errors.WrapIfWithDetails(err, "cloud provider call cancelled", "provider", rci.provider, "operation", operation, "region", region)
+		}
+		if backoff *= 2; rci.config.MaxBackoff > 0 && backoff > rci.config.MaxBackoff {
+			backoff = rci.config.MaxBackoff
+		}
+	}
+}
+
+// throttle waits till the call of the operation is allowed by the rate limit of the operation,
+// an error is returned if the context is done
+func (rci *resilientCloudInfoer) throttle(operation string) error {
+	if err := rci.ctx.Err(); err != nil || rci.limiter == nil {
+		return err
+	}
+
+	wait := rci.limiter.reserve(operation, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	rci.log.Debug("throttling call", map[string]interface{}{"operation": operation, "wait": wait.String()})
+	rci.metrics.ReportScrapeThrottled(rci.provider, operation, wait)
+
+	return rci.sleep(rci.ctx, wait)
+}
+
+// terminalError carries a terminal error through the circuit breaker, so that it isn't counted as a failure
+type terminalError struct {
+	err error
+}
+
+// execute calls the function through the circuit breaker of the region if there is one
+func (r *resilience) execute(region string, fn func() (interface{}, error)) (interface{}, error) {
+	if r.breakers == nil {
+		return fn()
+	}
+
+	res, err := r.breaker(region).Execute(func() (interface{}, error) {
+		res, err := fn()
+		if err != nil && isTerminal(err) {
+			return terminalError{err: err}, nil
+		}
+
+		return res, err
+	})
+	if terminal, ok := res.(terminalError); ok {
+		return nil, terminal.err
+	}
+
+	return res, err
+}
+
+// jitter randomizes the backoff between its half and its full length, so retries of parallel calls spread out
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+
+	// nolint: gosec
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func (rci *resilientCloudInfoer) Initialize() (map[string]map[string]types.Price, error) {
+	res, err := rci.call("Initialize", "", func() (interface{}, error) {
+		return rci.CloudInfoer.Initialize()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(map[string]map[string]types.Price), nil
+}
+
+func (rci *resilientCloudInfoer) GetVirtualMachines(region string) ([]types.VMInfo, error) {
+	res, err := rci.call("GetVirtualMachines", region, func() (interface{}, error) {
+		return rci.CloudInfoer.GetVirtualMachines(region)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]types.VMInfo), nil
+}
+
+func (rci *resilientCloudInfoer) GetProducts(vms []types.VMInfo, service, regionId string) ([]types.VMInfo, error) {
+	res, err := rci.call("GetProducts", regionId, func() (interface{}, error) {
+		return rci.CloudInfoer.GetProducts(vms, service, regionId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]types.VMInfo), nil
+}
+
+func (rci *resilientCloudInfoer) GetZones(region string) ([]string, error) {
+	res, err := rci.call("GetZones", region, func() (interface{}, error) {
+		return rci.CloudInfoer.GetZones(region)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]string), nil
+}
+
+func (rci *resilientCloudInfoer) GetRegions(service string) (map[string]string, error) {
+	res, err := rci.call("GetRegions", "", func() (interface{}, error) {
+		return rci.CloudInfoer.GetRegions(service)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(map[string]string), nil
+}
+
+func (rci *resilientCloudInfoer) GetCurrentPrices(region string) (map[string]types.Price, error) {
+	res, err := rci.call("GetCurrentPrices", region, func() (interface{}, error) {
+		return rci.CloudInfoer.GetCurrentPrices(region)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(map[string]types.Price), nil
+}
+
+func (rci *resilientCloudInfoer) GetServiceImages(service, region string) ([]types.Image, error) {
+	res, err := rci.call("GetServiceImages", region, func() (interface{}, error) {
+		return rci.CloudInfoer.GetServiceImages(service, region)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]types.Image), nil
+}
+
+func (rci *resilientCloudInfoer) GetVersions(service, region string) ([]types.LocationVersion, error) {
+	res, err := rci.call("GetVersions", region, func() (interface{}, error) {
+		return rci.CloudInfoer.GetVersions(service, region)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]types.LocationVersion), nil
+}
+
+func (rci *resilientCloudInfoer) GetServiceProducts(region, service string) ([]types.ProductDetails, error) {
+	res, err := rci.call("GetServiceProducts", region, func() (interface{}, error) {
+		return rci.CloudInfoer.GetServiceProducts(region, service)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]types.ProductDetails), nil
+}
+
+// retryBudget every call earns a fraction of a retry, retries are allowed while there are whole ones saved up
+type retryBudget struct {
+	tokens float64
+	ratio  float64
+	burst  float64
+	mu     sync.Mutex
+}
+
+func newRetryBudget(ratio float64, burst int) *retryBudget {
+	return &retryBudget{
+		tokens: float64(burst),
+		ratio:  ratio,
+		burst:  float64(burst),
+	}
+}
+
+func (rb *retryBudget) deposit() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.tokens += rb.ratio; rb.tokens > rb.burst {
+		rb.tokens = rb.burst
+	}
+}
+
+func (rb *retryBudget) withdraw() bool {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.tokens < 1 {
+		return false
+	}
+	rb.tokens--
+
+	return true
+}
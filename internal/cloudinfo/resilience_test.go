@@ -0,0 +1,360 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+)
+
+// flakyCloudInfoer fails the given number of zone requests before succeeding
+type flakyCloudInfoer struct {
+	// implement the interface
+	CloudInfoer
+	failures int
+	calls    int
+	// err the error of the failed requests, service unavailable if not set
+	err error
+}
+
+func (fci *flakyCloudInfoer) GetZones(region string) ([]string, error) {
+	fci.calls++
+	if fci.calls <= fci.failures {
+		if fci.err != nil {
+			return nil, fci.err
+		}
+		return nil, errors.New("service unavailable")
+	}
+
+	return []string{region + "a"}, nil
+}
+
+// breakerStateReporter records the reported circuit breaker states, retries and throttled calls
+type breakerStateReporter struct {
+	metrics.Reporter
+	states    []int
+	retries   int
+	throttled []string
+}
+
+func (bsr *breakerStateReporter) ReportCircuitBreakerState(provider string, state int) {
+	bsr.states = append(bsr.states, state)
+}
+
+func (bsr *breakerStateReporter) ReportScrapeRetry(provider, operation string) {
+	bsr.retries++
+}
+
+func (bsr *breakerStateReporter) ReportScrapeThrottled(provider, operation string, wait time.Duration) {
+	bsr.throttled = append(bsr.throttled, operation)
+}
+
+func newTestResilientCloudInfoer(infoer CloudInfoer, config ScrapeConfig, reporter metrics.Reporter) (*resilientCloudInfoer, *[]time.Duration) {
+	var waits []time.Duration
+
+	rci := NewResilientCloudInfoer("dummy", infoer, config, reporter, cloudinfoLogger).(*resilientCloudInfoer)
+	rci.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+
+	return rci, &waits
+}
+
+func TestResilientCloudInfoer_Retry(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RetryConfig
+		checker func(zones []string, err error, calls int, waits []time.Duration)
+	}{
+		{
+			name:   "retried with exponential backoff",
+			config: RetryConfig{MaxRetries: 3, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, BudgetBurst: 10},
+			checker: func(zones []string, err error, calls int, waits []time.Duration) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"eu-west-1a"}, zones)
+				assert.Equal(t, 4, calls)
+				assert.Len(t, waits, 3)
+				for i, max := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+					assert.True(t, waits[i] >= max/2 && waits[i] <= max, "backoff %d out of range: %s", i, waits[i])
+				}
+			},
+		},
+		{
+			name:   "retries limited",
+			config: RetryConfig{MaxRetries: 2, BudgetBurst: 10},
+			checker: func(zones []string, err error, calls int, waits []time.Duration) {
+				assert.EqualError(t, err, "service unavailable")
+				assert.Equal(t, 3, calls)
+			},
+		},
+		{
+			name:   "retries limited by the budget",
+			config: RetryConfig{MaxRetries: 3, BudgetBurst: 1},
+			checker: func(zones []string, err error, calls int, waits []time.Duration) {
+				assert.EqualError(t, err, "service unavailable")
+				assert.Equal(t, 2, calls)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			infoer := &flakyCloudInfoer{failures: 3}
+			rci, waits := newTestResilientCloudInfoer(infoer, ScrapeConfig{Retry: test.config}, metrics.NewNoOpMetricsReporter())
+
+			zones, err := rci.GetZones("eu-west-1")
+			test.checker(zones, err, infoer.calls, *waits)
+		})
+	}
+}
+
+func TestResilientCloudInfoer_CircuitBreaker(t *testing.T) {
+	infoer := &flakyCloudInfoer{failures: 2}
+	reporter := &breakerStateReporter{}
+	rci, _ := newTestResilientCloudInfoer(infoer, ScrapeConfig{
+		Retry:          RetryConfig{MaxRetries: -1},
+		CircuitBreaker: CircuitBreakerConfig{Threshold: 2, Timeout: 50 * time.Millisecond},
+	}, reporter)
+
+	for i := 0; i < 2; i++ {
+		_, err := rci.GetZones("eu-west-1")
+		assert.EqualError(t, err, "service unavailable")
+	}
+
+	// the calls are rejected without reaching the provider while the breaker is open
+	_, err := rci.GetZones("eu-west-1")
+	assert.EqualError(t, err, "cloud provider calls are suspended: circuit breaker is open")
+	assert.Equal(t, 2, infoer.calls)
+
+	time.Sleep(60 * time.Millisecond)
+
+	zones, err := rci.GetZones("eu-west-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1a"}, zones)
+
+	assert.Equal(t, []int{0, 2, 1, 0}, reporter.states)
+	assert.Equal(t, 0, reporter.retries)
+}
+
+func TestResilientCloudInfoer_TerminalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		terminal bool
+	}{
+		{
+			name:     "client error",
+			err:      errors.WrapIf(&googleapi.Error{Code: http.StatusForbidden}, "failed to list zones"),
+			terminal: true,
+		},
+		{
+			name:     "unsupported region",
+			err:      errors.WithDetails(ErrUnsupportedRegion, "region", "eu-west-1"),
+			terminal: true,
+		},
+		{
+			name:     "throttled",
+			err:      &googleapi.Error{Code: http.StatusTooManyRequests},
+			terminal: false,
+		},
+		{
+			name:     "server error",
+			err:      &googleapi.Error{Code: http.StatusServiceUnavailable},
+			terminal: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			infoer := &flakyCloudInfoer{failures: 3, err: test.err}
+			reporter := &breakerStateReporter{}
+			rci, _ := newTestResilientCloudInfoer(infoer, ScrapeConfig{
+				Retry:          RetryConfig{MaxRetries: 1, BudgetBurst: 10},
+				CircuitBreaker: CircuitBreakerConfig{Threshold: 2, Timeout: time.Minute},
+			}, reporter)
+
+			_, err := rci.GetZones("eu-west-1")
+			assert.Equal(t, test.err, err)
+
+			if test.terminal {
+				// neither retried nor counted as a failure by the circuit breaker
+				assert.Equal(t, 1, infoer.calls)
+				assert.Equal(t, []int{0}, reporter.states)
+			} else {
+				assert.Equal(t, 2, infoer.calls)
+				assert.Equal(t, 1, reporter.retries)
+				assert.Equal(t, []int{0, 2}, reporter.states)
+			}
+		})
+	}
+}
+
+func TestResilientCloudInfoer_CircuitBreakerPerRegion(t *testing.T) {
+	infoer := &flakyCloudInfoer{failures: 1}
+	reporter := &breakerStateReporter{}
+	rci, _ := newTestResilientCloudInfoer(infoer, ScrapeConfig{
+		Retry:          RetryConfig{MaxRetries: -1},
+		CircuitBreaker: CircuitBreakerConfig{Threshold: 1, Timeout: time.Minute},
+	}, reporter)
+
+	_, err := rci.GetZones("eu-west-1")
+	assert.EqualError(t, err, "service unavailable")
+
+	// the failures of a region don't suspend the calls of the other regions
+	zones, err := rci.GetZones("eu-central-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu-central-1a"}, zones)
+
+	_, err = rci.GetZones("eu-west-1")
+	assert.EqualError(t, err, "cloud provider calls are suspended: circuit breaker is open")
+
+	// the provider reports the worst state of its regions
+	assert.Equal(t, []int{0, 2}, reporter.states)
+}
+
+func TestNewResilientCloudInfoer_Disabled(t *testing.T) {
+	infoer := &flakyCloudInfoer{}
+
+	assert.Same(t, infoer, NewResilientCloudInfoer("dummy", infoer, ScrapeConfig{
+		Retry:          RetryConfig{MaxRetries: -1},
+		CircuitBreaker: CircuitBreakerConfig{Threshold: -1},
+		RateLimit:      RateLimitConfig{RateLimit: RateLimit{Rate: -1}},
+	}, metrics.NewNoOpMetricsReporter(), cloudinfoLogger))
+}
+
+func TestResilientCloudInfoer_RateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RateLimitConfig
+		checker func(waits []time.Duration, throttled []string)
+	}{
+		{
+			name:   "operation burst",
+			config: RateLimitConfig{Operations: map[string]RateLimit{"GetZones": {Rate: 1, Burst: 2}}},
+			checker: func(waits []time.Duration, throttled []string) {
+				// the burst is let through, the rest waits for the refill of the bucket
+				assert.Len(t, waits, 2)
+				assert.True(t, waits[0] > 0 && waits[0] <= time.Second, "wait out of range: %s", waits[0])
+				assert.True(t, waits[1] > time.Second && waits[1] <= 2*time.Second, "wait out of range: %s", waits[1])
+				assert.Equal(t, []string{"GetZones", "GetZones"}, throttled)
+			},
+		},
+		{
+			name:   "operation limit",
+			config: RateLimitConfig{RateLimit: RateLimit{Rate: 100, Burst: 10}, Operations: map[string]RateLimit{"getzones": {Rate: 1, Burst: 3}}},
+			checker: func(waits []time.Duration, throttled []string) {
+				assert.Len(t, waits, 1)
+				assert.Equal(t, []string{"GetZones"}, throttled)
+			},
+		},
+		{
+			name:   "other operation limited",
+			config: RateLimitConfig{Operations: map[string]RateLimit{"GetCurrentPrices": {Rate: 1}}},
+			checker: func(waits []time.Duration, throttled []string) {
+				assert.Empty(t, waits)
+				assert.Empty(t, throttled)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &breakerStateReporter{}
+			rci, waits := newTestResilientCloudInfoer(&flakyCloudInfoer{}, ScrapeConfig{
+				Retry:          RetryConfig{MaxRetries: -1},
+				CircuitBreaker: CircuitBreakerConfig{Threshold: -1},
+				RateLimit:      test.config,
+			}, reporter)
+
+			for i := 0; i < 4; i++ {
+				_, err := rci.GetZones("eu-west-1")
+				assert.NoError(t, err)
+			}
+
+			test.checker(*waits, reporter.throttled)
+		})
+	}
+}
+
+func TestResilientCloudInfoer_Cancelled(t *testing.T) {
+	config := ScrapeConfig{
+		Retry:          RetryConfig{MaxRetries: 3, InitialBackoff: time.Hour, BudgetBurst: 10},
+		CircuitBreaker: CircuitBreakerConfig{Threshold: -1},
+		RateLimit:      RateLimitConfig{Operations: map[string]RateLimit{"GetZones": {Rate: 1}}},
+	}
+
+	t.Run("not attempted", func(t *testing.T) {
+		infoer := &flakyCloudInfoer{}
+		rci, _ := newTestResilientCloudInfoer(infoer, config, &breakerStateReporter{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := rci.withContext(ctx).GetZones("eu-west-1")
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, 0, infoer.calls)
+	})
+
+	t.Run("retry backoff", func(t *testing.T) {
+		infoer := &flakyCloudInfoer{failures: 3}
+		rci, _ := newTestResilientCloudInfoer(infoer, config, &breakerStateReporter{})
+		rci.sleep = sleepContext
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := rci.withContext(ctx).GetZones("eu-west-1")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, 1, infoer.calls)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		infoer := &flakyCloudInfoer{}
+		rci, _ := newTestResilientCloudInfoer(infoer, config, &breakerStateReporter{})
+		rci.sleep = sleepContext
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		// the burst of 1 is used up by the first call, the second one waits a second for the refill of the bucket
+		_, err := rci.withContext(ctx).GetZones("eu-west-1")
+		assert.NoError(t, err)
+
+		_, err = rci.withContext(ctx).GetZones("eu-west-1")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, 1, infoer.calls)
+	})
+
+	t.Run("other contexts", func(t *testing.T) {
+		infoer := &flakyCloudInfoer{}
+		rci, _ := newTestResilientCloudInfoer(infoer, config, &breakerStateReporter{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := rci.withContext(ctx).GetZones("eu-west-1")
+		assert.Error(t, err)
+
+		zones, err := rci.GetZones("eu-west-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"eu-west-1a"}, zones)
+	})
+}
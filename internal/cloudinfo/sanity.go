@@ -0,0 +1,233 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// errScrapeRejected is returned if a scrape result fails the sanity checks
+const errScrapeRejected = errors.Sentinel("scrape result failed the sanity checks")
+
+const (
+	// sanityReasonInstanceDrop the number of instance types dropped beyond the threshold
+	sanityReasonInstanceDrop = "instance_drop"
+	// sanityReasonPriceChange an on demand price changed beyond the threshold
+	sanityReasonPriceChange = "price_change"
+	// sanityReasonMissingCategory an instance type category disappeared
+	sanityReasonMissingCategory = "missing_category"
+)
+
+// sanityViolation a failed sanity check of a scrape result
+type sanityViolation struct {
+	reason  string
+	message string
+}
+
+// checkSanity compares the scraped instance types of a region with the stored ones, it returns the failed checks
+// there's nothing to compare to when the region hasn't been scraped yet, the checks without a threshold are skipped
+func checkSanity(config SanityConfig, stored, scraped []types.VMInfo) []sanityViolation {
+	if len(stored) == 0 {
+		return nil
+	}
+
+	var violations []sanityViolation
+
+	if config.MaxInstanceDrop != nil && *config.MaxInstanceDrop >= 0 {
+		drop := float64(len(stored)-len(scraped)) / float64(len(stored)) * 100
+		if drop > *config.MaxInstanceDrop {
+			violations = append(violations, sanityViolation{
+				reason:  sanityReasonInstanceDrop,
+				message: fmt.Sprintf("number of instance types dropped from %d to %d", len(stored), len(scraped)),
+			})
+		}
+	}
+
+	if config.MaxPriceChange != nil && *config.MaxPriceChange >= 0 {
+		storedPrices := make(map[string]float64, len(stored))
+		for _, vm := range stored {
+			storedPrices[vm.Type] = vm.OnDemandPrice
+		}
+
+		var changed []string
+		for _, vm := range scraped {
+			price, ok := storedPrices[vm.Type]
+			if !ok || price <= 0 || vm.OnDemandPrice <= 0 {
+				continue
+			}
+
+			if math.Abs(vm.OnDemandPrice-price)/price*100 > *config.MaxPriceChange {
+				changed = append(changed, vm.Type)
+			}
+		}
+
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			violations = append(violations, sanityViolation{
+				reason:  sanityReasonPriceChange,
+				message: fmt.Sprintf("on demand price of %v changed by more than %v%%", changed, *config.MaxPriceChange),
+			})
+		}
+	}
+
+	if !config.AllowMissingCategories {
+		scrapedCategories := make(map[string]bool)
+		for _, vm := range scraped {
+			scrapedCategories[vm.Category] = true
+		}
+
+		missing := make(map[string]bool)
+		for _, vm := range stored {
+			if vm.Category != "" && !scrapedCategories[vm.Category] {
+				missing[vm.Category] = true
+			}
+		}
+
+		if len(missing) > 0 {
+			categories := make([]string, 0, len(missing))
+			for category := range missing {
+				categories = append(categories, category)
+			}
+			sort.Strings(categories)
+
+			violations = append(violations, sanityViolation{
+				reason:  sanityReasonMissingCategory,
+				message: fmt.Sprintf("instance type categories %v are missing", categories),
+			})
+		}
+	}
+
+	return violations
+}
+
+// checkScrapeSanity checks the scraped instance types of the service in the region against the stored ones
+// and takes the configured action on failure, an error is returned if the scrape result must not be stored
+// the result accepted by the operator is stored regardless of the checks
+func (sm *scrapingManager) checkScrapeSanity(service, regionId string, vms []types.VMInfo) error {
+	stored, _ := sm.store.GetVm(sm.provider, service, regionId)
+
+	accepted := sm.takeAccepted(service, regionId)
+
+	violations := checkSanity(sm.config.Sanity, stored, vms)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		reasons = append(reasons, violation.message)
+	}
+
+	if accepted || sm.config.Sanity.Action == SanityActionWarn {
+		for _, violation := range violations {
+			sm.metrics.ReportScrapeWarned(sm.provider, service, regionId, violation.reason)
+		}
+
+		msg := "scrape result failed the sanity checks, storing it anyway"
+		if accepted {
+			msg = "scrape result failed the sanity checks, storing it as accepted"
+		}
+		sm.log.Warn(msg, map[string]interface{}{"service": service, "region": regionId, "reasons": reasons})
+
+		return nil
+	}
+
+	for _, violation := range violations {
+		sm.metrics.ReportScrapeRejected(sm.provider, service, regionId, violation.reason)
+	}
+
+	if sm.config.Sanity.Action == SanityActionQuarantine {
+		sm.store.StoreQuarantine(sm.provider, service, regionId, types.QuarantinedScrape{Time: time.Now(), Reasons: reasons, Vms: vms})
+	}
+
+	sm.publish(messaging.ScrapeRejected, service, regionId, messaging.ScrapeRejectedPayload{Reasons: reasons})
+
+	return errors.WithDetails(errScrapeRejected, "reasons", reasons, "action", sm.config.Sanity.Action)
+}
+
+// acceptNext makes the next scrape result of the service in the region stored even if it fails the sanity checks
+func (sm *scrapingManager) acceptNext(service, regionId string) {
+	sm.acceptedMu.Lock()
+	defer sm.acceptedMu.Unlock()
+
+	sm.accepted[fmt.Sprintf("%s/%s", service, regionId)] = true
+}
+
+// takeAccepted returns true if the scrape result of the service in the region was accepted, the acceptance is used up
+func (sm *scrapingManager) takeAccepted(service, regionId string) bool {
+	sm.acceptedMu.Lock()
+	defer sm.acceptedMu.Unlock()
+
+	key := fmt.Sprintf("%s/%s", service, regionId)
+	accepted := sm.accepted[key]
+	delete(sm.accepted, key)
+
+	return accepted
+}
+
+// promoteQuarantine stores the quarantined instance types of the service in the region as a new generation of the region data
+// the quarantined result is promoted once, and only if no later scrape of the region succeeded
+func (sm *scrapingManager) promoteQuarantine(service, regionId string) error {
+	quarantined, ok := sm.store.GetQuarantine(sm.provider, service, regionId)
+	if !ok {
+		return errors.NewWithDetails("no quarantined scrape result", "provider", sm.provider, "service", service, "region", regionId)
+	}
+
+	if quarantined.PromotedAt != nil {
+		return errors.NewWithDetails("quarantined scrape result is already promoted",
+			"provider", sm.provider, "service", service, "region", regionId, "promotedAt", *quarantined.PromotedAt)
+	}
+
+	status, _ := sm.store.GetRegionStatus(sm.provider, service, regionId)
+	if status.LastSuccess.After(quarantined.Time) {
+		return errors.NewWithDetails("quarantined scrape result is older than the stored data",
+			"provider", sm.provider, "service", service, "region", regionId, "time", quarantined.Time)
+	}
+
+	zones, _ := sm.store.GetZones(sm.provider, service, regionId)
+	versions, _ := sm.store.GetVersion(sm.provider, service, regionId)
+	prices, _ := sm.store.GetPrices(sm.provider, regionId, instanceTypes(quarantined.Vms))
+
+	data := RegionData{
+		Generation:     time.Now().UnixNano() / 1e6,
+		Zones:          zones,
+		Vms:            quarantined.Vms,
+		ProductDetails: mergeProductDetails(quarantined.Vms, prices, sm.log),
+		Versions:       versions,
+		Status:         types.RegionStatus{LastSuccess: quarantined.Time},
+	}
+
+	sm.log.Info("promoting quarantined scrape result", map[string]interface{}{"service": service, "region": regionId, "reasons": quarantined.Reasons})
+
+	changes := sm.regionChanges(service, regionId, data)
+	if err := sm.store.StoreRegionData(sm.provider, service, regionId, data); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to promote quarantined scrape result", "provider", sm.provider, "service", service, "region", regionId)
+	}
+	sm.publishEvents(changes)
+
+	promotedAt := time.Now()
+	quarantined.PromotedAt = &promotedAt
+	sm.store.StoreQuarantine(sm.provider, service, regionId, quarantined)
+
+	return nil
+}
@@ -0,0 +1,306 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/emperror"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// percent returns a pointer to the threshold
+func percent(value float64) *float64 {
+	return &value
+}
+
+func TestCheckSanity(t *testing.T) {
+	config := SanityConfig{Action: SanityActionReject, MaxInstanceDrop: percent(50), MaxPriceChange: percent(90)}
+	stored := []types.VMInfo{
+		{Type: "m5.large", Category: "General purpose", OnDemandPrice: 0.1},
+		{Type: "c5.large", Category: "Compute optimized", OnDemandPrice: 0.1},
+		{Type: "r5.large", Category: "Memory optimized", OnDemandPrice: 0.1},
+	}
+
+	tests := []struct {
+		name    string
+		config  SanityConfig
+		stored  []types.VMInfo
+		scraped []types.VMInfo
+		reasons []string
+	}{
+		{
+			name:    "nothing stored yet",
+			config:  config,
+			scraped: []types.VMInfo{{Type: "m5.large"}},
+		},
+		{
+			name:    "unchanged",
+			config:  config,
+			stored:  stored,
+			scraped: stored,
+		},
+		{
+			name:   "instance types dropped",
+			config: SanityConfig{MaxInstanceDrop: percent(50), MaxPriceChange: percent(-1), AllowMissingCategories: true},
+			stored: stored,
+			scraped: []types.VMInfo{
+				{Type: "m5.large", Category: "General purpose", OnDemandPrice: 0.1},
+			},
+			reasons: []string{sanityReasonInstanceDrop},
+		},
+		{
+			name:   "price changed",
+			config: config,
+			stored: stored,
+			scraped: []types.VMInfo{
+				{Type: "m5.large", Category: "General purpose", OnDemandPrice: 1},
+				{Type: "c5.large", Category: "Compute optimized", OnDemandPrice: 0.15},
+				{Type: "r5.large", Category: "Memory optimized", OnDemandPrice: 0.01},
+			},
+			reasons: []string{sanityReasonPriceChange},
+		},
+		{
+			name:   "category missing",
+			config: config,
+			stored: stored,
+			scraped: []types.VMInfo{
+				{Type: "m5.large", Category: "General purpose", OnDemandPrice: 0.1},
+				{Type: "c5.large", Category: "Compute optimized", OnDemandPrice: 0.1},
+				{Type: "m5.xlarge", Category: "General purpose", OnDemandPrice: 0.2},
+			},
+			reasons: []string{sanityReasonMissingCategory},
+		},
+		{
+			name:   "no change accepted",
+			config: SanityConfig{MaxInstanceDrop: percent(0), MaxPriceChange: percent(0), AllowMissingCategories: true},
+			stored: stored,
+			scraped: []types.VMInfo{
+				{Type: "m5.large", Category: "General purpose", OnDemandPrice: 0.1},
+				{Type: "c5.large", Category: "Compute optimized", OnDemandPrice: 0.11},
+			},
+			reasons: []string{sanityReasonInstanceDrop, sanityReasonPriceChange},
+		},
+		{
+			name:    "checks disabled",
+			config:  SanityConfig{MaxInstanceDrop: percent(-1), MaxPriceChange: percent(-1), AllowMissingCategories: true},
+			stored:  stored,
+			scraped: []types.VMInfo{{Type: "m5.large", OnDemandPrice: 10}},
+		},
+		{
+			name:    "thresholds unset",
+			config:  SanityConfig{AllowMissingCategories: true},
+			stored:  stored,
+			scraped: []types.VMInfo{{Type: "m5.large", OnDemandPrice: 10}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var reasons []string
+			for _, violation := range checkSanity(test.config, test.stored, test.scraped) {
+				reasons = append(reasons, violation.reason)
+			}
+
+			assert.Equal(t, test.reasons, reasons)
+		})
+	}
+}
+
+// rejectedScrapes records the regions of the published "scrape rejected" events
+type rejectedScrapes struct {
+	// implement the interface
+	messaging.EventBus
+	rejected []string
+}
+
+func (rs *rejectedScrapes) Publish(event messaging.Event) {
+	if event.Type == messaging.ScrapeRejected {
+		rs.rejected = append(rs.rejected, event.Region)
+	}
+}
+
+// sanityReporter records the reasons of the rejected and the warned scrape results
+type sanityReporter struct {
+	metrics.Reporter
+	rejected []string
+	warned   []string
+}
+
+func (sr *sanityReporter) ReportScrapeRejected(provider, service, region, reason string) {
+	sr.rejected = append(sr.rejected, reason)
+}
+
+func (sr *sanityReporter) ReportScrapeWarned(provider, service, region, reason string) {
+	sr.warned = append(sr.warned, reason)
+}
+
+// quarantineStore records the quarantined scrape results
+type quarantineStore struct {
+	regionDataStore
+	quarantined map[string]types.QuarantinedScrape
+}
+
+func (qs *quarantineStore) StoreQuarantine(provider, service, region string, val types.QuarantinedScrape) {
+	qs.quarantined[region] = val
+}
+
+func (qs *quarantineStore) GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool) {
+	val, ok := qs.quarantined[region]
+	return val, ok
+}
+
+func (qs *quarantineStore) GetZones(provider, service, region string) ([]string, bool) {
+	return []string{region + "a"}, true
+}
+
+func newQuarantineStore() *quarantineStore {
+	return &quarantineStore{
+		regionDataStore: regionDataStore{
+			regions:  make(map[string]RegionData),
+			statuses: make(map[string]types.RegionStatus),
+			vms:      []types.VMInfo{{Type: "m5.large"}, {Type: "m5.xlarge"}, {Type: "m5.2xlarge"}, {Type: "m5.4xlarge"}},
+		},
+		quarantined: make(map[string]types.QuarantinedScrape),
+	}
+}
+
+func newSanityScrapingManager(store CloudInfoStore, action string, eventBus messaging.EventBus) *scrapingManager {
+	config := ScrapeConfig{
+		Concurrency: 2,
+		Sanity:      SanityConfig{Action: action, MaxInstanceDrop: percent(25), MaxPriceChange: percent(-1), AllowMissingCategories: true},
+	}
+
+	return NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, config, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), eventBus, emperror.NoopHandler{})
+}
+
+func TestScrapingManager_scrapeServiceRegion_Sanity(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		checker func(store *quarantineStore, eventBus *rejectedScrapes, reporter *sanityReporter, err error)
+	}{
+		{
+			name:   "rejected",
+			action: SanityActionReject,
+			checker: func(store *quarantineStore, eventBus *rejectedScrapes, reporter *sanityReporter, err error) {
+				assert.EqualError(t, err, "scrape result failed the sanity checks")
+				assert.Empty(t, store.regions)
+				assert.Empty(t, store.quarantined)
+				assert.Equal(t, []string{"eu-west-1"}, eventBus.rejected)
+				assert.Equal(t, []string{"instance_drop"}, reporter.rejected)
+				assert.Empty(t, reporter.warned)
+			},
+		},
+		{
+			name:   "quarantined",
+			action: SanityActionQuarantine,
+			checker: func(store *quarantineStore, eventBus *rejectedScrapes, reporter *sanityReporter, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, store.regions)
+				assert.Equal(t, []string{"number of instance types dropped from 4 to 1"}, store.quarantined["eu-west-1"].Reasons)
+				assert.Len(t, store.quarantined["eu-west-1"].Vms, 1)
+				assert.Equal(t, []string{"eu-west-1"}, eventBus.rejected)
+				assert.Equal(t, []string{"instance_drop"}, reporter.rejected)
+			},
+		},
+		{
+			name:   "stored with a warning",
+			action: SanityActionWarn,
+			checker: func(store *quarantineStore, eventBus *rejectedScrapes, reporter *sanityReporter, err error) {
+				assert.NoError(t, err)
+				assert.Contains(t, store.regions, "eu-west-1")
+				assert.Empty(t, eventBus.rejected)
+				assert.Empty(t, reporter.rejected, "the stored result is not counted as rejected")
+				assert.Equal(t, []string{"instance_drop"}, reporter.warned)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newQuarantineStore()
+			eventBus := &rejectedScrapes{}
+			reporter := &sanityReporter{}
+			sm := newSanityScrapingManager(store, test.action, eventBus)
+			sm.metrics = reporter
+
+			_, err := sm.scrapeServiceRegion(context.Background(), "compute", "eu-west-1", 42)
+			test.checker(store, eventBus, reporter, err)
+		})
+	}
+}
+
+func TestScrapingManager_scrapeServiceRegion_Accepted(t *testing.T) {
+	store := newQuarantineStore()
+	eventBus := &rejectedScrapes{}
+	reporter := &sanityReporter{}
+	sm := newSanityScrapingManager(store, SanityActionReject, eventBus)
+	sm.metrics = reporter
+
+	// the accepted result is stored despite the failed checks, the acceptance is used up by the scrape
+	sm.acceptNext("compute", "eu-west-1")
+
+	_, err := sm.scrapeServiceRegion(context.Background(), "compute", "eu-west-1", 42)
+	assert.NoError(t, err)
+	assert.Contains(t, store.regions, "eu-west-1")
+	assert.Empty(t, eventBus.rejected)
+	assert.Empty(t, reporter.rejected)
+	assert.Equal(t, []string{"instance_drop"}, reporter.warned)
+
+	_, err = sm.scrapeServiceRegion(context.Background(), "compute", "eu-west-1", 43)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"eu-west-1"}, eventBus.rejected)
+	assert.Equal(t, []string{"instance_drop"}, reporter.rejected)
+}
+
+func TestScrapingManager_promoteQuarantine(t *testing.T) {
+	store := newQuarantineStore()
+	sm := newSanityScrapingManager(store, SanityActionQuarantine, &rejectedScrapes{})
+
+	assert.Error(t, sm.promoteQuarantine("compute", "eu-west-1"))
+
+	_, err := sm.scrapeServiceRegion(context.Background(), "compute", "eu-west-1", 42)
+	assert.Error(t, err)
+	assert.Empty(t, store.regions)
+
+	assert.NoError(t, sm.promoteQuarantine("compute", "eu-west-1"))
+
+	data := store.regions["eu-west-1"]
+	assert.Equal(t, []types.VMInfo{{Type: "m5.large", OnDemandPrice: 0.1}}, data.Vms)
+	assert.Equal(t, []string{"eu-west-1a"}, data.Zones)
+	assert.Len(t, data.ProductDetails, 1)
+	assert.Equal(t, store.quarantined["eu-west-1"].Time, data.Status.LastSuccess)
+	assert.NotNil(t, store.quarantined["eu-west-1"].PromotedAt)
+
+	// the result is promoted only once
+	assert.Error(t, sm.promoteQuarantine("compute", "eu-west-1"))
+}
+
+func TestScrapingManager_scrapeServiceRegionData_Sanity(t *testing.T) {
+	store := newQuarantineStore()
+	eventBus := &rejectedScrapes{}
+	sm := newSanityScrapingManager(store, SanityActionReject, eventBus)
+
+	// the instance types of the static services are checked as well
+	err := sm.scrapeServiceRegionData(context.Background(), "compute", "eu-west-1", types.VmsData, &RegionData{})
+	assert.EqualError(t, err, "scrape result failed the sanity checks")
+	assert.Equal(t, []string{"eu-west-1"}, eventBus.rejected)
+}
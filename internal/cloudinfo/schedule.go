@@ -0,0 +1,173 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/robfig/cron/v3"
+)
+
+// parseSchedule parses a cron expression or descriptor
+func parseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "invalid scrape schedule", "schedule", spec)
+	}
+
+	return schedule, nil
+}
+
+// quietWindow a time range of the day, the end is before the start if the window spans midnight
+type quietWindow struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// parseQuietWindows parses time ranges of the day in the "15:04-15:04" format
+func parseQuietWindows(windows []string) ([]quietWindow, error) {
+	parsed := make([]quietWindow, 0, len(windows))
+	for _, window := range windows {
+		var startHour, startMinute, endHour, endMinute int
+		if _, err := fmt.Sscanf(window, "%d:%d-%d:%d", &startHour, &startMinute, &endHour, &endMinute); err != nil ||
+			startHour > 23 || endHour > 23 || startMinute > 59 || endMinute > 59 ||
+			startHour < 0 || endHour < 0 || startMinute < 0 || endMinute < 0 {
+			return nil, errors.NewWithDetails("invalid quiet window", "window", window)
+		}
+
+		parsed = append(parsed, quietWindow{
+			start: time.Duration(startHour)*time.Hour + time.Duration(startMinute)*time.Minute,
+			end:   time.Duration(endHour)*time.Hour + time.Duration(endMinute)*time.Minute,
+		})
+	}
+
+	return parsed, nil
+}
+
+// endOf returns the end of the window if the time falls into it
+func (w quietWindow) endOf(t time.Time) (time.Time, bool) {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	sinceMidnight := t.Sub(midnight)
+
+	switch {
+	case w.start <= w.end && sinceMidnight >= w.start && sinceMidnight < w.end:
+		return midnight.Add(w.end), true
+	case w.start > w.end && sinceMidnight >= w.start:
+		return midnight.AddDate(0, 0, 1).Add(w.end), true
+	case w.start > w.end && sinceMidnight < w.end:
+		return midnight.Add(w.end), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// ScheduledExecutor Executor that executes the passed in task function according to a cron schedule
+type ScheduledExecutor struct {
+	schedule     cron.Schedule
+	jitter       time.Duration
+	quietWindows []quietWindow
+	log          Logger
+
+	// random returns a random duration in [0, n), replaced in tests
+	random func(n int64) int64
+}
+
+// Execute executes the task function right away and then according to the schedule in a new goroutine
+// For tasks that need to be periodically executed within a defined deadline, the appropriate context needs to be passed in
+func (se *ScheduledExecutor) Execute(ctx context.Context, sf TaskFn) error {
+	go func(c context.Context) {
+		sf(c)
+		se.run(c, sf)
+	}(ctx)
+
+	return nil
+}
+
+// Schedule executes the task function according to the schedule in a new goroutine, without executing it right away
+func (se *ScheduledExecutor) Schedule(ctx context.Context, sf TaskFn) error {
+	go se.run(ctx, sf)
+
+	return nil
+}
+
+// run executes the task function at the scheduled times until the context is done
+func (se *ScheduledExecutor) run(ctx context.Context, sf TaskFn) {
+	for {
+		next := se.next(time.Now())
+		se.log.Debug("scheduled next execution", map[string]interface{}{"next": next})
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			sf(ctx)
+		case <-ctx.Done():
+			se.log.Debug("stopping scheduled execution")
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// next returns the time of the next execution after the given time
+// the jitter is added to the scheduled time, the executions falling into a quiet window are postponed to its end
+func (se *ScheduledExecutor) next(after time.Time) time.Time {
+	next := se.schedule.Next(after.UTC())
+	if se.jitter > 0 {
+		next = next.Add(time.Duration(se.random(int64(se.jitter))))
+	}
+
+	// the windows may overlap, at most every window is left once
+	for i := 0; i <= len(se.quietWindows); i++ {
+		postponed := false
+		for _, window := range se.quietWindows {
+			if end, ok := window.endOf(next); ok {
+				next = end
+				postponed = true
+			}
+		}
+
+		if !postponed {
+			break
+		}
+	}
+
+	return next
+}
+
+// NewScheduledExecutor creates a new Executor with the given schedule, jitter and quiet windows
+func NewScheduledExecutor(config ScheduleConfig, spec string, log Logger) (*ScheduledExecutor, error) {
+	schedule, err := parseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	quietWindows, err := parseQuietWindows(config.QuietWindows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScheduledExecutor{
+		schedule:     schedule,
+		jitter:       config.Jitter,
+		quietWindows: quietWindows,
+		log:          log,
+		random:       rand.Int63n,
+	}, nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuietWindows(t *testing.T) {
+	windows, err := parseQuietWindows([]string{"08:00-18:30", "22:00-06:00"})
+	assert.NoError(t, err)
+	assert.Equal(t, []quietWindow{
+		{start: 8 * time.Hour, end: 18*time.Hour + 30*time.Minute},
+		{start: 22 * time.Hour, end: 6 * time.Hour},
+	}, windows)
+
+	for _, window := range []string{"8-18", "08:00-24:00", "08:60-18:00", "daytime"} {
+		_, err := parseQuietWindows([]string{window})
+		assert.Error(t, err, window)
+	}
+}
+
+func TestQuietWindow_endOf(t *testing.T) {
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		window quietWindow
+		time   time.Time
+		end    time.Time
+		inside bool
+	}{
+		{name: "inside", window: quietWindow{start: 8 * time.Hour, end: 18 * time.Hour}, time: day.Add(9 * time.Hour), end: day.Add(18 * time.Hour), inside: true},
+		{name: "at the end", window: quietWindow{start: 8 * time.Hour, end: 18 * time.Hour}, time: day.Add(18 * time.Hour)},
+		{name: "before midnight", window: quietWindow{start: 22 * time.Hour, end: 6 * time.Hour}, time: day.Add(23 * time.Hour), end: day.Add(30 * time.Hour), inside: true},
+		{name: "after midnight", window: quietWindow{start: 22 * time.Hour, end: 6 * time.Hour}, time: day.Add(2 * time.Hour), end: day.Add(6 * time.Hour), inside: true},
+		{name: "outside across midnight", window: quietWindow{start: 22 * time.Hour, end: 6 * time.Hour}, time: day.Add(12 * time.Hour)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			end, inside := test.window.endOf(test.time)
+			assert.Equal(t, test.inside, inside)
+			assert.Equal(t, test.end, end)
+		})
+	}
+}
+
+func TestScheduledExecutor_next(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		config ScheduleConfig
+		spec   string
+		next   time.Time
+	}{
+		{
+			name: "cron expression",
+			spec: "0 2 * * *",
+			next: time.Date(2021, 3, 2, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "jitter",
+			config: ScheduleConfig{Jitter: time.Hour},
+			spec:   "@hourly",
+			next:   time.Date(2021, 3, 1, 11, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "postponed by quiet windows",
+			config: ScheduleConfig{QuietWindows: []string{"10:00-12:00", "11:30-13:00"}},
+			spec:   "@every 1h",
+			next:   time.Date(2021, 3, 1, 13, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor, err := NewScheduledExecutor(test.config, test.spec, cloudinfoLogger)
+			assert.NoError(t, err)
+
+			executor.random = func(n int64) int64 {
+				return n / 2
+			}
+
+			assert.Equal(t, test.next, executor.next(now))
+		})
+	}
+}
+
+func TestScheduleConfig_Validate(t *testing.T) {
+	assert.NoError(t, ScheduleConfig{Cron: "0 2 * * *", Prices: "@every 4m", Services: map[string]string{"eks": "@daily"}}.Validate())
+	assert.Error(t, ScheduleConfig{Services: map[string]string{"eks": "daily"}}.Validate())
+	assert.Error(t, ScheduleConfig{QuietWindows: []string{"night"}}.Validate())
+}
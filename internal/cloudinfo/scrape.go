@@ -17,11 +17,13 @@ package cloudinfo
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strconv"
 	"sync"
 	"time"
 
 	"emperror.dev/errors"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
 	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
@@ -36,35 +38,73 @@ type scrapingManager struct {
 	provider     string
 	infoer       CloudInfoer
 	store        CloudInfoStore
+	config       ScrapeConfig
 	metrics      metrics.Reporter
 	tracer       tracing.Tracer
 	log          Logger
 	eventBus     messaging.EventBus
 	errorHandler ErrorHandler
+
+	// priceHistory is optional, the price changes are recorded if set
+	priceHistory PriceHistoryStore
+	// recordedPrices the last recorded prices per region and instance type
+	recordedPrices map[string]map[string]types.Price
+	pricesMu       sync.Mutex
+
+	// runsMu guards the updates of the recorded scrape runs
+	runsMu sync.Mutex
+
+	// accepted the regions (<service>/<region>) whose next scrape result is stored even if it fails the sanity checks
+	accepted   map[string]bool
+	acceptedMu sync.Mutex
+}
+
+// contextBinder is implemented by the cloud infoers whose calls can be bound to a context
+type contextBinder interface {
+	withContext(ctx context.Context) CloudInfoer
 }
 
-func (sm *scrapingManager) initialize(ctx context.Context) {
+// infoerFor returns the cloud infoer with its calls bound to the context of the scrape if it supports it,
+// so the waits for its retries and rate limits end when the scrape is cancelled
+func (sm *scrapingManager) infoerFor(ctx context.Context) CloudInfoer {
+	if binder, ok := sm.infoer.(contextBinder); ok {
+		return binder.withContext(ctx)
+	}
+
+	return sm.infoer
+}
+
+// initialize stores the prices the cloud infoer initializes
+func (sm *scrapingManager) initialize(ctx context.Context) error {
 	ctx, _ = sm.tracer.StartWithTags(ctx, "initialize", map[string]interface{}{"provider": sm.provider})
 	defer sm.tracer.EndSpan(ctx)
 
 	sm.log.Info("initializing cloud product information")
-	prices, err := sm.infoer.Initialize()
+	prices, err := sm.infoerFor(ctx).Initialize()
 	if err != nil {
 		sm.log.Error("failed to initialize cloud product information")
-		sm.errorHandler.Handle(err)
-		return
+		sm.publishFailure("", "", err)
+		return errors.WrapIfWithDetails(err, "failed to initialize cloud product information", "provider", sm.provider)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return errors.WrapIfWithDetails(err, "initializing cloud product information cancelled", "provider", sm.provider)
 	}
 
 	for region, ap := range prices {
+		sm.publishPriceChanges(region, ap)
 		for instType, p := range ap {
 			sm.store.StorePrice(sm.provider, region, instType, p)
 			metrics.OnDemandPriceGauge.WithLabelValues(sm.provider, region, instType).Set(p.OnDemandPrice)
 		}
+		sm.recordPrices(region, ap)
 	}
 	sm.log.Info("finished initializing cloud product information")
+
+	return nil
 }
 
-func (sm *scrapingManager) scrapeServiceRegionProducts(ctx context.Context, service string, regionId string) error {
+func (sm *scrapingManager) scrapeServiceRegionProducts(ctx context.Context, service string, regionId string) ([]types.VMInfo, []types.ProductDetails, error) {
 	logger := log.WithFields(sm.log, map[string]interface{}{"service": service, "region": regionId})
 
 	logger.Debug("retrieving regional product information")
@@ -73,9 +113,9 @@ func (sm *scrapingManager) scrapeServiceRegionProducts(ctx context.Context, serv
 		logger.Debug("VMs not yet cached, proceeding to scraping them...")
 	}
 
-	values, err := sm.infoer.GetProducts(vms, service, regionId)
+	values, err := sm.infoerFor(ctx).GetProducts(vms, service, regionId)
 	if err != nil {
-		return errors.Wrap(err, "failed to retrieve products for region")
+		return nil, nil, errors.Wrap(err, "failed to retrieve products for region")
 	}
 
 	for _, vm := range values {
@@ -84,65 +124,108 @@ func (sm *scrapingManager) scrapeServiceRegionProducts(ctx context.Context, serv
 		}
 	}
 
-	sm.store.StoreVm(sm.provider, service, regionId, values)
+	virtualMachines, prices := sm.updateVirtualMachines(regionId, values, vms)
+
+	return virtualMachines, mergeProductDetails(virtualMachines, prices, sm.log), nil
+}
+
+func (sm *scrapingManager) scrapeServiceRegionImages(ctx context.Context, service string, regionId string) ([]types.Image, error) {
+	if !sm.infoer.HasImages() {
+		return nil, nil
+	}
 
-	err = sm.updateVirtualMachines(service, regionId)
+	sm.log.Debug("retrieving regional image information", map[string]interface{}{"service": service, "region": regionId})
+	images, err := sm.infoerFor(ctx).GetServiceImages(service, regionId)
 	if err != nil {
-		return err
+		return nil, errors.WrapIff(err, "failed to retrieve service images for region")
 	}
 
-	return nil
-}
+	if images == nil {
+		images = make([]types.Image, 0)
+	}
 
-func (sm *scrapingManager) scrapeServiceRegionImages(ctx context.Context, service string, regionId string) error {
-	if sm.infoer.HasImages() {
-		sm.log.Debug("retrieving regional image information", map[string]interface{}{"service": service, "region": regionId})
-		images, err := sm.infoer.GetServiceImages(service, regionId)
-		if err != nil {
-			return errors.WrapIff(err, "failed to retrieve service images for region")
-		}
+	return images, nil
+}
 
-		sm.store.DeleteImage(sm.provider, service, regionId)
-		sm.store.StoreImage(sm.provider, service, regionId, images)
+func (sm *scrapingManager) scrapeServiceRegionVersions(ctx context.Context, service string, regionId string) ([]types.LocationVersion, error) {
+	versions, err := sm.infoerFor(ctx).GetVersions(service, regionId)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to retrieve service versions for region")
 	}
-	return nil
+
+	return versions, nil
 }
 
-func (sm *scrapingManager) scrapeServiceRegionVersions(ctx context.Context, service string, regionId string) error {
-	versions, err := sm.infoer.GetVersions(service, regionId)
+func (sm *scrapingManager) scrapeServiceRegionZones(ctx context.Context, service, region string) ([]string, error) {
+	zones, err := sm.infoerFor(ctx).GetZones(region)
 	if err != nil {
-		return errors.WrapIf(err, "failed to retrieve service versions for region")
+		return nil, errors.WrapIf(err, "failed to retrieve zones for region")
 	}
 
-	sm.store.DeleteVersion(sm.provider, service, regionId)
-	sm.store.StoreVersion(sm.provider, service, regionId, versions)
-
-	return nil
+	return zones, nil
 }
 
-func (sm *scrapingManager) scrapeServiceRegionZones(ctx context.Context, service, region string) error {
-	zones, err := sm.infoer.GetZones(region)
-	if err != nil {
-		return errors.WrapIf(err, "failed to retrieve zones for region")
+// scrapeServiceRegion scrapes the data of the service in the region and stores it at once as a new generation
+// if any part of the scraping fails nothing is stored, the previous generation remains in place
+func (sm *scrapingManager) scrapeServiceRegion(ctx context.Context, service, regionId string, generation int64) (RegionData, error) {
+	var (
+		data = RegionData{Generation: generation, Status: types.RegionStatus{LastSuccess: time.Now()}}
+		err  error
+	)
+
+	if data.Zones, err = sm.scrapeServiceRegionZones(ctx, service, regionId); err != nil {
+		return data, errors.WithMessage(err, "failed to scrape zones for region")
 	}
 
-	sm.store.DeleteZones(sm.provider, service, region)
-	sm.store.StoreZones(sm.provider, service, region, zones)
+	if data.Vms, data.ProductDetails, err = sm.scrapeServiceRegionProducts(ctx, service, regionId); err != nil {
+		sm.metrics.ReportScrapeFailure(sm.provider, service, regionId)
+		return data, errors.WithMessage(err, "failed to scrape products for region")
+	}
 
-	return nil
+	if err = sm.checkScrapeSanity(service, regionId, data.Vms); err != nil {
+		return data, err
+	}
+
+	if data.Images, err = sm.scrapeServiceRegionImages(ctx, service, regionId); err != nil {
+		sm.metrics.ReportScrapeFailure(sm.provider, service, regionId)
+		return data, errors.WithMessage(err, "failed to scrape images for region")
+	}
+
+	if data.Versions, err = sm.scrapeServiceRegionVersions(ctx, service, regionId); err != nil {
+		sm.metrics.ReportScrapeFailure(sm.provider, service, regionId)
+		return data, errors.WithMessage(err, "failed to scrape versions for region")
+	}
+
+	// the replica may have lost the leadership of the provider during the scrape
+	if err = ctx.Err(); err != nil {
+		return data, errors.WithMessage(err, "scraping cancelled")
+	}
+
+	// the changes are published once the data is stored
+	changes := sm.regionChanges(service, regionId, data)
+	if err = sm.store.StoreRegionData(sm.provider, service, regionId, data); err != nil {
+		return data, errors.WithMessage(err, "failed to store region data")
+	}
+	sm.publishEvents(changes)
+
+	return data, nil
 }
 
-func (sm *scrapingManager) scrapeServiceRegionInfo(ctx context.Context, services []types.Service) error {
+// scrapeServiceRegionInfo scrapes the regions of the services, only the regions selected by the include function are scraped
+func (sm *scrapingManager) scrapeServiceRegionInfo(ctx context.Context, services []types.Service, include func(region string) bool) error {
 	ctx, _ = sm.tracer.StartWithTags(ctx, "scrape-region-info", map[string]interface{}{"provider": sm.provider})
 	defer sm.tracer.EndSpan(ctx)
 
+	// all the regions scraped in this run get the same generation
+	generation := time.Now().UnixNano() / 1e6
+
 	var lastScrapeError error = nil
 	for _, service := range services {
 		sm.log.Info("start to scrape service region information", map[string]interface{}{"service": service.ServiceName()})
 
 		if service.IsStatic {
-			// todo hack for the PKE static service - image info needs to be scraped
-			if err := sm.scrapePKEImages(ctx, service); err != nil {
+			// the data of static services is loaded, except for the data kinds declared to be scraped
+			if err := sm.scrapeStaticServiceData(ctx, service, include); err != nil {
 				sm.errorHandler.Handle(err)
 			}
 
@@ -150,50 +233,120 @@ func (sm *scrapingManager) scrapeServiceRegionInfo(ctx context.Context, services
 			continue
 		}
 
-		regions, err := sm.infoer.GetRegions(service.ServiceName())
+		if err := ctx.Err(); err != nil {
+			return errors.WithMessage(err, "scraping cancelled")
+		}
+
+		start := time.Now()
+		regions, err := sm.infoerFor(ctx).GetRegions(service.ServiceName())
 		if err != nil {
 			sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), "N/A")
+			sm.recordScrapeRuns([]types.ScrapeRun{newScrapeRun(sm.provider, service.ServiceName(), "", start, RegionData{}, err)})
+			sm.publishFailure(service.ServiceName(), "", err)
+
+			// the previously scraped regions are kept, but their data gets stale
+			storedRegions, _ := sm.store.GetRegions(sm.provider, service.ServiceName())
+			for regionId := range storedRegions {
+				sm.markRegionStale(service.ServiceName(), regionId)
+			}
+
 			return errors.WithDetails(err, "failed to retrieve regions", "service", service.ServiceName())
 		}
 
-		sm.store.DeleteRegions(sm.provider, service.ServiceName())
+		regions = selectRegions(regions, func(regionId string) bool {
+			return include(regionId) && sm.config.Regions.Allows(service.ServiceName(), regionId)
+		})
+
+		if err := ctx.Err(); err != nil {
+			return errors.WithMessage(err, "scraping cancelled")
+		}
+
+		// the region list is overwritten, not deleted, so it's never missing for readers
 		sm.store.StoreRegions(sm.provider, service.ServiceName(), regions)
 
-		for regionId := range regions {
+		var (
+			mu   sync.Mutex
+			runs = make([]types.ScrapeRun, 0, len(regions))
+		)
+		sm.forEachRegion(ctx, regions, func(regionId string) {
 			start := time.Now()
-			if err = sm.scrapeServiceRegionZones(ctx, service.ServiceName(), regionId); err != nil {
-				lastScrapeError = errors.WithDetails(err, "provider", sm.provider, "service", service.ServiceName(), "region", regionId)
-				sm.log.WithFields(map[string]interface{}{"error": lastScrapeError, "region": regionId}).
-					Error("failed to scrape zones for region")
-				continue
-			}
-			if err = sm.scrapeServiceRegionProducts(ctx, service.ServiceName(), regionId); err != nil {
-				sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), regionId)
-				lastScrapeError = errors.WithDetails(err, "provider", sm.provider, "service", service.ServiceName(), "region", regionId)
-				sm.log.WithFields(map[string]interface{}{"error": lastScrapeError, "region": regionId}).
-					Error("failed to scrape products for region")
-				continue
+			data, err := sm.scrapeServiceRegion(ctx, service.ServiceName(), regionId, generation)
+			if ctx.Err() != nil {
+				// the region is left to the replica taking over the provider
+				return
 			}
-			if err = sm.scrapeServiceRegionImages(ctx, service.ServiceName(), regionId); err != nil {
-				sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), regionId)
-				lastScrapeError = errors.WithDetails(err, "provider", sm.provider, "service", service.ServiceName(), "region", regionId)
-				sm.log.WithFields(map[string]interface{}{"error": lastScrapeError, "region": regionId}).
-					Error("failed to scrape images for region")
-				continue
-			}
-			if err = sm.scrapeServiceRegionVersions(ctx, service.ServiceName(), regionId); err != nil {
-				sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), regionId)
-				lastScrapeError = errors.WithDetails(err, "provider", sm.provider, "service", service.ServiceName(), "region", regionId)
-				sm.log.WithFields(map[string]interface{}{"error": lastScrapeError, "region": regionId}).
-					Error("failed to scrape images for region")
-				continue
+
+			mu.Lock()
+			runs = append(runs, newScrapeRun(sm.provider, service.ServiceName(), regionId, start, data, err))
+			mu.Unlock()
+
+			if err != nil {
+				err = errors.WithDetails(err, "provider", sm.provider, "service", service.ServiceName(), "region", regionId)
+				sm.log.WithFields(map[string]interface{}{"error": err, "region": regionId}).
+					Error("failed to scrape service region information")
+				sm.markRegionStale(service.ServiceName(), regionId)
+				sm.publishFailure(service.ServiceName(), regionId, err)
+
+				mu.Lock()
+				lastScrapeError = err
+				mu.Unlock()
+				return
 			}
 			sm.metrics.ReportScrapeRegionCompleted(sm.provider, service.ServiceName(), regionId, start)
-		}
+		})
+
+		sm.recordScrapeRuns(runs)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return errors.WithMessage(err, "scraping cancelled")
 	}
+
 	return lastScrapeError
 }
 
+// markRegionStale flags the data of the service in the region stale, the data from the last successful scrape is kept
+func (sm *scrapingManager) markRegionStale(service, regionId string) {
+	status, _ := sm.store.GetRegionStatus(sm.provider, service, regionId)
+	if status.Stale {
+		return
+	}
+
+	status.Stale = true
+	sm.store.StoreRegionStatus(sm.provider, service, regionId, status)
+}
+
+// forEachRegion calls the function for every region, at most the configured number of regions are processed in parallel
+// no more regions are started once the context is done
+func (sm *scrapingManager) forEachRegion(ctx context.Context, regions map[string]string, fn func(regionId string)) {
+	concurrency := sm.config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for regionId := range regions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+
+		go func(regionId string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			fn(regionId)
+		}(regionId)
+	}
+	wg.Wait()
+}
+
 func (sm *scrapingManager) updateStatus(ctx context.Context) {
 	values := strconv.Itoa(int(time.Now().UnixNano() / 1e6))
 	sm.log.Info("updating status for provider")
@@ -201,7 +354,8 @@ func (sm *scrapingManager) updateStatus(ctx context.Context) {
 }
 
 // scrapeServiceInformation scrapes service and region dependant cloud information and stores its
-func (sm *scrapingManager) scrapeServiceInformation(ctx context.Context) {
+// only the services selected by the include function are scraped
+func (sm *scrapingManager) scrapeServiceInformation(ctx context.Context, include func(service string) bool) {
 	ctx, _ = sm.tracer.StartWithTags(ctx, "scrape-service-info", map[string]interface{}{"provider": sm.provider})
 	defer sm.tracer.EndSpan(ctx)
 
@@ -209,10 +363,22 @@ func (sm *scrapingManager) scrapeServiceInformation(ctx context.Context) {
 	if !ok {
 		sm.metrics.ReportScrapeFailure(sm.provider, "N/A", "N/A")
 		sm.log.Error("failed to retrieve services")
+		sm.publishFailure("", "", errors.New("failed to retrieve services"))
 		return
 	}
 
-	err := sm.scrapeServiceRegionInfo(ctx, storedServices)
+	services := make([]types.Service, 0, len(storedServices))
+	for _, service := range storedServices {
+		if include(service.ServiceName()) {
+			services = append(services, service)
+		}
+	}
+
+	err := sm.scrapeServiceRegionInfo(ctx, services, allRegions)
+	if ctx.Err() != nil {
+		// nothing is stored once the scraping is cancelled
+		return
+	}
 	if err != nil {
 		sm.log.Error("failed to load service region information")
 		sm.errorHandler.Handle(err)
@@ -222,178 +388,545 @@ func (sm *scrapingManager) scrapeServiceInformation(ctx context.Context) {
 	sm.updateStatus(ctx)
 }
 
-func (sm *scrapingManager) scrapePricesInRegion(ctx context.Context, region string, wg *sync.WaitGroup) {
-	if wg != nil {
-		defer wg.Done()
-	}
+func (sm *scrapingManager) scrapePricesInRegion(ctx context.Context, region string) {
 	start := time.Now()
-	prices, err := sm.infoer.GetCurrentPrices(region)
+	prices, err := sm.infoerFor(ctx).GetCurrentPrices(region)
 	if err != nil {
 		sm.metrics.ReportScrapeShortLivedFailure(sm.provider, region)
 		sm.log.Error("failed to scrape spot prices in region")
 		sm.errorHandler.Handle(err)
+		sm.publishFailure("", region, err)
 	}
 
+	// the replica may have lost the leadership of the provider during the scrape
+	if ctx.Err() != nil {
+		return
+	}
+
+	sm.publishPriceChanges(region, prices)
 	for instType, price := range prices {
 		sm.store.StorePrice(sm.provider, region, instType, price)
 	}
 
+	if len(prices) > 0 {
+		sm.recordPrices(region, prices)
+		sm.updateProductDetails(region)
+	}
+
 	sm.metrics.ReportScrapeRegionShortLivedCompleted(sm.provider, region, start)
 }
 
 func (sm *scrapingManager) scrapePricesInAllRegions(ctx context.Context) {
-	var wg sync.WaitGroup
-
 	ctx, _ = sm.tracer.StartWithTags(ctx, "scrape-region-prices", map[string]interface{}{"provider": sm.provider})
 	defer sm.tracer.EndSpan(ctx)
 	sm.log.Info("start scraping prices")
 
 	// record current time for metrics
 	start := time.Now()
-	regions, err := sm.infoer.GetRegions("compute")
+	regions, err := sm.infoerFor(ctx).GetRegions("compute")
 	if err != nil {
 		sm.log.Error("failed to retrieve regions")
 		sm.errorHandler.Handle(err)
 	}
 
-	for regionId := range regions {
-		wg.Add(1)
-		go sm.scrapePricesInRegion(ctx, regionId, &wg)
-	}
-	wg.Wait()
+	// the prices are shared by the services, only the regions left out for every service are skipped
+	sm.forEachRegion(ctx, selectRegions(regions, sm.config.Regions.Match), func(regionId string) {
+		sm.scrapePricesInRegion(ctx, regionId)
+	})
 	sm.metrics.ReportScrapeProviderShortLivedCompleted(sm.provider, start)
 }
 
-func (sm *scrapingManager) updateVirtualMachines(service, region string) error {
-	vms, ok := sm.store.GetVm(sm.provider, service, region)
-	if !ok {
-		sm.log.Debug("VMs not yet cached, update suspended")
-		return errors.NewWithDetails("VMs not yet cached", "provider", sm.provider, "service", service, "region", region)
+// recordPrices appends the changed prices of the region to the price history
+// missing on demand or spot prices are carried over from the previous point, as not every scrape retrieves both
+func (sm *scrapingManager) recordPrices(region string, prices map[string]types.Price) {
+	if sm.priceHistory == nil {
+		return
 	}
 
-	virtualMachines := make([]types.VMInfo, 0, len(vms))
-	for _, vm := range vms {
-		prices, found := sm.store.GetPrice(sm.provider, region, vm.Type)
+	sm.pricesMu.Lock()
+	defer sm.pricesMu.Unlock()
 
+	recorded := sm.recordedPrices[region]
+
+	changed := make(map[string]types.Price)
+	for instType, price := range prices {
+		previous, found := recorded[instType]
 		if found {
-			if prices.OnDemandPrice > 0 {
-				vm.OnDemandPrice = prices.OnDemandPrice
+			if price.OnDemandPrice <= 0 {
+				price.OnDemandPrice = previous.OnDemandPrice
+			}
+			if len(price.SpotPrice) == 0 {
+				price.SpotPrice = previous.SpotPrice
+			}
+
+			if reflect.DeepEqual(previous, price) {
+				continue
 			}
 		}
 
+		changed[instType] = price
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	if err := sm.priceHistory.AppendPrices(sm.provider, region, time.Now(), changed); err != nil {
+		sm.log.Error("failed to record price history", map[string]interface{}{"region": region})
+		sm.errorHandler.Handle(err)
+		return
+	}
+
+	if recorded == nil {
+		recorded = make(map[string]types.Price, len(changed))
+		sm.recordedPrices[region] = recorded
+	}
+	for instType, price := range changed {
+		recorded[instType] = price
+	}
+}
+
+// updateVirtualMachines sets the stored on demand prices on the vms, the vms without price are left out
+// if the price of a vm is missing (eg. the price scrape failed), the last known price is kept from the previous vms
+func (sm *scrapingManager) updateVirtualMachines(region string, vms []types.VMInfo, previous []types.VMInfo) ([]types.VMInfo, map[string]types.Price) {
+	prices, _ := sm.store.GetPrices(sm.provider, region, instanceTypes(vms))
+
+	lastKnownPrices := make(map[string]float64, len(previous))
+	for _, vm := range previous {
+		lastKnownPrices[vm.Type] = vm.OnDemandPrice
+	}
+
+	virtualMachines := make([]types.VMInfo, 0, len(vms))
+	for _, vm := range vms {
+		if price, found := prices[vm.Type]; found {
+			if price.OnDemandPrice > 0 {
+				vm.OnDemandPrice = price.OnDemandPrice
+			}
+		}
+
+		if vm.OnDemandPrice <= 0 && lastKnownPrices[vm.Type] > 0 {
+			vm.OnDemandPrice = lastKnownPrices[vm.Type]
+		}
+
 		if vm.OnDemandPrice != 0 {
 			virtualMachines = append(virtualMachines, vm)
 		}
 	}
 
-	sm.store.DeleteVm(sm.provider, service, region)
-	sm.store.StoreVm(sm.provider, service, region, virtualMachines)
+	return virtualMachines, prices
+}
 
-	return nil
+// updateProductDetails rebuilds the product views of the region after the prices changed
+func (sm *scrapingManager) updateProductDetails(region string) {
+	services, ok := sm.store.GetServices(sm.provider)
+	if !ok {
+		return
+	}
+
+	for _, service := range services {
+		if service.IsStatic {
+			// the static services have a view only if their vms are scraped, the loaded vms are merged when read
+			if details, ok := sm.store.GetProductDetails(sm.provider, service.ServiceName(), region); !ok || details == nil {
+				continue
+			}
+		}
+
+		vms, ok := sm.store.GetVm(sm.provider, service.ServiceName(), region)
+		if !ok {
+			continue
+		}
+
+		prices, _ := sm.store.GetPrices(sm.provider, region, instanceTypes(vms))
+		sm.store.StoreProductDetails(sm.provider, service.ServiceName(), region, mergeProductDetails(vms, prices, sm.log))
+	}
 }
 
-// scrape implements the scraping logic for a provider
-func (sm *scrapingManager) scrape(ctx context.Context) {
+// scrape implements the scraping logic for a provider, only the services selected by the include function are scraped
+func (sm *scrapingManager) scrape(ctx context.Context, include func(service string) bool) {
 	ctx, _ = sm.tracer.StartWithTags(ctx, fmt.Sprintf("scraping-%s", sm.provider), map[string]interface{}{"provider": sm.provider})
 	defer sm.tracer.EndSpan(ctx)
 
 	sm.log.Info("start scraping for provider information")
 	start := time.Now()
+	sm.publish(messaging.ScrapeStarted, "", "", nil)
 
-	sm.initialize(ctx)
+	if err := sm.initialize(ctx); err != nil {
+		sm.errorHandler.Handle(err)
+	}
 
-	sm.scrapeServiceInformation(ctx)
+	sm.scrapeServiceInformation(ctx, include)
 
 	// emit a scraping complete event to notify potential subscribers
-	sm.eventBus.PublishScrapingComplete(sm.provider)
+	sm.publish(messaging.ScrapeCompleted, "", "", nil)
 
 	sm.metrics.ReportScrapeProviderCompleted(sm.provider, start)
 }
 
-func (sm *scrapingManager) scrapePKEImages(ctx context.Context, service types.Service) error {
-	// todo find a better solution - PKE service is static but images need to be scraped
-	if service.ServiceName() == "pke" {
-		regions, err := sm.infoer.GetRegions(service.ServiceName())
-		if err != nil {
-			sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), "N/A")
-			return errors.WithDetails(err, "failed to retrieve regions", "service", service.ServiceName())
+// scrapeService returns a task scraping a single service of the provider, for the services having their own schedule
+// the prices are not initialized, they are kept up to date by the scrapes of the provider
+func (sm *scrapingManager) scrapeService(service string) TaskFn {
+	return func(ctx context.Context) {
+		ctx, _ = sm.tracer.StartWithTags(ctx, fmt.Sprintf("scraping-%s-%s", sm.provider, service), map[string]interface{}{"provider": sm.provider, "service": service})
+		defer sm.tracer.EndSpan(ctx)
+
+		sm.log.Info("start scraping for service information", map[string]interface{}{"service": service})
+		sm.publish(messaging.ScrapeStarted, service, "", nil)
+
+		sm.scrapeServiceInformation(ctx, func(s string) bool {
+			return s == service
+		})
+
+		sm.publish(messaging.ScrapeCompleted, service, "", nil)
+	}
+}
+
+// hasOwnSchedule returns true if the service is scraped according to its own schedule, not with the rest of the provider
+func (sm *scrapingManager) hasOwnSchedule(service string) bool {
+	_, ok := sm.config.Schedule.Services[service]
+	return ok
+}
+
+// allServices selects every service of the provider
+func allServices(string) bool {
+	return true
+}
+
+// allRegions selects every region of a service
+func allRegions(string) bool {
+	return true
+}
+
+// selectRegions returns the regions selected by the include function
+func selectRegions(regions map[string]string, include func(region string) bool) map[string]string {
+	selected := make(map[string]string, len(regions))
+	for regionId, name := range regions {
+		if include(regionId) {
+			selected[regionId] = name
+		}
+	}
+
+	return selected
+}
+
+// scrapeStaticServiceData scrapes the data kinds of a static service that are declared to be scraped
+// a region failed to be scraped keeps its data flagged stale, the rest of the regions are scraped anyway
+func (sm *scrapingManager) scrapeStaticServiceData(ctx context.Context, service types.Service, include func(region string) bool) error {
+	if len(service.ScrapedData) == 0 {
+		return nil
+	}
+
+	regions, err := sm.infoerFor(ctx).GetRegions(service.ServiceName())
+	if err != nil {
+		sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), "N/A")
+		sm.publishFailure(service.ServiceName(), "", err)
+
+		// the previously scraped regions are kept, but their data gets stale
+		storedRegions, _ := sm.store.GetRegions(sm.provider, service.ServiceName())
+		for regionId := range storedRegions {
+			sm.markRegionStale(service.ServiceName(), regionId)
 		}
 
-		for regionId := range regions {
-			if err = sm.scrapeServiceRegionImages(ctx, service.ServiceName(), regionId); err != nil {
-				sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), regionId)
-				return errors.WithDetails(err, "provider", sm.provider, "service", service.ServiceName(), "region", regionId)
+		return errors.WithDetails(err, "failed to retrieve regions", "service", service.ServiceName())
+	}
+
+	// all the regions scraped in this run get the same generation
+	generation := time.Now().UnixNano() / 1e6
+
+	var lastScrapeError error
+	for regionId := range selectRegions(regions, func(regionId string) bool {
+		return include(regionId) && sm.config.Regions.Allows(service.ServiceName(), regionId)
+	}) {
+		if ctx.Err() != nil {
+			// the rest of the regions are left to the replica taking over the provider
+			return errors.WithMessage(ctx.Err(), "scraping cancelled")
+		}
+
+		if err := sm.scrapeStaticServiceRegion(ctx, service, regionId, generation); err != nil {
+			if ctx.Err() != nil {
+				return err
 			}
+
+			sm.metrics.ReportScrapeFailure(sm.provider, service.ServiceName(), regionId)
+			sm.log.Error("failed to scrape static service region data",
+				map[string]interface{}{"service": service.ServiceName(), "region": regionId, "error": err.Error()})
+			sm.markRegionStale(service.ServiceName(), regionId)
+			sm.publishFailure(service.ServiceName(), regionId, err)
+
+			lastScrapeError = err
+		}
+	}
+
+	return lastScrapeError
+}
+
+// scrapeStaticServiceRegion scrapes the declared data kinds of the static service in the region and stores them at once
+// with the loaded data of the rest of the kinds as a new generation, nothing is stored if any of the kinds fails
+// the product view is only written if the vms are scraped, otherwise the stored one (if any) is kept
+func (sm *scrapingManager) scrapeStaticServiceRegion(ctx context.Context, service types.Service, regionId string, generation int64) error {
+	name := service.ServiceName()
+
+	data := RegionData{Generation: generation, Status: types.RegionStatus{LastSuccess: time.Now()}}
+	data.Zones, _ = sm.store.GetZones(sm.provider, name, regionId)
+	data.Vms, _ = sm.store.GetVm(sm.provider, name, regionId)
+	data.Images, _ = sm.store.GetImage(sm.provider, name, regionId)
+	data.Versions, _ = sm.store.GetVersion(sm.provider, name, regionId)
+
+	for _, kind := range service.ScrapedData {
+		if err := sm.scrapeServiceRegionData(ctx, name, regionId, kind, &data); err != nil {
+			return errors.WithDetails(err, "provider", sm.provider, "service", name, "region", regionId, "data", kind)
 		}
 	}
 
+	// the replica may have lost the leadership of the provider during the scrape
+	if err := ctx.Err(); err != nil {
+		return errors.WithMessage(err, "scraping cancelled")
+	}
+
+	// the changes are published once the data is stored
+	changes := sm.regionChanges(name, regionId, data)
+	if err := sm.store.StoreRegionData(sm.provider, name, regionId, data); err != nil {
+		return errors.WithDetails(err, "provider", sm.provider, "service", name, "region", regionId)
+	}
+	sm.publishEvents(changes)
+
 	return nil
 }
 
-func NewScrapingManager(provider string, infoer CloudInfoer, store CloudInfoStore, log Logger,
+// scrapeServiceRegionData scrapes a single data kind of the service in the region into the region data
+func (sm *scrapingManager) scrapeServiceRegionData(ctx context.Context, service, regionId, kind string, data *RegionData) error {
+	switch kind {
+	case types.ZonesData:
+		zones, err := sm.scrapeServiceRegionZones(ctx, service, regionId)
+		if err != nil {
+			return err
+		}
+
+		data.Zones = zones
+
+	case types.VmsData:
+		vms, details, err := sm.scrapeServiceRegionProducts(ctx, service, regionId)
+		if err != nil {
+			return err
+		}
+
+		if err := sm.checkScrapeSanity(service, regionId, vms); err != nil {
+			return err
+		}
+
+		data.Vms, data.ProductDetails = vms, details
+
+	case types.ImagesData:
+		images, err := sm.scrapeServiceRegionImages(ctx, service, regionId)
+		if err != nil {
+			return err
+		}
+
+		if images != nil {
+			data.Images = images
+		}
+
+	case types.VersionsData:
+		versions, err := sm.scrapeServiceRegionVersions(ctx, service, regionId)
+		if err != nil {
+			return err
+		}
+
+		data.Versions = versions
+
+	default:
+		return errors.NewWithDetails("unsupported service data kind", "data", kind)
+	}
+
+	return nil
+}
+
+func NewScrapingManager(provider string, infoer CloudInfoer, store CloudInfoStore, priceHistory PriceHistoryStore, config ScrapeConfig, log Logger,
 	metrics metrics.Reporter, tracer tracing.Tracer, eventBus messaging.EventBus, errorHandler ErrorHandler) *scrapingManager {
 	return &scrapingManager{
-		provider:     provider,
-		infoer:       infoer,
-		store:        store,
-		log:          log.WithFields(map[string]interface{}{"component": "scraping-manager", "provider": provider}),
-		metrics:      metrics,
-		tracer:       tracer,
-		eventBus:     eventBus,
-		errorHandler: errorHandler,
+		provider:       provider,
+		infoer:         NewResilientCloudInfoer(provider, infoer, config, metrics, log),
+		store:          store,
+		priceHistory:   priceHistory,
+		config:         config,
+		recordedPrices: make(map[string]map[string]types.Price),
+		accepted:       make(map[string]bool),
+		log:            log.WithFields(map[string]interface{}{"component": "scraping-manager", "provider": provider}),
+		metrics:        metrics,
+		tracer:         tracer,
+		eventBus:       eventBus,
+		errorHandler:   errorHandler,
 	}
 }
 
 type ScrapingDriver struct {
 	scrapingManagers []*scrapingManager
-	renewalInterval  time.Duration
+	leader           *LeaderElector
+	regionScrapes    *singleflight.Group
 	errorHandler     ErrorHandler
 	log              Logger
 }
 
+// StartScraping starts scraping the providers according to their schedules, only the providers the replica is the leader of are scraped
+// the first scrape of a provider covers all of its services, the services having their own schedule are scraped separately afterwards
 func (sd *ScrapingDriver) StartScraping() error {
 	ctx := context.Background()
 
-	if err := NewPeriodicExecutor(sd.renewalInterval, sd.log).Execute(ctx, sd.renewAll); err != nil {
-		return errors.WrapIf(err, "failed to scrape cloud information")
+	providers := make([]string, 0, len(sd.scrapingManagers))
+	for _, manager := range sd.scrapingManagers {
+		providers = append(providers, manager.provider)
 	}
+	sd.leader.Start(ctx, providers)
 
-	// start scraping providers for pricing information
-	if err := NewPeriodicExecutor(4*time.Minute, sd.log).Execute(ctx, sd.renewShortLived); err != nil {
-		return errors.WrapIf(err, "failed to scrape spot price info")
-	}
+	for _, manager := range sd.scrapingManagers {
+		schedule := manager.config.Schedule
 
-	return nil
-}
+		executor, err := NewScheduledExecutor(schedule, schedule.Cron, manager.log)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to schedule scraping", "provider", manager.provider)
+		}
 
-func (sd *ScrapingDriver) renewAll(ctx context.Context) {
-	for _, manager := range sd.scrapingManagers {
-		go manager.scrape(ctx)
-	}
-}
+		if err := executor.Execute(ctx, sd.scrapeProvider(manager)); err != nil {
+			return errors.WrapIf(err, "failed to scrape cloud information")
+		}
+
+		for service, spec := range schedule.Services {
+			executor, err := NewScheduledExecutor(schedule, spec, manager.log)
+			if err != nil {
+				return errors.WrapIfWithDetails(err, "failed to schedule scraping", "provider", manager.provider, "service", service)
+			}
+
+			if err := executor.Schedule(ctx, sd.leading(manager, manager.scrapeService(service))); err != nil {
+				return errors.WrapIf(err, "failed to scrape service information")
+			}
+		}
 
-func (sd *ScrapingDriver) renewShortLived(ctx context.Context) {
-	for _, manager := range sd.scrapingManagers {
 		if !manager.infoer.HasShortLivedPriceInfo() {
 			// the manager's logger is used here - that has the provider in it's context
 			manager.log.Debug("skip scraping for short lived prices (not applicable for provider)")
 			continue
 		}
-		go manager.scrapePricesInAllRegions(ctx)
+
+		// start scraping providers for pricing information
+		executor, err = NewScheduledExecutor(schedule, schedule.Prices, manager.log)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to schedule scraping", "provider", manager.provider)
+		}
+
+		if err := executor.Execute(ctx, sd.leading(manager, manager.scrapePricesInAllRegions)); err != nil {
+			return errors.WrapIf(err, "failed to scrape spot price info")
+		}
+	}
+
+	return nil
+}
+
+// scrapeProvider returns the task scraping the provider, the services having their own schedule are left out after the first scrape
+func (sd *ScrapingDriver) scrapeProvider(manager *scrapingManager) TaskFn {
+	include := allServices
+
+	return sd.leading(manager, func(ctx context.Context) {
+		manager.scrape(ctx, include)
+
+		include = func(service string) bool {
+			return !manager.hasOwnSchedule(service)
+		}
+	})
+}
+
+// leading returns a task executing the passed in one only if the replica is the leader of the provider
+// the task is cancelled if the leadership is lost while it's running, so it doesn't overwrite the results of the new leader
+func (sd *ScrapingDriver) leading(manager *scrapingManager, sf TaskFn) TaskFn {
+	return func(ctx context.Context) {
+		if !sd.leader.IsLeader(manager.provider) {
+			manager.log.Debug("skip scraping, another replica is the leader of the provider")
+			return
+		}
+
+		leaderCtx, cancel := sd.leader.LeaderContext(ctx, manager.provider)
+		defer cancel()
+
+		sf(leaderCtx)
+
+		if leaderCtx.Err() != nil && ctx.Err() == nil {
+			manager.log.Warn("scraping cancelled, the leadership of the provider is lost")
+		}
 	}
 }
 
 func (sd *ScrapingDriver) RefreshProvider(ctx context.Context, provider string) {
 	for _, manager := range sd.scrapingManagers {
 		if manager.provider == provider {
-			manager.scrape(ctx)
+			sd.leading(manager, func(ctx context.Context) {
+				manager.scrape(ctx, allServices)
+			})(ctx)
+		}
+	}
+}
+
+// ErrNotLeader is returned if a region is requested to be scraped by a replica that is not the leader of the provider
+const ErrNotLeader = errors.Sentinel("the provider is scraped by another replica")
+
+// ScrapeRegion scrapes the data of the service in the region right away, the concurrent requests of a region share a single scrape
+// the caller waits for the scrape until the context is done, the scrape itself is not cancelled with the context
+// only the leader of the provider scrapes on demand, ErrNotLeader is returned by the other replicas
+// and if the leadership is lost during the scrape
+// (the requests served by them see the region once the leader scraped it)
+func (sd *ScrapingDriver) ScrapeRegion(ctx context.Context, provider, service, region string) error {
+	manager, err := sd.manager(provider)
+	if err != nil {
+		return err
+	}
+
+	if !sd.leader.IsLeader(provider) {
+		return errors.WithDetails(ErrNotLeader, "provider", provider)
+	}
+
+	if !manager.config.Regions.Allows(service, region) {
+		return errors.NewWithDetails("the region is not scraped", "provider", provider, "service", service, "region", region)
+	}
+
+	services, _ := manager.store.GetServices(provider)
+	for _, s := range services {
+		if s.ServiceName() == service && s.IsStatic {
+			return errors.NewWithDetails("static services are not scraped", "provider", provider, "service", service)
 		}
 	}
+
+	key := fmt.Sprintf("%s/%s/%s", provider, service, region)
+	scrape := sd.regionScrapes.DoChan(key, func() (interface{}, error) {
+		leaderCtx, cancel := sd.leader.LeaderContext(context.Background(), provider)
+		defer cancel()
+
+		start := time.Now()
+
+		data, err := manager.scrapeServiceRegion(leaderCtx, service, region, start.UnixNano()/1e6)
+		if leaderCtx.Err() != nil {
+			return nil, errors.WithDetails(ErrNotLeader, "provider", provider)
+		}
+		manager.recordScrapeRuns([]types.ScrapeRun{newScrapeRun(provider, service, region, start, data, err)})
+		if err != nil {
+			manager.publishFailure(service, region, err)
+			return nil, errors.WithDetails(err, "provider", provider, "service", service, "region", region)
+		}
+
+		manager.metrics.ReportScrapeRegionCompleted(provider, service, region, start)
+
+		return nil, nil
+	})
+
+	select {
+	case result := <-scrape:
+		return result.Err
+	case <-ctx.Done():
+		return errors.WithDetails(ctx.Err(), "provider", provider, "service", service, "region", region)
+	}
 }
 
-func NewScrapingDriver(renewalInterval time.Duration,
-	infoers map[string]CloudInfoer,
+func NewScrapingDriver(infoers map[string]CloudInfoer,
 	store CloudInfoStore,
+	priceHistory PriceHistoryStore,
+	configs map[string]ScrapeConfig,
+	leader *LeaderElector,
 	eventBus messaging.EventBus,
 	metrics metrics.Reporter,
 	tracer tracing.Tracer,
@@ -402,13 +935,53 @@ func NewScrapingDriver(renewalInterval time.Duration,
 	managers := make([]*scrapingManager, 0, len(infoers))
 
 	for provider, infoer := range infoers {
-		managers = append(managers, NewScrapingManager(provider, infoer, store, log, metrics, tracer, eventBus, errorHandler))
+		managers = append(managers, NewScrapingManager(provider, infoer, store, priceHistory, configs[provider], log, metrics, tracer, eventBus, errorHandler))
 	}
 
 	return &ScrapingDriver{
 		scrapingManagers: managers,
-		renewalInterval:  renewalInterval,
+		leader:           leader,
+		regionScrapes:    &singleflight.Group{},
 		errorHandler:     errorHandler,
 		log:              log.WithFields(map[string]interface{}{"component": "scraping-driver"}),
 	}
 }
+
+// AcceptRegion makes the next scrape result of the service in the region stored even if it fails the sanity checks,
+// eg. after the operator verified that the instance types were dropped by the provider indeed
+// the region is scraped by the leader of the provider, ErrNotLeader is returned by the other replicas
+func (sd *ScrapingDriver) AcceptRegion(provider, service, region string) error {
+	manager, err := sd.manager(provider)
+	if err != nil {
+		return err
+	}
+
+	if !sd.leader.IsLeader(provider) {
+		return errors.WithDetails(ErrNotLeader, "provider", provider)
+	}
+
+	manager.acceptNext(service, region)
+
+	return nil
+}
+
+// PromoteQuarantine stores the quarantined scrape result of the service in the region in place of the stored data
+func (sd *ScrapingDriver) PromoteQuarantine(provider, service, region string) error {
+	manager, err := sd.manager(provider)
+	if err != nil {
+		return err
+	}
+
+	return manager.promoteQuarantine(service, region)
+}
+
+// manager returns the scraping manager of the provider
+func (sd *ScrapingDriver) manager(provider string) (*scrapingManager, error) {
+	for _, manager := range sd.scrapingManagers {
+		if manager.provider == provider {
+			return manager, nil
+		}
+	}
+
+	return nil, errors.NewWithDetails("unsupported provider", "provider", provider)
+}
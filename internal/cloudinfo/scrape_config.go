@@ -0,0 +1,406 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"path"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// ScrapeConfig holds the scrape settings of a provider.
+type ScrapeConfig struct {
+	// Concurrency is the maximum number of regions scraped in parallel.
+	Concurrency int
+
+	// RunHistory is the number of the scrape runs recorded per service and region.
+	RunHistory int
+
+	// Retry configures the retries of the failed cloud provider calls.
+	Retry RetryConfig
+
+	// CircuitBreaker configures the circuit breaker in front of the cloud provider.
+	CircuitBreaker CircuitBreakerConfig
+
+	// RateLimit limits the rate of the cloud provider calls.
+	RateLimit RateLimitConfig
+
+	// Sanity configures the checks of the scraped data against the stored one.
+	Sanity SanityConfig
+
+	// Schedule configures when the provider is scraped.
+	Schedule ScheduleConfig
+
+	// Regions selects the regions of the provider that are scraped.
+	Regions RegionsConfig
+
+	// Events configures the events published about the changes of the scraped data.
+	Events EventsConfig
+}
+
+// RetryConfig holds the retry settings of the cloud provider calls.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries of a failed call, a negative value disables retrying.
+	MaxRetries int
+
+	// InitialBackoff is the wait before the first retry, it's doubled for every further retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between two retries.
+	MaxBackoff time.Duration
+
+	// BudgetRatio is the number of retries earned by a call, eg. 0.2 allows one retry for every five calls.
+	BudgetRatio float64
+
+	// BudgetBurst is the maximum number of retries that can be saved up.
+	BudgetBurst int
+}
+
+// CircuitBreakerConfig holds the settings of the circuit breakers of the cloud provider regions.
+type CircuitBreakerConfig struct {
+	// Threshold is the number of consecutive failures in a region opening its circuit breaker, a negative value disables it.
+	Threshold int
+
+	// Timeout is the time the circuit breaker stays open before letting a trial call through.
+	Timeout time.Duration
+}
+
+// RateLimitConfig holds the token bucket limits of the cloud provider calls.
+type RateLimitConfig struct {
+	// RateLimit limits all the API requests sent to the cloud provider, every page of a listing included.
+	RateLimit `mapstructure:",squash"`
+
+	// APIOperations limits the API requests of single API operations on top of the provider limit.
+	APIOperations []APIOperationRateLimit
+
+	// Operations limits the calls of single cloud infoer operations (eg. GetCurrentPrices),
+	// a call takes a single token however many API requests it sends.
+	Operations map[string]RateLimit
+}
+
+// LimitsRequests returns true if the API requests are limited, ie. the provider or an API operation has a positive rate.
+func (c RateLimitConfig) LimitsRequests() bool {
+	if c.Rate > 0 {
+		return true
+	}
+
+	for _, operation := range c.APIOperations {
+		if operation.Rate > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIOperationRateLimit holds the token bucket of the API requests sent to a host, optionally narrowed down to a path prefix.
+type APIOperationRateLimit struct {
+	// Host is the host of the API, a leading dot matches every subdomain (eg. ".amazonaws.com").
+	Host string
+
+	// Path is the prefix of the request paths of the operation, every path matches if it's empty.
+	Path string
+
+	RateLimit `mapstructure:",squash"`
+}
+
+// RateLimit holds the settings of a token bucket.
+type RateLimit struct {
+	// Rate is the number of calls allowed per second, the calls are not limited if it's unset or negative.
+	Rate float64
+
+	// Burst is the number of calls allowed at once, at least one.
+	Burst int
+}
+
+// Validate validates the rate limit configuration.
+func (c RateLimitConfig) Validate() error {
+	if c.Burst < 0 {
+		return errors.NewWithDetails("rate limit burst must not be negative", "burst", c.Burst)
+	}
+
+	for _, operation := range c.APIOperations {
+		if operation.Host == "" {
+			return errors.NewWithDetails("rate limited API operation must have a host", "path", operation.Path)
+		}
+
+		if operation.Burst < 0 {
+			return errors.NewWithDetails("rate limit burst must not be negative", "host", operation.Host, "burst", operation.Burst)
+		}
+	}
+
+	for operation, limit := range c.Operations {
+		if !isCloudInfoerOperation(operation) {
+			return errors.NewWithDetails("unknown rate limited operation", "operation", operation)
+		}
+
+		if limit.Burst < 0 {
+			return errors.NewWithDetails("rate limit burst must not be negative", "operation", operation, "burst", limit.Burst)
+		}
+	}
+
+	return nil
+}
+
+const (
+	// SanityActionReject drops the suspicious scrape results, the stored data is kept
+	SanityActionReject = "reject"
+	// SanityActionQuarantine drops the suspicious scrape results like reject, but saves them aside for inspection
+	SanityActionQuarantine = "quarantine"
+	// SanityActionWarn only reports the suspicious scrape results, they are stored as usual
+	SanityActionWarn = "warn"
+)
+
+// SanityConfig holds the settings of the checks comparing the scraped data of a region with the stored one.
+type SanityConfig struct {
+	// Action is taken when a check fails: reject, quarantine or warn.
+	Action string
+
+	// MaxInstanceDrop is the highest accepted drop of the number of instance types in percent, a negative value disables the check.
+	// It's a pointer, so that 0 (no drop accepted) can be told apart from the unset value.
+	MaxInstanceDrop *float64
+
+	// MaxPriceChange is the highest accepted change of an on demand price in percent, a negative value disables the check.
+	// It's a pointer, so that 0 (no change accepted) can be told apart from the unset value.
+	MaxPriceChange *float64
+
+	// AllowMissingCategories disables the check of instance type categories disappearing.
+	AllowMissingCategories bool
+}
+
+// Validate validates the sanity check configuration.
+func (c SanityConfig) Validate() error {
+	switch c.Action {
+	case "", SanityActionReject, SanityActionQuarantine, SanityActionWarn:
+		return nil
+	default:
+		return errors.NewWithDetails("invalid scrape sanity action", "action", c.Action)
+	}
+}
+
+// EventsConfig holds the settings of the events published about the changes of the scraped data.
+type EventsConfig struct {
+	// SpotPriceThreshold is the lowest change of a spot price in percent published as an event, 0 or a negative value publishes every change.
+	// It's a pointer, so that 0 (every change published) can be told apart from the unset value.
+	SpotPriceThreshold *float64
+}
+
+// ScheduleConfig holds the scrape schedules of a provider.
+// The schedules are cron expressions (eg. "0 2 * * *") or descriptors (eg. "@daily", "@every 4m"), evaluated in UTC.
+type ScheduleConfig struct {
+	// Cron is the schedule of the full scrape of the provider.
+	Cron string
+
+	// Prices is the schedule of the short lived (spot) price scrapes.
+	Prices string
+
+	// Services are the schedules of the services scraped separately from the rest of the provider.
+	Services map[string]string
+
+	// Jitter is the upper limit of the random delay added to every scheduled scrape.
+	Jitter time.Duration
+
+	// QuietWindows are the times of the day in UTC (eg. "08:00-18:00") no scheduled scrape is started in, they are postponed.
+	QuietWindows []string
+}
+
+// Validate validates the schedule configuration.
+func (c ScheduleConfig) Validate() error {
+	specs := []string{c.Cron, c.Prices}
+	for _, spec := range c.Services {
+		specs = append(specs, spec)
+	}
+
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+
+		if _, err := parseSchedule(spec); err != nil {
+			return err
+		}
+	}
+
+	_, err := parseQuietWindows(c.QuietWindows)
+
+	return err
+}
+
+// RegionFilter selects regions by glob patterns (eg. "eu-*"), see path.Match for the syntax.
+type RegionFilter struct {
+	// Include are the patterns of the selected regions, every region is selected if it's empty.
+	Include []string
+
+	// Exclude are the patterns of the regions left out, even if they are included.
+	Exclude []string
+}
+
+// Validate validates the region patterns.
+func (f RegionFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.WrapIfWithDetails(err, "invalid region pattern", "pattern", pattern)
+		}
+	}
+
+	return nil
+}
+
+// Match checks whether the region is selected by the filter.
+func (f RegionFilter) Match(region string) bool {
+	return (len(f.Include) == 0 || matchRegion(f.Include, region)) && !matchRegion(f.Exclude, region)
+}
+
+// matchRegion checks whether the region matches any of the patterns, the invalid patterns match nothing
+func matchRegion(patterns []string, region string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, region); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RegionsConfig holds the regions of a provider that are scraped.
+type RegionsConfig struct {
+	// RegionFilter selects the regions of every service.
+	RegionFilter `mapstructure:",squash"`
+
+	// Services narrow down the regions of the services further.
+	Services map[string]RegionFilter
+}
+
+// Validate validates the region patterns.
+func (c RegionsConfig) Validate() error {
+	if err := c.RegionFilter.Validate(); err != nil {
+		return err
+	}
+
+	for service, filter := range c.Services {
+		if err := filter.Validate(); err != nil {
+			return errors.WithDetails(err, "service", service)
+		}
+	}
+
+	return nil
+}
+
+// Allows checks whether the region of the service is selected by both the provider and the service filters.
+func (c RegionsConfig) Allows(service, region string) bool {
+	return c.Match(region) && c.Services[service].Match(region)
+}
+
+// RegionSelector checks whether the region of the service of the provider is selected by the configuration.
+type RegionSelector func(provider, service, region string) bool
+
+// NewRegionSelector returns a selector of the regions allowed by the scrape configurations of the providers.
+// The regions of the providers without configuration are all selected.
+func NewRegionSelector(configs map[string]ScrapeConfig) RegionSelector {
+	return func(provider, service, region string) bool {
+		return configs[provider].Regions.Allows(service, region)
+	}
+}
+
+// WithDefaults returns the configuration with the unset values taken from the defaults.
+func (c ScrapeConfig) WithDefaults(defaults ScrapeConfig) ScrapeConfig {
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaults.Concurrency
+	}
+
+	if c.RunHistory <= 0 {
+		c.RunHistory = defaults.RunHistory
+	}
+
+	if c.Retry.MaxRetries == 0 {
+		c.Retry.MaxRetries = defaults.Retry.MaxRetries
+	}
+	if c.Retry.InitialBackoff == 0 {
+		c.Retry.InitialBackoff = defaults.Retry.InitialBackoff
+	}
+	if c.Retry.MaxBackoff == 0 {
+		c.Retry.MaxBackoff = defaults.Retry.MaxBackoff
+	}
+	if c.Retry.BudgetRatio == 0 {
+		c.Retry.BudgetRatio = defaults.Retry.BudgetRatio
+	}
+	if c.Retry.BudgetBurst == 0 {
+		c.Retry.BudgetBurst = defaults.Retry.BudgetBurst
+	}
+
+	if c.CircuitBreaker.Threshold == 0 {
+		c.CircuitBreaker.Threshold = defaults.CircuitBreaker.Threshold
+	}
+	if c.CircuitBreaker.Timeout == 0 {
+		c.CircuitBreaker.Timeout = defaults.CircuitBreaker.Timeout
+	}
+
+	if c.RateLimit.Rate == 0 {
+		c.RateLimit.Rate = defaults.RateLimit.Rate
+	}
+	if c.RateLimit.Burst == 0 {
+		c.RateLimit.Burst = defaults.RateLimit.Burst
+	}
+	if c.RateLimit.APIOperations == nil {
+		c.RateLimit.APIOperations = defaults.RateLimit.APIOperations
+	}
+	if c.RateLimit.Operations == nil {
+		c.RateLimit.Operations = defaults.RateLimit.Operations
+	}
+
+	if c.Sanity.Action == "" {
+		c.Sanity.Action = defaults.Sanity.Action
+	}
+	if c.Sanity.MaxInstanceDrop == nil {
+		c.Sanity.MaxInstanceDrop = defaults.Sanity.MaxInstanceDrop
+	}
+	if c.Sanity.MaxPriceChange == nil {
+		c.Sanity.MaxPriceChange = defaults.Sanity.MaxPriceChange
+	}
+	c.Sanity.AllowMissingCategories = c.Sanity.AllowMissingCategories || defaults.Sanity.AllowMissingCategories
+
+	if c.Schedule.Cron == "" {
+		c.Schedule.Cron = defaults.Schedule.Cron
+	}
+	if c.Schedule.Prices == "" {
+		c.Schedule.Prices = defaults.Schedule.Prices
+	}
+	if c.Schedule.Services == nil {
+		c.Schedule.Services = defaults.Schedule.Services
+	}
+	if c.Schedule.Jitter == 0 {
+		c.Schedule.Jitter = defaults.Schedule.Jitter
+	}
+	if c.Schedule.QuietWindows == nil {
+		c.Schedule.QuietWindows = defaults.Schedule.QuietWindows
+	}
+
+	if c.Regions.Include == nil {
+		c.Regions.Include = defaults.Regions.Include
+	}
+	if c.Regions.Exclude == nil {
+		c.Regions.Exclude = defaults.Regions.Exclude
+	}
+	if c.Regions.Services == nil {
+		c.Regions.Services = defaults.Regions.Services
+	}
+
+	if c.Events.SpotPriceThreshold == nil {
+		c.Events.SpotPriceThreshold = defaults.Events.SpotPriceThreshold
+	}
+
+	return c
+}
@@ -0,0 +1,119 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeConfig_WithDefaults(t *testing.T) {
+	defaults := ScrapeConfig{
+		Concurrency:    4,
+		Retry:          RetryConfig{MaxRetries: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute, BudgetRatio: 0.2, BudgetBurst: 10},
+		CircuitBreaker: CircuitBreakerConfig{Threshold: 5, Timeout: time.Minute},
+		RateLimit:      RateLimitConfig{RateLimit: RateLimit{Rate: 5, Burst: 1}},
+		Sanity:         SanityConfig{Action: SanityActionReject, MaxInstanceDrop: percent(50), MaxPriceChange: percent(90)},
+		Schedule:       ScheduleConfig{Cron: "@every 24h", Prices: "@every 4m", QuietWindows: []string{"08:00-18:00"}},
+		Regions:        RegionsConfig{RegionFilter: RegionFilter{Include: []string{"eu-*"}, Exclude: []string{}}},
+		Events:         EventsConfig{SpotPriceThreshold: percent(10)},
+	}
+
+	config := ScrapeConfig{
+		Concurrency:    8,
+		Retry:          RetryConfig{MaxRetries: -1},
+		CircuitBreaker: CircuitBreakerConfig{Timeout: time.Second},
+		RateLimit:      RateLimitConfig{Operations: map[string]RateLimit{"GetCurrentPrices": {Rate: 0.5}}},
+		Sanity:         SanityConfig{Action: SanityActionWarn, MaxPriceChange: percent(-1), AllowMissingCategories: true},
+		Schedule:       ScheduleConfig{Cron: "0 2 * * *", Jitter: time.Hour},
+		Regions:        RegionsConfig{RegionFilter: RegionFilter{Exclude: []string{"eu-south-*"}}},
+	}.WithDefaults(defaults)
+
+	assert.Equal(t, ScrapeConfig{
+		Concurrency:    8,
+		Retry:          RetryConfig{MaxRetries: -1, InitialBackoff: time.Second, MaxBackoff: time.Minute, BudgetRatio: 0.2, BudgetBurst: 10},
+		CircuitBreaker: CircuitBreakerConfig{Threshold: 5, Timeout: time.Second},
+		RateLimit:      RateLimitConfig{RateLimit: RateLimit{Rate: 5, Burst: 1}, Operations: map[string]RateLimit{"GetCurrentPrices": {Rate: 0.5}}},
+		Sanity:         SanityConfig{Action: SanityActionWarn, MaxInstanceDrop: percent(50), MaxPriceChange: percent(-1), AllowMissingCategories: true},
+		Schedule:       ScheduleConfig{Cron: "0 2 * * *", Prices: "@every 4m", Jitter: time.Hour, QuietWindows: []string{"08:00-18:00"}},
+		Regions:        RegionsConfig{RegionFilter: RegionFilter{Include: []string{"eu-*"}, Exclude: []string{"eu-south-*"}}},
+		Events:         EventsConfig{SpotPriceThreshold: percent(10)},
+	}, config)
+
+	assert.Equal(t, defaults, ScrapeConfig{}.WithDefaults(defaults))
+
+	// a zero threshold is kept, it accepts no change at all
+	sanity := ScrapeConfig{Sanity: SanityConfig{MaxInstanceDrop: percent(0)}}.WithDefaults(defaults).Sanity
+	assert.Equal(t, percent(0), sanity.MaxInstanceDrop)
+	assert.Equal(t, percent(90), sanity.MaxPriceChange)
+
+	// a zero spot price threshold is kept, it publishes every change
+	events := ScrapeConfig{Events: EventsConfig{SpotPriceThreshold: percent(0)}}.WithDefaults(defaults).Events
+	assert.Equal(t, percent(0), events.SpotPriceThreshold)
+}
+
+func TestSanityConfig_Validate(t *testing.T) {
+	assert.NoError(t, SanityConfig{}.Validate())
+	assert.NoError(t, SanityConfig{Action: SanityActionQuarantine}.Validate())
+	assert.Error(t, SanityConfig{Action: "drop"}.Validate())
+}
+
+func TestRateLimitConfig_Validate(t *testing.T) {
+	assert.NoError(t, RateLimitConfig{}.Validate())
+	assert.NoError(t, RateLimitConfig{RateLimit: RateLimit{Rate: 10}, Operations: map[string]RateLimit{"getcurrentprices": {Rate: 1, Burst: 2}}}.Validate())
+	assert.Error(t, RateLimitConfig{RateLimit: RateLimit{Burst: -1}}.Validate())
+	assert.Error(t, RateLimitConfig{Operations: map[string]RateLimit{"DescribeInstances": {Rate: 1}}}.Validate())
+	assert.Error(t, RateLimitConfig{Operations: map[string]RateLimit{"GetZones": {Burst: -1}}}.Validate())
+	assert.NoError(t, RateLimitConfig{APIOperations: []APIOperationRateLimit{{Host: ".amazonaws.com", RateLimit: RateLimit{Rate: 1}}}}.Validate())
+	assert.Error(t, RateLimitConfig{APIOperations: []APIOperationRateLimit{{Path: "/prices", RateLimit: RateLimit{Rate: 1}}}}.Validate())
+	assert.Error(t, RateLimitConfig{APIOperations: []APIOperationRateLimit{{Host: "api.example.com", RateLimit: RateLimit{Burst: -1}}}}.Validate())
+}
+
+func TestRegionsConfig_Allows(t *testing.T) {
+	config := RegionsConfig{
+		RegionFilter: RegionFilter{Include: []string{"eu-*", "us-east-1"}, Exclude: []string{"eu-south-*"}},
+		Services:     map[string]RegionFilter{"eks": {Include: []string{"eu-west-?"}}},
+	}
+
+	tests := []struct {
+		service string
+		region  string
+		allowed bool
+	}{
+		{service: "compute", region: "eu-west-1", allowed: true},
+		{service: "compute", region: "us-east-1", allowed: true},
+		{service: "compute", region: "us-east-2", allowed: false},
+		{service: "compute", region: "eu-south-1", allowed: false},
+		{service: "eks", region: "eu-west-3", allowed: true},
+		{service: "eks", region: "eu-central-1", allowed: false},
+		{service: "eks", region: "us-east-1", allowed: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, config.Allows(test.service, test.region), test.service+" "+test.region)
+	}
+
+	assert.True(t, RegionsConfig{}.Allows("compute", "ap-east-1"))
+	assert.True(t, NewRegionSelector(map[string]ScrapeConfig{"amazon": {Regions: config}})("google", "compute", "europe-west1"))
+	assert.False(t, NewRegionSelector(map[string]ScrapeConfig{"amazon": {Regions: config}})("amazon", "compute", "us-east-2"))
+}
+
+func TestRegionsConfig_Validate(t *testing.T) {
+	assert.NoError(t, RegionsConfig{RegionFilter: RegionFilter{Include: []string{"eu-*"}}}.Validate())
+	assert.Error(t, RegionsConfig{RegionFilter: RegionFilter{Exclude: []string{"eu-["}}}.Validate())
+	assert.Error(t, RegionsConfig{Services: map[string]RegionFilter{"eks": {Include: []string{"["}}}}.Validate())
+}
@@ -0,0 +1,123 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/messaging"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// ScrapeFilter selects the providers, services and regions to scrape, an empty list selects all of them
+type ScrapeFilter struct {
+	Providers []string
+	Services  []string
+	Regions   []string
+}
+
+// includes checks whether the value is selected by the list
+func (f ScrapeFilter) includes(selected []string, value string) bool {
+	return len(selected) == 0 || Contains(selected, value)
+}
+
+// ScrapeOnce scrapes the providers, services and regions selected by the filter a single time, nothing is scheduled
+// the selected providers are scraped in parallel, the returned error combines the failures of all of them
+func (sd *ScrapingDriver) ScrapeOnce(ctx context.Context, filter ScrapeFilter) error {
+	var managers []*scrapingManager
+	for _, manager := range sd.scrapingManagers {
+		if filter.includes(filter.Providers, manager.provider) {
+			managers = append(managers, manager)
+		}
+	}
+
+	if len(filter.Providers) > 0 && len(managers) < len(filter.Providers) {
+		return errors.NewWithDetails("unsupported provider", "providers", filter.Providers)
+	}
+
+	errs := make([]error, len(managers))
+	done := make(chan struct{})
+	for i, manager := range managers {
+		go func(i int, manager *scrapingManager) {
+			defer func() { done <- struct{}{} }()
+
+			errs[i] = manager.scrapeOnce(ctx, filter)
+		}(i, manager)
+	}
+
+	for range managers {
+		<-done
+	}
+
+	return errors.Combine(errs...)
+}
+
+// scrapeOnce scrapes the services and regions of the provider selected by the filter
+// the short lived prices are scraped first, so the product details hold them
+// the "scrape completed" event is published at the end unless the prices failed to initialize
+func (sm *scrapingManager) scrapeOnce(ctx context.Context, filter ScrapeFilter) error {
+	start := time.Now()
+	sm.publish(messaging.ScrapeStarted, "", "", nil)
+
+	if err := sm.initialize(ctx); err != nil {
+		return err
+	}
+
+	includeRegion := func(region string) bool {
+		return filter.includes(filter.Regions, region)
+	}
+
+	if sm.infoer.HasShortLivedPriceInfo() {
+		regions, err := sm.infoerFor(ctx).GetRegions("compute")
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "failed to retrieve regions", "provider", sm.provider)
+		}
+
+		regions = selectRegions(regions, func(region string) bool {
+			return includeRegion(region) && sm.config.Regions.Match(region)
+		})
+
+		sm.forEachRegion(ctx, regions, func(regionId string) {
+			sm.scrapePricesInRegion(ctx, regionId)
+		})
+	}
+
+	storedServices, ok := sm.store.GetServices(sm.provider)
+	if !ok {
+		return errors.NewWithDetails("failed to retrieve services", "provider", sm.provider)
+	}
+
+	services := make([]types.Service, 0, len(storedServices))
+	for _, service := range storedServices {
+		if filter.includes(filter.Services, service.ServiceName()) {
+			services = append(services, service)
+		}
+	}
+
+	// the status is updated even if some of the regions failed, the data of the rest of them is kept
+	err := sm.scrapeServiceRegionInfo(ctx, services, includeRegion)
+
+	sm.updateStatus(ctx)
+
+	// the subscribers (eg. the loaders of the derived services) complete the scraped data
+	sm.publish(messaging.ScrapeCompleted, "", "", nil)
+
+	sm.metrics.ReportScrapeProviderCompleted(sm.provider, start)
+
+	return errors.WithDetails(err, "provider", sm.provider)
+}
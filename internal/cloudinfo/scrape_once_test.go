@@ -0,0 +1,133 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// onceCloudInfoer has no prices to initialize
+type onceCloudInfoer struct {
+	dummyCloudInfoer
+}
+
+func (oci *onceCloudInfoer) Initialize() (map[string]map[string]types.Price, error) {
+	return nil, nil
+}
+
+func (oci *onceCloudInfoer) HasShortLivedPriceInfo() bool {
+	return false
+}
+
+// statusDataStore records the provider status
+type statusDataStore struct {
+	serviceRegionDataStore
+	status string
+}
+
+func (sds *statusDataStore) StoreStatus(provider string, val string) {
+	sds.status = val
+}
+
+func TestScrapingDriver_ScrapeOnce(t *testing.T) {
+	tests := map[string]struct {
+		filter ScrapeFilter
+		check  func(t *testing.T, store *statusDataStore, err error)
+	}{
+		"selected region": {
+			filter: ScrapeFilter{Providers: []string{"dummy"}, Services: []string{"compute"}, Regions: []string{"eu-west-1"}},
+			check: func(t *testing.T, store *statusDataStore, err error) {
+				assert.NoError(t, err)
+				assert.Contains(t, store.regions, "eu-west-1")
+				assert.NotContains(t, store.statuses, "broken")
+				assert.NotEmpty(t, store.status)
+			},
+		},
+		"failed region": {
+			filter: ScrapeFilter{},
+			check: func(t *testing.T, store *statusDataStore, err error) {
+				assert.Error(t, err)
+
+				// the data of the successful regions is kept
+				assert.Contains(t, store.regions, "eu-west-1")
+				assert.NotEmpty(t, store.status)
+			},
+		},
+		"unselected service": {
+			filter: ScrapeFilter{Services: []string{"pke"}},
+			check: func(t *testing.T, store *statusDataStore, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, store.regions)
+			},
+		},
+		"unsupported provider": {
+			filter: ScrapeFilter{Providers: []string{"dummy", "other"}},
+			check: func(t *testing.T, store *statusDataStore, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, store.status)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			store := &statusDataStore{serviceRegionDataStore: serviceRegionDataStore{regionDataStore{
+				regions:  make(map[string]RegionData),
+				statuses: make(map[string]types.RegionStatus),
+			}}}
+			driver := NewScrapingDriver(map[string]CloudInfoer{"dummy": &onceCloudInfoer{}}, store, nil,
+				map[string]ScrapeConfig{"dummy": {Concurrency: 2, RunHistory: 10, Retry: RetryConfig{MaxRetries: -1}, CircuitBreaker: CircuitBreakerConfig{Threshold: -1}}},
+				NewLeaderElector(NewLocalLock(), LeaderElectionConfig{}, cloudinfoLogger), nil,
+				metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), emperror.NoopHandler{}, cloudinfoLogger)
+
+			test.check(t, store, driver.ScrapeOnce(context.Background(), test.filter))
+		})
+	}
+}
+
+// failingPricesCloudInfoer fails to initialize the prices
+type failingPricesCloudInfoer struct {
+	onceCloudInfoer
+}
+
+func (fci *failingPricesCloudInfoer) Initialize() (map[string]map[string]types.Price, error) {
+	return nil, errors.New("failed to get prices")
+}
+
+func TestScrapingDriver_ScrapeOnce_InitializeFailure(t *testing.T) {
+	store := &statusDataStore{serviceRegionDataStore: serviceRegionDataStore{regionDataStore{
+		regions:  make(map[string]RegionData),
+		statuses: make(map[string]types.RegionStatus),
+	}}}
+	driver := NewScrapingDriver(map[string]CloudInfoer{"dummy": &failingPricesCloudInfoer{}}, store, nil,
+		map[string]ScrapeConfig{"dummy": {Concurrency: 2, Retry: RetryConfig{MaxRetries: -1}, CircuitBreaker: CircuitBreakerConfig{Threshold: -1}}},
+		NewLeaderElector(NewLocalLock(), LeaderElectionConfig{}, cloudinfoLogger), nil,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), emperror.NoopHandler{}, cloudinfoLogger)
+
+	err := driver.ScrapeOnce(context.Background(), ScrapeFilter{})
+	assert.EqualError(t, err, "failed to initialize cloud product information: failed to get prices")
+	assert.Empty(t, store.regions)
+}
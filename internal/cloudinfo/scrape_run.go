@@ -0,0 +1,157 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// newScrapeRun creates the record of a scrape run of a service in a region ending now
+func newScrapeRun(provider, service, region string, start time.Time, data RegionData, err error) types.ScrapeRun {
+	run := types.ScrapeRun{
+		Provider: provider,
+		Service:  service,
+		Region:   region,
+		Start:    start,
+		End:      time.Now(),
+		Outcome:  types.ScrapeRunSucceeded,
+		Zones:    len(data.Zones),
+		Vms:      len(data.Vms),
+		Images:   len(data.Images),
+		Versions: len(data.Versions),
+	}
+
+	if err != nil {
+		run.Outcome = types.ScrapeRunFailed
+		if errors.Is(err, errScrapeRejected) {
+			run.Outcome = types.ScrapeRunRejected
+		}
+		run.Error = err.Error()
+	}
+
+	return run
+}
+
+// recordScrapeRuns appends the scrape runs to the recorded ones of the provider
+func (sm *scrapingManager) recordScrapeRuns(runs []types.ScrapeRun) {
+	sm.runsMu.Lock()
+	defer sm.runsMu.Unlock()
+
+	recorded, _ := sm.store.GetScrapeRuns(sm.provider)
+	sm.store.StoreScrapeRuns(sm.provider, appendScrapeRuns(recorded, runs, sm.config.RunHistory))
+}
+
+// appendScrapeRuns appends the scrape runs to the recorded ones in chronological order
+// only the last runs are kept per service and region, up to the given number
+func appendScrapeRuns(recorded, runs []types.ScrapeRun, keep int) []types.ScrapeRun {
+	all := make([]types.ScrapeRun, 0, len(recorded)+len(runs))
+	all = append(all, recorded...)
+	all = append(all, runs...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Start.Before(all[j].Start)
+	})
+
+	// the runs are counted from the newest one
+	counts := make(map[[2]string]int)
+	kept := make([]types.ScrapeRun, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		key := [2]string{all[i].Service, all[i].Region}
+		if counts[key] >= keep {
+			continue
+		}
+
+		counts[key]++
+		kept = append(kept, all[i])
+	}
+
+	// restore the chronological order
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+
+	return kept
+}
+
+// ScrapeRunSource retrieves scrape runs.
+type ScrapeRunSource interface {
+	// GetScrapeRuns returns the recorded scrape runs of the provider matching the filter in chronological order
+	GetScrapeRuns(provider string, filter types.ScrapeRunFilter) ([]types.ScrapeRun, error)
+}
+
+// ScrapeRunService returns the recorded scrape runs of the providers.
+type ScrapeRunService struct {
+	source ScrapeRunSource
+}
+
+// NewScrapeRunService returns a new ScrapeRunService.
+func NewScrapeRunService(source ScrapeRunSource) *ScrapeRunService {
+	return &ScrapeRunService{
+		source: source,
+	}
+}
+
+// ScrapeRunQueryValidationError is returned if a scrape run query is invalid.
+type ScrapeRunQueryValidationError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e ScrapeRunQueryValidationError) Error() string {
+	return e.Message
+}
+
+// IsBusinessError tells the transport layer whether this error should be translated into the transport format
+// or an internal error should be returned instead.
+func (ScrapeRunQueryValidationError) IsBusinessError() bool {
+	return true
+}
+
+// ValidateScrapeRunOutcome returns an error if the outcome is not a known one, the empty outcome is accepted.
+func ValidateScrapeRunOutcome(outcome string) error {
+	switch outcome {
+	case "", types.ScrapeRunSucceeded, types.ScrapeRunFailed, types.ScrapeRunRejected:
+		return nil
+	default:
+		return errors.WithStack(ScrapeRunQueryValidationError{
+			Message: "outcome must be one of success, failure and rejected",
+		})
+	}
+}
+
+// GetScrapeRuns returns the recorded scrape runs of the provider matching the filter.
+func (s *ScrapeRunService) GetScrapeRuns(ctx context.Context, provider string, filter types.ScrapeRunFilter) ([]types.ScrapeRun, error) {
+	if provider == "" {
+		return nil, errors.WithStack(ScrapeRunQueryValidationError{
+			Message: "provider field must not be empty",
+		})
+	}
+
+	if err := ValidateScrapeRunOutcome(filter.Outcome); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.source.GetScrapeRuns(provider, filter)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to retrieve scrape runs")
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,100 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+func TestNewScrapeRun(t *testing.T) {
+	start := time.Now()
+	data := RegionData{Zones: []string{"eu-west-1a"}, Vms: []types.VMInfo{{Type: "m5.large"}, {Type: "m5.xlarge"}}}
+
+	run := newScrapeRun("amazon", "compute", "eu-west-1", start, data, nil)
+	assert.Equal(t, types.ScrapeRunSucceeded, run.Outcome)
+	assert.Equal(t, 1, run.Zones)
+	assert.Equal(t, 2, run.Vms)
+	assert.False(t, run.End.Before(start))
+
+	run = newScrapeRun("amazon", "compute", "eu-west-1", start, data, errors.New("failed to get versions"))
+	assert.Equal(t, types.ScrapeRunFailed, run.Outcome)
+	assert.Equal(t, "failed to get versions", run.Error)
+
+	run = newScrapeRun("amazon", "compute", "eu-west-1", start, data, errors.WithDetails(errScrapeRejected, "action", "reject"))
+	assert.Equal(t, types.ScrapeRunRejected, run.Outcome)
+}
+
+func TestAppendScrapeRuns(t *testing.T) {
+	at := func(minutes int) time.Time {
+		return time.Date(2021, 3, 1, 10, minutes, 0, 0, time.UTC)
+	}
+
+	recorded := []types.ScrapeRun{
+		{Service: "compute", Region: "eu-west-1", Start: at(0)},
+		{Service: "compute", Region: "eu-west-2", Start: at(1)},
+		{Service: "compute", Region: "eu-west-1", Start: at(10)},
+	}
+	runs := []types.ScrapeRun{
+		{Service: "compute", Region: "eu-west-1", Start: at(21)},
+		{Service: "compute", Region: "eu-west-2", Start: at(20)},
+	}
+
+	assert.Equal(t, []types.ScrapeRun{
+		{Service: "compute", Region: "eu-west-2", Start: at(1)},
+		{Service: "compute", Region: "eu-west-1", Start: at(10)},
+		{Service: "compute", Region: "eu-west-2", Start: at(20)},
+		{Service: "compute", Region: "eu-west-1", Start: at(21)},
+	}, appendScrapeRuns(recorded, runs, 2))
+}
+
+// scrapeRunSource returns fixed scrape runs
+type scrapeRunSource struct{}
+
+func (scrapeRunSource) GetScrapeRuns(provider string, filter types.ScrapeRunFilter) ([]types.ScrapeRun, error) {
+	runs := []types.ScrapeRun{
+		{Provider: provider, Service: "compute", Region: "eu-west-1", Outcome: types.ScrapeRunSucceeded},
+		{Provider: provider, Service: "compute", Region: "broken", Outcome: types.ScrapeRunFailed},
+	}
+
+	matching := make([]types.ScrapeRun, 0, len(runs))
+	for _, run := range runs {
+		if filter.Match(run) {
+			matching = append(matching, run)
+		}
+	}
+
+	return matching, nil
+}
+
+func TestScrapeRunService_GetScrapeRuns(t *testing.T) {
+	service := NewScrapeRunService(scrapeRunSource{})
+
+	runs, err := service.GetScrapeRuns(context.Background(), "amazon", types.ScrapeRunFilter{Outcome: types.ScrapeRunFailed})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.ScrapeRun{{Provider: "amazon", Service: "compute", Region: "broken", Outcome: types.ScrapeRunFailed}}, runs)
+
+	_, err = service.GetScrapeRuns(context.Background(), "", types.ScrapeRunFilter{})
+	assert.IsType(t, ScrapeRunQueryValidationError{}, errors.Cause(err))
+
+	_, err = service.GetScrapeRuns(context.Background(), "amazon", types.ScrapeRunFilter{Outcome: "crashed"})
+	assert.IsType(t, ScrapeRunQueryValidationError{}, errors.Cause(err))
+}
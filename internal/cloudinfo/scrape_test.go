@@ -0,0 +1,450 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"emperror.dev/emperror"
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/cloudinfo/internal/app/cloudinfo/tracing"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/metrics"
+	"github.com/banzaicloud/cloudinfo/internal/cloudinfo/types"
+)
+
+// dummyCloudInfoer returns fixed region information, the versions fail for the "broken" region
+type dummyCloudInfoer struct {
+	// implement the interface
+	CloudInfoer
+}
+
+func (dci *dummyCloudInfoer) GetZones(region string) ([]string, error) {
+	return []string{region + "a"}, nil
+}
+
+func (dci *dummyCloudInfoer) GetProducts(vms []types.VMInfo, service, regionId string) ([]types.VMInfo, error) {
+	return []types.VMInfo{{Type: "m5.large"}, {Type: "free"}}, nil
+}
+
+func (dci *dummyCloudInfoer) GetRegions(service string) (map[string]string, error) {
+	return map[string]string{"eu-west-1": "EU (Ireland)", "broken": "Broken"}, nil
+}
+
+func (dci *dummyCloudInfoer) HasImages() bool {
+	return false
+}
+
+func (dci *dummyCloudInfoer) GetVersions(service, region string) ([]types.LocationVersion, error) {
+	if region == "broken" {
+		return nil, errors.New("failed to get versions")
+	}
+
+	return []types.LocationVersion{{Location: region, Versions: []string{"1.21"}}}, nil
+}
+
+// regionDataStore records the region data, statuses and scrape runs written
+type regionDataStore struct {
+	// implement the interface
+	CloudInfoStore
+	regions  map[string]RegionData
+	statuses map[string]types.RegionStatus
+	vms      []types.VMInfo
+	runs     []types.ScrapeRun
+	// storeErr fails the region data writes
+	storeErr error
+	mu       sync.Mutex
+}
+
+func (rds *regionDataStore) GetScrapeRuns(provider string) ([]types.ScrapeRun, bool) {
+	return rds.runs, rds.runs != nil
+}
+
+func (rds *regionDataStore) StoreScrapeRuns(provider string, val []types.ScrapeRun) {
+	rds.runs = val
+}
+
+func (rds *regionDataStore) GetVm(provider, service, region string) ([]types.VMInfo, bool) {
+	return rds.vms, rds.vms != nil
+}
+
+func (rds *regionDataStore) StoreRegions(provider, service string, val map[string]string) {
+}
+
+func (rds *regionDataStore) GetImage(provider, service, region string) ([]types.Image, bool) {
+	return nil, false
+}
+
+func (rds *regionDataStore) GetVersion(provider, service, region string) ([]types.LocationVersion, bool) {
+	return nil, false
+}
+
+func (rds *regionDataStore) GetRegionStatus(provider, service, region string) (types.RegionStatus, bool) {
+	rds.mu.Lock()
+	defer rds.mu.Unlock()
+
+	status, ok := rds.statuses[region]
+	return status, ok
+}
+
+func (rds *regionDataStore) StoreRegionStatus(provider, service, region string, val types.RegionStatus) {
+	rds.mu.Lock()
+	defer rds.mu.Unlock()
+
+	rds.statuses[region] = val
+}
+
+func (rds *regionDataStore) GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool) {
+	return map[string]types.Price{"m5.large": {OnDemandPrice: 0.1, SpotPrice: types.SpotPriceInfo{region + "a": 0.05}}}, true
+}
+
+func (rds *regionDataStore) StoreRegionData(provider, service, region string, data RegionData) error {
+	rds.mu.Lock()
+	defer rds.mu.Unlock()
+
+	if rds.storeErr != nil {
+		return rds.storeErr
+	}
+
+	rds.regions[region] = data
+	rds.statuses[region] = data.Status
+
+	return nil
+}
+
+func TestScrapingManager_scrapeServiceRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  string
+		checker func(regions map[string]RegionData, err error)
+	}{
+		{
+			name:   "region data stored at once",
+			region: "eu-west-1",
+			checker: func(regions map[string]RegionData, err error) {
+				assert.Nil(t, err, "the error should be nil")
+
+				data := regions["eu-west-1"]
+				assert.False(t, data.Status.Stale)
+				assert.WithinDuration(t, time.Now(), data.Status.LastSuccess, time.Minute)
+				data.Status = types.RegionStatus{}
+
+				assert.Equal(t, RegionData{
+					Generation: 42,
+					Zones:      []string{"eu-west-1a"},
+					Vms:        []types.VMInfo{{Type: "m5.large", OnDemandPrice: 0.1}},
+					ProductDetails: []types.ProductDetails{{
+						VMInfo: types.VMInfo{Type: "m5.large", OnDemandPrice: 0.1, SpotPrice: []types.ZonePrice{{Zone: "eu-west-1a", Price: 0.05}}},
+					}},
+					Versions: []types.LocationVersion{{Location: "eu-west-1", Versions: []string{"1.21"}}},
+				}, data)
+			},
+		},
+		{
+			name:   "nothing stored when a part of the scrape fails",
+			region: "broken",
+			checker: func(regions map[string]RegionData, err error) {
+				assert.EqualError(t, err, "failed to scrape versions for region: failed to retrieve service versions for region: failed to get versions")
+				assert.Empty(t, regions)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &regionDataStore{regions: make(map[string]RegionData), statuses: make(map[string]types.RegionStatus)}
+			sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, ScrapeConfig{Concurrency: 2}, cloudinfoLogger,
+				metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+			_, err := sm.scrapeServiceRegion(context.Background(), "compute", test.region, 42)
+			test.checker(store.regions, err)
+		})
+	}
+}
+
+func TestScrapingManager_scrapeServiceRegionInfo_Stale(t *testing.T) {
+	lastSuccess := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	store := &regionDataStore{
+		regions:  make(map[string]RegionData),
+		statuses: map[string]types.RegionStatus{"broken": {LastSuccess: lastSuccess}},
+	}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, ScrapeConfig{Concurrency: 2, RunHistory: 10}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	err := sm.scrapeServiceRegionInfo(context.Background(), []types.Service{{Service: "compute"}}, allRegions)
+	assert.Error(t, err)
+
+	// the runs of both regions are recorded
+	outcomes := make(map[string]string)
+	for _, run := range store.runs {
+		outcomes[run.Region] = run.Outcome
+	}
+	assert.Equal(t, map[string]string{"eu-west-1": types.ScrapeRunSucceeded, "broken": types.ScrapeRunFailed}, outcomes)
+
+	// the failed region keeps its previous data, flagged stale
+	assert.Equal(t, types.RegionStatus{LastSuccess: lastSuccess, Stale: true}, store.statuses["broken"])
+	assert.False(t, store.statuses["eu-west-1"].Stale)
+	assert.NotContains(t, store.regions, "broken")
+}
+
+func TestScrapingManager_scrapeServiceRegionInfo_Regions(t *testing.T) {
+	store := &regionDataStore{regions: make(map[string]RegionData), statuses: make(map[string]types.RegionStatus)}
+	config := ScrapeConfig{Concurrency: 2, RunHistory: 10, Regions: RegionsConfig{RegionFilter: RegionFilter{Exclude: []string{"bro*"}}}}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, config, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	err := sm.scrapeServiceRegionInfo(context.Background(), []types.Service{{Service: "compute"}}, allRegions)
+	assert.NoError(t, err)
+
+	// the excluded region is not scraped at all
+	assert.Contains(t, store.regions, "eu-west-1")
+	assert.NotContains(t, store.statuses, "broken")
+	assert.Len(t, store.runs, 1)
+}
+
+func TestScrapingManager_scrapeServiceRegionInfo_Cancelled(t *testing.T) {
+	lastSuccess := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	store := &regionDataStore{
+		regions:  make(map[string]RegionData),
+		statuses: map[string]types.RegionStatus{"broken": {LastSuccess: lastSuccess}},
+	}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, ScrapeConfig{Concurrency: 2, RunHistory: 10}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	// eg. the replica lost the leadership of the provider
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := sm.scrapeServiceRegionInfo(ctx, []types.Service{{Service: "compute"}}, allRegions)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// nothing is stored, the regions are left to the new leader
+	assert.Empty(t, store.regions)
+	assert.Empty(t, store.runs)
+	assert.Equal(t, types.RegionStatus{LastSuccess: lastSuccess}, store.statuses["broken"])
+}
+
+// staticDataStore has the loaded data of static services, it records the scraped data
+type staticDataStore struct {
+	regionDataStore
+	zones    map[string][]string
+	versions map[string][]types.LocationVersion
+}
+
+func (sds *staticDataStore) GetZones(provider, service, region string) ([]string, bool) {
+	zones, ok := sds.zones[region]
+	return zones, ok
+}
+
+func (sds *staticDataStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	return nil, false
+}
+
+func (sds *staticDataStore) GetVersion(provider, service, region string) ([]types.LocationVersion, bool) {
+	versions, ok := sds.versions[region]
+	return versions, ok
+}
+
+func (sds *staticDataStore) GetRegions(provider, service string) (map[string]string, bool) {
+	return nil, false
+}
+
+func (sds *staticDataStore) StoreRegionData(provider, service, region string, data RegionData) error {
+	if err := sds.regionDataStore.StoreRegionData(provider, service, region, data); err != nil {
+		return err
+	}
+
+	sds.zones[region] = data.Zones
+	sds.versions[region] = data.Versions
+
+	return nil
+}
+
+func TestScrapingManager_scrapeStaticServiceData(t *testing.T) {
+	tests := map[string]struct {
+		service types.Service
+		check   func(t *testing.T, store *staticDataStore, err error)
+	}{
+		"nothing scraped": {
+			service: types.Service{Service: "pke", IsStatic: true},
+			check: func(t *testing.T, store *staticDataStore, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[string][]string{"eu-west-1": {"static"}, "broken": {"static"}}, store.zones)
+				assert.Empty(t, store.regions)
+			},
+		},
+		"scraped zones": {
+			service: types.Service{Service: "pke", IsStatic: true, ScrapedData: []string{types.ZonesData}},
+			check: func(t *testing.T, store *staticDataStore, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[string][]string{"eu-west-1": {"eu-west-1a"}, "broken": {"brokena"}}, store.zones)
+
+				// the loaded data of the rest of the kinds is stored with the scraped zones
+				assert.Equal(t, []types.LocationVersion{{Location: "static"}}, store.regions["eu-west-1"].Versions)
+			},
+		},
+		"failing scrape": {
+			service: types.Service{Service: "pke", IsStatic: true, ScrapedData: []string{types.ZonesData, types.VersionsData}},
+			check: func(t *testing.T, store *staticDataStore, err error) {
+				assert.Error(t, err)
+				assert.Equal(t, []interface{}{"provider", "dummy", "service", "pke", "region", "broken", "data", "versions"}, errors.GetDetails(err))
+
+				// the rest of the regions are scraped, the failed one keeps its data flagged stale
+				assert.Contains(t, store.regions, "eu-west-1")
+				assert.NotContains(t, store.regions, "broken")
+				assert.Equal(t, []string{"static"}, store.zones["broken"])
+				assert.True(t, store.statuses["broken"].Stale)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			store := &staticDataStore{
+				regionDataStore: regionDataStore{regions: make(map[string]RegionData), statuses: make(map[string]types.RegionStatus)},
+				zones:           map[string][]string{"eu-west-1": {"static"}, "broken": {"static"}},
+				versions:        map[string][]types.LocationVersion{"eu-west-1": {{Location: "static"}}, "broken": {{Location: "static"}}},
+			}
+			sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, ScrapeConfig{Concurrency: 2}, cloudinfoLogger,
+				metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+			test.check(t, store, sm.scrapeStaticServiceData(context.Background(), test.service, allRegions))
+		})
+	}
+}
+
+func TestScrapingManager_updateVirtualMachines(t *testing.T) {
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, &regionDataStore{}, nil, ScrapeConfig{Concurrency: 2}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	vms, _ := sm.updateVirtualMachines("eu-west-1",
+		[]types.VMInfo{{Type: "m5.large"}, {Type: "m5.xlarge"}, {Type: "m5.2xlarge"}},
+		[]types.VMInfo{{Type: "m5.xlarge", OnDemandPrice: 0.2}},
+	)
+
+	// the price of m5.large is stored, the last known price of m5.xlarge is kept, m5.2xlarge has no price at all
+	assert.Equal(t, []types.VMInfo{{Type: "m5.large", OnDemandPrice: 0.1}, {Type: "m5.xlarge", OnDemandPrice: 0.2}}, vms)
+}
+
+// appendedPrices records the appended price points
+type appendedPrices struct {
+	// implement the interface
+	PriceHistoryStore
+	appended []map[string]types.Price
+}
+
+func (ap *appendedPrices) AppendPrices(provider, region string, at time.Time, prices map[string]types.Price) error {
+	ap.appended = append(ap.appended, prices)
+	return nil
+}
+
+func TestScrapingManager_recordPrices(t *testing.T) {
+	history := &appendedPrices{}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, &regionDataStore{}, history, ScrapeConfig{Concurrency: 2}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	sm.recordPrices("eu-west-1", map[string]types.Price{
+		"m5.large":  {OnDemandPrice: 0.1, SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.05}},
+		"m5.xlarge": {OnDemandPrice: 0.2},
+	})
+	// spot price scrapes come without on demand prices
+	sm.recordPrices("eu-west-1", map[string]types.Price{
+		"m5.large":  {OnDemandPrice: -1, SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.05}},
+		"m5.xlarge": {OnDemandPrice: -1, SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.07}},
+	})
+	sm.recordPrices("eu-west-1", map[string]types.Price{
+		"m5.large": {OnDemandPrice: 0.1},
+	})
+
+	assert.Equal(t, []map[string]types.Price{
+		{
+			"m5.large":  {OnDemandPrice: 0.1, SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.05}},
+			"m5.xlarge": {OnDemandPrice: 0.2},
+		},
+		{
+			"m5.xlarge": {OnDemandPrice: 0.2, SpotPrice: types.SpotPriceInfo{"eu-west-1a": 0.07}},
+		},
+	}, history.appended)
+}
+
+// viewStore has product views for some of the services, it records the refreshed views
+type viewStore struct {
+	regionDataStore
+	views     map[string][]types.ProductDetails
+	refreshed []string
+}
+
+func (vs *viewStore) GetServices(provider string) ([]types.Service, bool) {
+	return []types.Service{{Service: "compute"}, {Service: "pke", IsStatic: true}, {Service: "static", IsStatic: true}}, true
+}
+
+func (vs *viewStore) GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool) {
+	details, ok := vs.views[service]
+	return details, ok
+}
+
+func (vs *viewStore) StoreProductDetails(provider, service, region string, val []types.ProductDetails) {
+	vs.refreshed = append(vs.refreshed, service)
+}
+
+func TestScrapingManager_updateProductDetails(t *testing.T) {
+	store := &viewStore{
+		regionDataStore: regionDataStore{vms: []types.VMInfo{{Type: "m5.large"}}},
+		views:           map[string][]types.ProductDetails{"pke": {{VMInfo: types.VMInfo{Type: "m5.large"}}}},
+	}
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, store, nil, ScrapeConfig{Concurrency: 1}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	sm.updateProductDetails("eu-west-1")
+
+	// the static service with a scraped view is refreshed too, the one without a view is merged when read
+	assert.Equal(t, []string{"compute", "pke"}, store.refreshed)
+}
+
+func TestScrapingManager_forEachRegion(t *testing.T) {
+	sm := NewScrapingManager("dummy", &dummyCloudInfoer{}, &regionDataStore{}, nil, ScrapeConfig{Concurrency: 2}, cloudinfoLogger,
+		metrics.NewNoOpMetricsReporter(), tracing.NewNoOpTracer(), nil, emperror.NoopHandler{})
+
+	regions := map[string]string{"r1": "", "r2": "", "r3": "", "r4": "", "r5": ""}
+
+	var (
+		mu                sync.Mutex
+		running, maxCount int
+		visited           = make(map[string]bool)
+	)
+	sm.forEachRegion(context.Background(), regions, func(regionId string) {
+		mu.Lock()
+		running++
+		if running > maxCount {
+			maxCount = running
+		}
+		visited[regionId] = true
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	assert.Len(t, visited, len(regions))
+	assert.Equal(t, 2, maxCount)
+}
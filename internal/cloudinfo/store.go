@@ -21,6 +21,9 @@ import (
 )
 
 const (
+	// KeyPrefix the common prefix of all the cloud information keys
+	KeyPrefix = "/banzaicloud.com/cloudinfo/"
+
 	// vmKeyTemplate format for generating vm cache keys
 	VmKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/services/%s/regions/%s/vms"
 
@@ -44,8 +47,43 @@ const (
 
 	// servicesKeyTemplate key for storing provider specific services
 	ServicesKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/services"
+
+	// ProductDetailsKeyTemplate format for generating the keys of the merged vm and price views
+	ProductDetailsKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/services/%s/regions/%s/products"
+
+	// GenerationKeyTemplate format for generating the keys of the region data generations
+	GenerationKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/services/%s/regions/%s/generation"
+
+	// RegionStatusKeyTemplate format for generating the keys of the region scrape statuses
+	RegionStatusKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/services/%s/regions/%s/status"
+
+	// ScrapeRunsKeyTemplate format for generating the keys of the recorded scrape runs of the providers
+	ScrapeRunsKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/runs"
+
+	// QuarantineKeyTemplate format for generating the keys of the quarantined scrape results
+	QuarantineKeyTemplate = "/banzaicloud.com/cloudinfo/providers/%s/services/%s/regions/%s/quarantine"
 )
 
+// RegionData holds the scraped data of a service in a region
+// it's written at once, so readers see either the previous or the new generation of the data
+type RegionData struct {
+	// Generation identifies the scrape the data comes from (the start of the scrape in milliseconds)
+	Generation int64
+
+	Zones    []string
+	Vms      []types.VMInfo
+	Versions []types.LocationVersion
+
+	// ProductDetails are only written if not nil (the product view isn't precomputed for every service)
+	ProductDetails []types.ProductDetails
+
+	// Images are only written if not nil (not all the providers support images)
+	Images []types.Image
+
+	// Status the scrape status stored with the data
+	Status types.RegionStatus
+}
+
 // Storage operations for cloud information
 type CloudInfoStore interface {
 	Ready() bool
@@ -60,11 +98,18 @@ type CloudInfoStore interface {
 
 	StorePrice(provider, region, instanceType string, val types.Price)
 	GetPrice(provider, region, instanceType string) (types.Price, bool)
+	// GetPrices retrieves the prices of the instance types in a region in a single read
+	// missing prices are left out of the result, false is returned if none of them is found
+	GetPrices(provider, region string, instanceTypes []string) (map[string]types.Price, bool)
 
 	StoreVm(provider, service, region string, val []types.VMInfo)
 	GetVm(provider, service, region string) ([]types.VMInfo, bool)
 	DeleteVm(provider, service, region string)
 
+	StoreProductDetails(provider, service, region string, val []types.ProductDetails)
+	GetProductDetails(provider, service, region string) ([]types.ProductDetails, bool)
+	DeleteProductDetails(provider, service, region string)
+
 	StoreImage(provider, service, regionId string, val []types.Image)
 	GetImage(provider, service, regionId string) ([]types.Image, bool)
 	DeleteImage(provider, service, regionId string)
@@ -73,6 +118,22 @@ type CloudInfoStore interface {
 	GetVersion(provider, service, region string) ([]types.LocationVersion, bool)
 	DeleteVersion(provider, service, region string)
 
+	// StoreRegionData atomically replaces the data of the service in the region, nothing is replaced if an error is returned
+	StoreRegionData(provider, service, region string, data RegionData) error
+	GetGeneration(provider, service, region string) (int64, bool)
+
+	// StoreRegionStatus replaces the scrape status of the service in the region, eg. to mark its data stale
+	StoreRegionStatus(provider, service, region string, val types.RegionStatus)
+	GetRegionStatus(provider, service, region string) (types.RegionStatus, bool)
+
+	// StoreQuarantine saves aside the scrape result of the service in the region that failed the sanity checks
+	StoreQuarantine(provider, service, region string, val types.QuarantinedScrape)
+	GetQuarantine(provider, service, region string) (types.QuarantinedScrape, bool)
+
+	// StoreScrapeRuns replaces the recorded scrape runs of the provider
+	StoreScrapeRuns(provider string, val []types.ScrapeRun)
+	GetScrapeRuns(provider string) ([]types.ScrapeRun, bool)
+
 	StoreStatus(provider string, val string)
 	GetStatus(provider string) (string, bool)
 
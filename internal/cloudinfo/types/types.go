@@ -15,6 +15,7 @@
 package types
 
 import (
+	"sort"
 	"strings"
 	"time"
 )
@@ -55,6 +56,15 @@ type CloudInfo interface {
 	GetContinentsData(provider, service string) (map[string][]Region, error)
 
 	GetContinents() []string
+
+	// GetRegionStatus returns the scrape status of the service in the region, false if the region hasn't been scraped yet
+	GetRegionStatus(provider, service, region string) (RegionStatus, bool)
+
+	// GetPriceHistory returns the recorded prices of an instance type in a time range
+	GetPriceHistory(provider, region, instanceType string, from, to time.Time) ([]PricePoint, error)
+
+	// GetScrapeRuns returns the recorded scrape runs of the provider matching the filter in chronological order
+	GetScrapeRuns(provider string, filter ScrapeRunFilter) ([]ScrapeRun, error)
 }
 
 const (
@@ -158,8 +168,18 @@ type ServiceDescriber interface {
 type Service struct {
 	Service  string `json:"service"`
 	IsStatic bool   `json:"isStatic"`
+	// ScrapedData lists the data kinds of a static service that are scraped instead of loaded
+	ScrapedData []string `json:"scrapedData,omitempty"`
 }
 
+// data kinds of a service
+const (
+	ZonesData    = "zones"
+	VmsData      = "vms"
+	ImagesData   = "images"
+	VersionsData = "versions"
+)
+
 // ServiceName returns the service name
 func (s Service) ServiceName() string {
 	return s.Service
@@ -221,6 +241,86 @@ func (v Version) VersionName() string {
 type Region struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+
+	// Stale is set if the last scrape of the region failed, the data of the region is from the last successful scrape
+	Stale bool `json:"stale"`
+	// LastSuccess the time of the last successful scrape of the region
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// SnapshotTime the time of the snapshot the data of the region was restored from, unset once it's scraped again
+	SnapshotTime *time.Time `json:"snapshotTime,omitempty"`
+}
+
+// RegionStatus the scrape status of the data of a service in a region
+type RegionStatus struct {
+	// LastSuccess the time of the last successful scrape, zero if the region hasn't been scraped successfully yet
+	LastSuccess time.Time `json:"lastSuccess"`
+	// Stale is set if the last scrape failed, the data is kept from the last successful scrape
+	Stale bool `json:"stale"`
+	// SnapshotTime the time of the snapshot the data was restored from at startup, unset once it's scraped again
+	SnapshotTime *time.Time `json:"snapshotTime,omitempty"`
+}
+
+// LastSuccessTime returns the time of the last successful scrape, nil if there was none
+func (s RegionStatus) LastSuccessTime() *time.Time {
+	if s.LastSuccess.IsZero() {
+		return nil
+	}
+
+	return &s.LastSuccess
+}
+
+const (
+	// ScrapeRunSucceeded the outcome of a successful scrape run
+	ScrapeRunSucceeded = "success"
+	// ScrapeRunFailed the outcome of a scrape run failing to retrieve the data
+	ScrapeRunFailed = "failure"
+	// ScrapeRunRejected the outcome of a scrape run whose result failed the sanity checks
+	ScrapeRunRejected = "rejected"
+)
+
+// ScrapeRun a scrape of the data of a service in a region
+type ScrapeRun struct {
+	Provider string `json:"provider"`
+	Service  string `json:"service"`
+	// Region is empty if the scrape failed before getting to the regions, eg. the regions couldn't be retrieved
+	Region string    `json:"region,omitempty"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	// Outcome is one of success, failure and rejected
+	Outcome string `json:"outcome"`
+	// Error the error message of a failed or rejected scrape run
+	Error string `json:"error,omitempty"`
+	// the number of the scraped items
+	Zones    int `json:"zones"`
+	Vms      int `json:"vms"`
+	Images   int `json:"images"`
+	Versions int `json:"versions"`
+}
+
+// ScrapeRunFilter selects scrape runs, the empty fields match every run
+type ScrapeRunFilter struct {
+	Service string
+	Region  string
+	Outcome string
+}
+
+// Match returns true if the scrape run is selected by the filter
+func (f ScrapeRunFilter) Match(run ScrapeRun) bool {
+	return (f.Service == "" || f.Service == run.Service) &&
+		(f.Region == "" || f.Region == run.Region) &&
+		(f.Outcome == "" || f.Outcome == run.Outcome)
+}
+
+// QuarantinedScrape the scrape result of a service in a region that failed the sanity checks, kept for inspection
+type QuarantinedScrape struct {
+	// Time the time of the scrape
+	Time time.Time `json:"time"`
+	// Reasons the failed checks
+	Reasons []string `json:"reasons"`
+	// Vms the scraped instance types
+	Vms []VMInfo `json:"vms"`
+	// PromotedAt the time the operator promoted the scrape result in place of the stored data
+	PromotedAt *time.Time `json:"promotedAt,omitempty"`
 }
 
 // SpotPriceInfo represents different prices per availability zones
@@ -232,6 +332,32 @@ type Price struct {
 	SpotPrice     SpotPriceInfo `json:"spotPrice"`
 }
 
+// PricePoint the price of an instance type from a point in time (till the next point)
+type PricePoint struct {
+	Time          time.Time   `json:"time"`
+	OnDemandPrice float64     `json:"onDemandPrice"`
+	SpotPrice     []ZonePrice `json:"spotPrice"`
+}
+
+// NewPricePoint creates a new price point, the spot prices are sorted by zone
+func NewPricePoint(at time.Time, price Price) PricePoint {
+	pp := PricePoint{
+		Time:          at,
+		OnDemandPrice: price.OnDemandPrice,
+		SpotPrice:     make([]ZonePrice, 0, len(price.SpotPrice)),
+	}
+
+	for zone, zonePrice := range price.SpotPrice {
+		pp.SpotPrice = append(pp.SpotPrice, *NewZonePrice(zone, zonePrice))
+	}
+
+	sort.Slice(pp.SpotPrice, func(i, j int) bool {
+		return pp.SpotPrice[i].Zone < pp.SpotPrice[j].Zone
+	})
+
+	return pp
+}
+
 // VMInfo representation of a virtual machine
 type VMInfo struct {
 	Category      string            `json:"category"`
@@ -0,0 +1,37 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"os"
+	"path/filepath"
+
+	"emperror.dev/errors"
+	"go.etcd.io/bbolt"
+)
+
+// NewDB opens the database file, the missing directories are created.
+func NewDB(config Config) (*bbolt.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to create database directory", "path", config.Path)
+	}
+
+	db, err := bbolt.Open(config.Path, 0600, &bbolt.Options{Timeout: config.Timeout})
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to open database", "path", config.Path)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,45 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// Config holds information necessary for opening the embedded Bolt database.
+type Config struct {
+	// Path is the location of the database file, it's created if it doesn't exist.
+	Path string
+
+	// Timeout is the time to wait for the lock on the database file.
+	Timeout time.Duration
+
+	Enabled bool
+}
+
+// Validate checks that the configuration is valid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Path == "" {
+		return errors.New("bolt path is required")
+	}
+
+	return nil
+}
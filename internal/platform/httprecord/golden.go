@@ -0,0 +1,63 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// TestingT is the subset of testing.T used by the golden file assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// AssertGolden compares the JSON encoding of the value with the content of the golden file.
+// The golden file is written instead if update is set (eg. by the -update flag of the test).
+func AssertGolden(t TestingT, path string, value interface{}, update bool) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode value: %v", err)
+	}
+	actual = append(actual, '\n')
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run the test with -update to create it): %v", err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%s does not match (run the test with -update to rewrite it)\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httprecord records the HTTP traffic of the cloud provider clients into cassettes and replays it,
+// so the cloud infoers can be exercised without credentials.
+package httprecord
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// volatileParams lists the query and form parameters that differ between two runs of the same request
+// (signatures, nonces, timestamps, credentials), they are left out of the recorded requests
+var volatileParams = map[string]bool{
+	"accesskeyid":          true,
+	"endtime":              true,
+	"signature":            true,
+	"signaturenonce":       true,
+	"starttime":            true,
+	"timestamp":            true,
+	"x-amz-credential":     true,
+	"x-amz-date":           true,
+	"x-amz-security-token": true,
+	"x-amz-signature":      true,
+}
+
+// Cassette holds the recorded interactions of a cloud provider.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and the response received to it.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request, without its headers and volatile parameters.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Transport records the requests passing through it, or replays the responses recorded before.
+// Token requests of OAuth2 flows are never recorded: they are passed through while recording
+// and answered with a placeholder token while replaying.
+type Transport struct {
+	// base sends the requests while recording, it's nil while replaying
+	base http.RoundTripper
+
+	cassette Cassette
+
+	// the number of times the recorded interactions were replayed per request
+	replayed map[string]int
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a transport recording the interactions of the requests sent with the base transport.
+// The default transport is used if base is nil.
+func NewRecorder(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{base: base}
+}
+
+// NewReplayer returns a transport replaying the interactions of the cassette.
+func NewReplayer(cassette Cassette) *Transport {
+	return &Transport{cassette: cassette, replayed: make(map[string]int)}
+}
+
+// Load returns a transport replaying the interactions of the cassette file.
+func Load(path string) (*Transport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to read cassette", "path", path)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to decode cassette", "path", path)
+	}
+
+	return NewReplayer(cassette), nil
+}
+
+// Client returns an HTTP client sending its requests through the transport.
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Cassette returns the interactions recorded so far.
+func (t *Transport) Cassette() Cassette {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return Cassette{Interactions: append([]Interaction(nil), t.cassette.Interactions...)}
+}
+
+// Save writes the recorded interactions into the cassette file.
+func (t *Transport) Save(path string) error {
+	data, err := json.MarshalIndent(t.Cassette(), "", "  ")
+	if err != nil {
+		return errors.WrapIf(err, "failed to encode cassette")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to create cassette directory", "path", path)
+	}
+
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return errors.WrapIfWithDetails(err, "failed to write cassette", "path", path)
+	}
+
+	return nil
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	request := newRequest(req, body)
+
+	if isTokenRequest(req, body) {
+		if t.base == nil {
+			return placeholderToken(req), nil
+		}
+
+		return t.base.RoundTrip(req)
+	}
+
+	if t.base == nil {
+		return t.replay(req, request)
+	}
+
+	return t.record(req, request)
+}
+
+func (t *Transport) record(req *http.Request, request Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := readResponseBody(resp)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to read response", "method", request.Method, "url", request.URL)
+	}
+
+	// the body is recorded decoded, so the headers describing the encoding of the original one are left out
+	header := resp.Header.Clone()
+	for _, key := range []string{"Set-Cookie", "Content-Encoding", "Content-Length", "Transfer-Encoding"} {
+		header.Del(key)
+	}
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request:  request,
+		Response: Response{StatusCode: resp.StatusCode, Header: header, Body: string(data)},
+	})
+	t.mu.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(data))
+
+	return resp, nil
+}
+
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	gr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	return ioutil.ReadAll(gr)
+}
+
+// replay returns the recorded responses of the request in the order they were recorded,
+// the last one is returned again once all of them were replayed
+// a request not recorded is answered with 501 Not Implemented
+func (t *Transport) replay(req *http.Request, request Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var matches []Response
+	for _, interaction := range t.cassette.Interactions {
+		if interaction.Request == request {
+			matches = append(matches, interaction.Response)
+		}
+	}
+
+	// the clients retry the failed requests (some of them for minutes), they don't retry a 501 response though
+	if len(matches) == 0 {
+		return newResponse(req, Response{
+			StatusCode: http.StatusNotImplemented,
+			Header:     http.Header{"Content-Type": []string{"text/plain"}},
+			Body:       fmt.Sprintf("no recorded interaction for request: %s %s %s", request.Method, request.URL, request.Body),
+		}), nil
+	}
+
+	key := request.Method + " " + request.URL + " " + request.Body
+	n := t.replayed[key]
+	t.replayed[key] = n + 1
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+
+	return newResponse(req, matches[n]), nil
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to read request body")
+	}
+	_ = req.Body.Close()
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// newRequest returns the request as it's recorded, the volatile parameters of the query and the form body are left out
+func newRequest(req *http.Request, body []byte) Request {
+	u := *req.URL
+	u.RawQuery = stableParams(u.Query())
+	u.User = nil
+
+	request := Request{Method: req.Method, URL: u.String(), Body: string(body)}
+
+	if isForm(req) {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			request.Body = stableParams(form)
+		}
+	}
+
+	return request
+}
+
+func stableParams(params url.Values) string {
+	for key := range params {
+		if volatileParams[strings.ToLower(key)] {
+			params.Del(key)
+		}
+	}
+
+	// url.Values.Encode sorts the parameters by key
+	return params.Encode()
+}
+
+func isForm(req *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+// isTokenRequest checks whether the request obtains an OAuth2 access token
+func isTokenRequest(req *http.Request, body []byte) bool {
+	if req.Method != http.MethodPost || !isForm(req) {
+		return false
+	}
+
+	form, err := url.ParseQuery(string(body))
+
+	return err == nil && form.Get("grant_type") != ""
+}
+
+func placeholderToken(req *http.Request) *http.Response {
+	expiresIn := int64(time.Hour / time.Second)
+
+	data, _ := json.Marshal(map[string]string{
+		"access_token": "replayed",
+		"token_type":   "Bearer",
+		"expires_in":   strconv.FormatInt(expiresIn, 10),
+		"expires_on":   strconv.FormatInt(time.Now().Unix()+expiresIn, 10),
+		"resource":     req.URL.Query().Get("resource"),
+	})
+
+	return newResponse(req, Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       string(data),
+	})
+}
+
+func newResponse(req *http.Request, response Response) *http.Response {
+	header := response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(response.StatusCode) + " " + http.StatusText(response.StatusCode),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(response.Body)),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}
+}
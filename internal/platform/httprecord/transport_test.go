@@ -0,0 +1,181 @@
+// Copyright © 2021 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprecord
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_RecordReplay(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(w)
+			_, _ = gw.Write([]byte("compressed"))
+			_ = gw.Close()
+
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte(r.URL.Query().Get("page") + string(body)))
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder(nil)
+	client := recorder.Client()
+
+	get := func(client *http.Client, rawurl string) string {
+		resp, err := client.Get(rawurl)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	assert.Equal(t, "1", get(client, server.URL+"/items?page=1&Timestamp=100&Signature=abc"))
+	assert.Equal(t, "2", get(client, server.URL+"/items?page=2&Timestamp=200&Signature=def"))
+	assert.Equal(t, "compressed", get(client, server.URL+"/gzip"))
+
+	resp, err := client.Post(server.URL+"/form", "application/x-www-form-urlencoded", strings.NewReader("b=2&a=1&SignatureNonce=x"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	cassette := recorder.Cassette()
+	require.Len(t, cassette.Interactions, 4)
+	assert.Equal(t, server.URL+"/items?page=1", cassette.Interactions[0].Request.URL)
+	assert.Empty(t, cassette.Interactions[0].Response.Header.Get("Set-Cookie"))
+	assert.Equal(t, "compressed", cassette.Interactions[2].Response.Body)
+	assert.Equal(t, "a=1&b=2", cassette.Interactions[3].Request.Body)
+
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+	require.NoError(t, recorder.Save(path))
+
+	replayer, err := Load(path)
+	require.NoError(t, err)
+	client = replayer.Client()
+
+	recorded := calls
+	assert.Equal(t, "2", get(client, server.URL+"/items?Signature=ghi&page=2&Timestamp=300"))
+	assert.Equal(t, "1", get(client, server.URL+"/items?page=1"))
+	assert.Equal(t, "compressed", get(client, server.URL+"/gzip"))
+
+	resp, err = client.Post(server.URL+"/form", "application/x-www-form-urlencoded", strings.NewReader("a=1&b=2"))
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "b=2&a=1&SignatureNonce=x", string(body))
+
+	assert.Equal(t, recorded, calls, "the replayer must not send requests")
+
+	resp, err = client.Get(server.URL + "/items?page=3")
+	require.NoError(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	assert.Equal(t, "no recorded interaction for request: GET "+server.URL+"/items?page=3 ", string(body))
+}
+
+func TestTransport_ReplayOrder(t *testing.T) {
+	request := Request{Method: http.MethodGet, URL: "https://example.com/status"}
+	replayer := NewReplayer(Cassette{Interactions: []Interaction{
+		{Request: request, Response: Response{StatusCode: http.StatusServiceUnavailable}},
+		{Request: request, Response: Response{StatusCode: http.StatusOK, Body: "ok"}},
+	}})
+	client := replayer.Client()
+
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		resp, err := client.Get(request.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, status, resp.StatusCode)
+	}
+}
+
+func TestTransport_TokenRequest(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"access_token":"secret"}`))
+	}))
+	defer server.Close()
+
+	form := url.Values{"grant_type": {"client_credentials"}, "client_secret": {"secret"}}
+
+	recorder := NewRecorder(nil)
+	resp, err := recorder.Client().PostForm(server.URL+"/token", form)
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, string(body), "secret")
+	assert.Empty(t, recorder.Cassette().Interactions, "token requests must not be recorded")
+
+	resp, err = NewReplayer(Cassette{}).Client().PostForm(server.URL+"/token?resource=https://management.azure.com/", form)
+	require.NoError(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, string(body), `"access_token":"replayed"`)
+	assert.Contains(t, string(body), `"resource":"https://management.azure.com/"`)
+}
+
+type goldenT struct {
+	*testing.T
+
+	failed bool
+}
+
+func (t *goldenT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+}
+
+func TestAssertGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	value := map[string]int{"b": 2, "a": 1}
+
+	AssertGolden(t, path, value, true)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("{\n  \"a\": 1")))
+
+	AssertGolden(t, path, value, false)
+
+	gt := &goldenT{T: t}
+	AssertGolden(gt, path, map[string]int{"a": 2}, false)
+	assert.True(t, gt.failed)
+}